@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/geoip"
+)
+
+// geoRequired — включена ли функция, которой нужна база стран.
+func geoRequired(cfg *config.Config) bool {
+	return cfg.ImpossibleTravel || cfg.CountryGrouping || (cfg.Shadow != nil && cfg.Shadow.CountryGrouping)
+}
+
+// openGeoDatabase открывает базу стран для IMPOSSIBLE_TRAVEL и
+// COUNTRY_GROUPING. Недостающий файл скачивается, только если база нужна и
+// задан MAXMIND_LICENSE_KEY; существующий открывается всегда, чтобы режим можно
+// было включить из /settings. В отличие от базы ASN ошибка не фатальна:
+// возвращается nil, и страны IP не определяются.
+func openGeoDatabase(cfg *config.Config, logger *logrus.Logger) *geoip.CityResolver {
+	dbPath := cfg.GeoDatabasePath
+	required := geoRequired(cfg)
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		if !required {
+			return nil
+		}
+		if cfg.MaxMindLicenseKey == "" {
+			logger.Warnf("База стран %s не найдена и MAXMIND_LICENSE_KEY не задан — IMPOSSIBLE_TRAVEL и COUNTRY_GROUPING не работают", dbPath)
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+			logger.Warnf("Не удалось создать директорию для базы %s: %v — страны IP не определяются", cfg.GeoDatabaseEdition, err)
+			return nil
+		}
+		logger.Infof("Файл базы %s не найден, скачиваю через MaxMind...", dbPath)
+		dl := &geoip.Downloader{
+			LicenseKey: cfg.MaxMindLicenseKey,
+			Edition:    cfg.GeoDatabaseEdition,
+			Validate:   geoip.DefaultValidate,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		err := dl.Download(ctx, dbPath)
+		cancel()
+		if err != nil {
+			logger.Warnf("Ошибка загрузки базы %s: %v — страны IP не определяются", cfg.GeoDatabaseEdition, err)
+			return nil
+		}
+	}
+
+	db, err := geoip.NewCityResolver(dbPath)
+	if err != nil {
+		logger.Warnf("Не удалось открыть базу %s: %v — страны IP не определяются", dbPath, err)
+		return nil
+	}
+	logger.WithFields(logrus.Fields{
+		"db":               dbPath,
+		"impossibleTravel": cfg.ImpossibleTravel,
+	}).Info("База стран загружена")
+	return db
+}
+
+// asnClasses собирает классы ASN из ASN_MOBILE, ASN_RESIDENTIAL и ASN_HOSTING.
+// Пересечения списков отклоняет config.Validate.
+func asnClasses(cfg *config.Config) map[uint32]string {
+	classes := make(map[uint32]string)
+	for class, list := range map[string][]uint32{
+		geoip.ClassMobile:      cfg.ASNMobile,
+		geoip.ClassResidential: cfg.ASNResidential,
+		geoip.ClassHosting:     cfg.ASNHosting,
+	} {
+		for _, asn := range list {
+			classes[asn] = class
+		}
+	}
+	return classes
+}
@@ -6,14 +6,17 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
+	"github.com/remnawave/limiter/internal/admin"
 	"github.com/remnawave/limiter/internal/config"
 	"github.com/remnawave/limiter/internal/monitor"
+	"github.com/remnawave/limiter/internal/panelhook"
 	"github.com/remnawave/limiter/internal/version"
 )
 
-func startHealthServer(ctx context.Context, addr string, mon *monitor.Monitor, cfgProvider *config.Provider, logger *logrus.Logger) {
+func startHealthServer(ctx context.Context, addr string, mon *monitor.Monitor, cfgProvider *config.Provider, adminAPI *admin.Server, panelHook *panelhook.Receiver, logger *logrus.Logger) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		last := mon.LastSuccessfulCheck()
@@ -40,6 +43,17 @@ func startHealthServer(ctx context.Context, addr string, mon *monitor.Monitor, c
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "ok: last check %s ago, v%s\n", age, version.Version)
 	})
+	mux.Handle("/metrics", promhttp.Handler())
+
+	endpoints := "/healthz, /metrics"
+	if adminAPI != nil {
+		adminAPI.Register(mux)
+		endpoints += ", /api/v1"
+	}
+	if panelHook != nil {
+		panelHook.Register(mux)
+		endpoints += ", " + panelhook.Path
+	}
 
 	srv := &http.Server{
 		Addr:              addr,
@@ -55,7 +69,7 @@ func startHealthServer(ctx context.Context, addr string, mon *monitor.Monitor, c
 	}()
 
 	go func() {
-		logger.Infof("Health endpoint слушает %s/healthz", addr)
+		logger.Infof("Health endpoint слушает %s (%s)", addr, endpoints)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.WithError(err).Error("Health endpoint остановлен с ошибкой")
 		}
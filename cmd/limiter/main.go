@@ -6,17 +6,21 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/remnawave/limiter/internal/admin"
 	"github.com/remnawave/limiter/internal/api"
 	"github.com/remnawave/limiter/internal/cache"
 	"github.com/remnawave/limiter/internal/config"
 	"github.com/remnawave/limiter/internal/geoip"
 	"github.com/remnawave/limiter/internal/i18n"
 	"github.com/remnawave/limiter/internal/monitor"
+	"github.com/remnawave/limiter/internal/notify"
+	"github.com/remnawave/limiter/internal/panelhook"
 	"github.com/remnawave/limiter/internal/settings"
 	"github.com/remnawave/limiter/internal/telegram"
 	"github.com/remnawave/limiter/internal/version"
@@ -27,15 +31,12 @@ func main() {
 	os.Exit(run())
 }
 
-func groupingMode(cfg *config.Config) string {
-	switch {
-	case cfg.ASNGrouping:
-		return "asn"
-	case cfg.SubnetGrouping:
-		return fmt.Sprintf("subnet/%d", cfg.SubnetPrefixV4)
-	default:
-		return "ip"
+func joinSelectors(selectors []config.NodeSelector) string {
+	parts := make([]string, len(selectors))
+	for i, sel := range selectors {
+		parts[i] = sel.String()
 	}
+	return strings.Join(parts, ",")
 }
 
 func run() int {
@@ -103,6 +104,15 @@ func run() int {
 	default:
 		logger.Info("ASN enrichment отключён: MAXMIND_LICENSE_KEY не задан и файл базы не найден")
 	}
+	if classes := asnClasses(cfg); len(classes) > 0 && maxmindLoaded {
+		resolver = geoip.NewClassifier(resolver, classes)
+		logger.Infof("Классификация ASN включена: %d ASN", len(classes))
+	}
+
+	geoDB := openGeoDatabase(cfg, logger)
+	if geoDB != nil {
+		defer geoDB.Close()
+	}
 
 	redisCache, err := cache.New(cfg.RedisURL)
 	if err != nil {
@@ -141,11 +151,46 @@ func run() int {
 		"mode":     cfg.ActionMode,
 		"interval": fmt.Sprintf("%ds", cfg.CheckInterval),
 		"api":      cfg.RemnawaveAPIURL,
-		"grouping": groupingMode(cfg),
+		"grouping": cfg.GroupingMode(),
+	}
+	switch cfg.GroupingMode() {
+	case "subnet":
+		startupFields["subnetPrefix"] = fmt.Sprintf("/%d,/%d", cfg.SubnetPrefixV4, cfg.SubnetPrefixV6)
+	case "hybrid":
+		startupFields["subnetPrefix"] = fmt.Sprintf("/%d,/%d", cfg.SubnetPrefixV4, monitor.HybridPrefixV6)
+	}
+	if cfg.Scoring && (cfg.ViolationThreshold > 1 || cfg.AutoNotifySoft) {
+		logger.Warn("SCORING включён — VIOLATION_THRESHOLD и AUTO_NOTIFY_SOFT не используются, действия выбирают пороги SCORE_*")
+	}
+	if len(asnClasses(cfg)) > 0 && !maxmindLoaded {
+		logger.Warn("Заданы ASN_MOBILE/ASN_RESIDENTIAL/ASN_HOSTING, но MaxMind ASN база не загружена — классы ASN не определяются")
+	}
+	if cfg.VPNResellerAlert && len(cfg.ASNHosting) == 0 {
+		logger.Warn("VPN_RESELLER_ALERT включён, но ASN_HOSTING пуст — алерт не сработает")
+	}
+	if len(cfg.EscalationLadder) > 0 {
+		steps := make([]string, len(cfg.EscalationLadder))
+		for i, s := range cfg.EscalationLadder {
+			steps[i] = s.String()
+		}
+		startupFields["escalation"] = strings.Join(steps, ",")
 	}
 	if len(cfg.IgnoredNodeUUIDs) > 0 {
 		startupFields["ignoredNodes"] = len(cfg.IgnoredNodeUUIDs)
 	}
+	if len(cfg.NodeInclude) > 0 {
+		startupFields["nodeInclude"] = joinSelectors(cfg.NodeInclude)
+	}
+	if len(cfg.NodeExclude) > 0 {
+		startupFields["nodeExclude"] = joinSelectors(cfg.NodeExclude)
+	}
+	if len(cfg.NodeIntervals) > 0 {
+		intervals := make([]string, len(cfg.NodeIntervals))
+		for i, ni := range cfg.NodeIntervals {
+			intervals[i] = fmt.Sprintf("%s=%ds", ni.Selector, ni.Interval)
+		}
+		startupFields["nodeIntervals"] = strings.Join(intervals, ";")
+	}
 	logger.WithFields(startupFields).Info("Конфигурация")
 
 	if err := redisCache.InitWhitelist(ctx, cfg.WhitelistUserIDs); err != nil {
@@ -174,57 +219,97 @@ func run() int {
 	}
 	logger.Info("Telegram бот подключён")
 
-	var webhookClient *webhook.Client
-	if cfg.WebhookURL != "" {
-		webhookClient = webhook.NewClient(cfg.WebhookURL, cfg.WebhookSecret, logger)
-		logger.Info("Webhook включён")
+	var webhookDispatcher *webhook.Dispatcher
+	if len(cfg.Webhooks) > 0 {
+		webhookDispatcher = webhook.NewDispatcher(cfg.Webhooks, redisCache, cfg.WebhookMaxAttempts, logger)
+		logger.Infof("Webhook включён (endpoint'ов: %d)", len(cfg.Webhooks))
 	}
 
-	mon, err := monitor.New(cfgProvider, apiClient, redisCache, bot, webhookClient, resolver, logger)
+	mon, err := monitor.New(cfgProvider, apiClient, redisCache, bot, webhookDispatcher, resolver, logger)
 	if err != nil {
 		logger.Errorf("Ошибка монитора: %v", err)
 		return 1
 	}
+	if geoDB != nil {
+		mon.SetLocationResolver(geoDB)
+	}
 
 	settingsMgr := settings.NewManager(cfgProvider, redisCache, "", appliedOverrides)
 	bot.SetSettingsProvider(settingsMgr)
+	settingsMgr.SetChangeHandler(mon.ConfigChanged)
 	bot.SetStatsHandler(mon.StatsText)
+	bot.SetUserHandler(mon.UserCard)
 
-	bot.SetActionHandler(func(ctx context.Context, action string, userID int64) error {
-		switch action {
-		case "drop":
-			return apiClient.DropConnections(ctx, []int64{userID})
-		case "disable":
-			return apiClient.DisableUser(ctx, userID)
-		case "disable_temp":
-			if err := apiClient.DisableUser(ctx, userID); err != nil {
-				return err
-			}
-			if dur := cfgProvider.Load().AutoDisableDuration; dur > 0 {
-				duration := time.Duration(dur) * time.Minute
-				if err := redisCache.SetRestoreTimer(ctx, userID, duration); err != nil {
-					logger.WithError(err).WithField("userID", userID).Error("Ошибка установки таймера восстановления (manual disable_temp)")
-					return err
-				}
-			}
-			return nil
-		case "enable":
-			return apiClient.EnableUser(ctx, userID)
-		case "ignore":
-			return redisCache.AddToWhitelist(ctx, userID)
-		case "ignore_temp":
-			ttl := time.Duration(cfgProvider.Load().IgnoreDuration) * time.Minute
-			return redisCache.AddToWhitelistTemp(ctx, userID, ttl)
+	bot.SetActionHandler(mon.HandleAction)
+
+	// /start и /stop принимает тот же бот, который шлёт уведомления.
+	notifyBot := bot
+	if cfg.UserNotifyBotToken != "" {
+		notifyBot, err = telegram.NewBot(cfg.UserNotifyBotToken, 0, 0, nil, cfg.TelegramProxy, logger)
+		if err != nil {
+			logger.Errorf("Ошибка Telegram-бота уведомлений: %v", err)
+			return 1
 		}
-		return nil
-	})
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		logger.Errorf("Неверная таймзона %q: %v", cfg.Timezone, err)
+		return 1
+	}
+	notifier, err := notify.New(notifyBot, redisCache, cfgProvider, loc, logger)
+	if err != nil {
+		logger.Errorf("Ошибка уведомлений пользователям: %v", err)
+		return 1
+	}
+	notifyBot.SetSubscriptionHandler(notifier.HandleSubscription)
+	mon.SetNotifier(notifier)
+	if cfg.UserNotify {
+		logger.WithField("separateBot", notifyBot != bot).Info("Уведомления пользователям включены")
+	}
+	if cfg.UserPrefetch {
+		logger.WithFields(logrus.Fields{
+			"interval": cfg.UserPrefetchInterval,
+			"pageSize": cfg.UserPrefetchPageSize,
+		}).Info("Фоновая загрузка пользователей включена")
+	}
 
-	if cfg.ASNGrouping && cfg.SubnetGrouping {
-		logger.Warn("Включены оба режима ASN_GROUPING и SUBNET_GROUPING — приоритет у ASN, подсети будут игнорироваться")
+	if cfg.ASNGrouping && cfg.SubnetGrouping && !cfg.HybridGrouping && !cfg.CountryGrouping {
+		logger.Warn("Включены оба режима ASN_GROUPING и SUBNET_GROUPING — приоритет у ASN, подсети будут игнорироваться. Для совместной работы включите HYBRID_GROUPING")
+	}
+	if cfg.CountryGrouping && cfg.HybridGrouping {
+		logger.Warn("COUNTRY_GROUPING включён вместе с HYBRID_GROUPING — приоритет у стран")
+	}
+	if cfg.HybridGrouping && !maxmindLoaded {
+		logger.Warn("HYBRID_GROUPING включён, но MaxMind ASN база не загружена — все IP будут группироваться по подсетям")
+	}
+	if cfg.CountryGrouping && (cfg.ASNGrouping || cfg.SubnetGrouping) {
+		logger.Warn("COUNTRY_GROUPING включён вместе с ASN_GROUPING или SUBNET_GROUPING — приоритет у стран")
+	}
+	if cfg.ImpossibleTravel && cfg.GeoDatabaseEdition == "GeoLite2-Country" {
+		logger.Warn("IMPOSSIBLE_TRAVEL включён с базой GeoLite2-Country — без координат расстояние не определить и алерты не сработают. Используйте GeoLite2-City")
+	}
+	if cfg.CountryGrouping && geoDB == nil {
+		logger.Warn("COUNTRY_GROUPING включён, но база стран не загружена — каждый IP будет считаться отдельной группой")
 	}
 	if cfg.ASNGrouping && !maxmindLoaded {
 		logger.Warn("ASN_GROUPING включён, но MaxMind ASN база не загружена — все IP без ASN будут считаться отдельными группами")
 	}
+	if len(cfg.EscalationLadder) > 0 {
+		if cfg.ActionMode != "auto" && len(cfg.LimitPolicies) == 0 {
+			logger.Warn("ESCALATION_LADDER задан, но ACTION_MODE=manual — лестница применяется только в режиме auto")
+		}
+		if cfg.EscalationWindowDays > cfg.ViolationHistoryDays {
+			logger.Warnf("ESCALATION_WINDOW_DAYS (%d) больше VIOLATION_HISTORY_DAYS (%d) — старые нарушения уже удалены из истории и не будут учтены",
+				cfg.EscalationWindowDays, cfg.ViolationHistoryDays)
+		}
+	}
+	if cfg.Shadow != nil {
+		logger.WithFields(logrus.Fields{
+			"mode":     cfg.Shadow.ActionMode,
+			"grouping": cfg.Shadow.GroupingMode(),
+			"changes":  len(cfg.ShadowOverrides),
+		}).Info("Shadow-режим включён: кандидатный конфиг считается параллельно, без действий")
+	}
 	if cfg.ActiveIPWindow < 2*cfg.CheckInterval {
 		logger.Warnf("ACTIVE_IP_WINDOW (%dс) меньше двух интервалов проверки (%dс) — часть подключений может не попасть в подсчёт",
 			cfg.ActiveIPWindow, 2*cfg.CheckInterval)
@@ -234,9 +319,29 @@ func run() int {
 	defer stop()
 
 	if cfg.HealthAddr != "" {
-		startHealthServer(sigCtx, cfg.HealthAddr, mon, cfgProvider, logger)
+		var adminAPI *admin.Server
+		if cfg.AdminAPIToken != "" {
+			adminAPI = admin.New(redisCache, cfgProvider, cfg.AdminAPIToken, logger)
+			adminAPI.SetActionHandler(mon.HandleAction)
+		}
+		var panelHook *panelhook.Receiver
+		if cfg.RemnawaveWebhookSecret != "" {
+			panelHook = panelhook.New(cfg.RemnawaveWebhookSecret, mon.HandlePanelEvent, logger)
+		}
+		startHealthServer(sigCtx, cfg.HealthAddr, mon, cfgProvider, adminAPI, panelHook, logger)
+	} else {
+		if cfg.AdminAPIToken != "" {
+			logger.Warn("ADMIN_API_TOKEN задан, но HEALTH_ADDR пуст — admin API не запущен")
+		}
+		if cfg.RemnawaveWebhookSecret != "" {
+			logger.Warn("REMNAWAVE_WEBHOOK_SECRET задан, но HEALTH_ADDR пуст — приём webhook панели не запущен")
+		}
 	}
 
+	startupPrefixV6 := cfg.SubnetPrefixV6
+	if cfg.GroupingMode() == "hybrid" {
+		startupPrefixV6 = monitor.HybridPrefixV6
+	}
 	startupMsg := telegram.FormatStartupMessage(
 		version.Version,
 		cfg.ActionMode,
@@ -247,10 +352,13 @@ func run() int {
 		cfg.DefaultDeviceLimit,
 		cfg.AutoDisableDuration,
 		cfg.AutoNotifySoft,
-		cfg.WebhookURL != "",
+		len(cfg.Webhooks) > 0,
 		cfg.SubnetGrouping,
 		cfg.SubnetPrefixV4,
+		startupPrefixV6,
 		cfg.ASNGrouping,
+		cfg.CountryGrouping,
+		cfg.HybridGrouping,
 		maxmindLoaded,
 		cfg.ViolationThreshold,
 		cfg.ViolationThresholdWindow,
@@ -276,7 +384,29 @@ func run() int {
 		logger.Infof("Авто-обновление базы ASN включено, интервал: %v", cfg.MaxMindUpdateInterval)
 	}
 
+	if geoDB != nil && cfg.MaxMindLicenseKey != "" {
+		updater := &geoip.Updater{
+			Downloader: &geoip.Downloader{
+				LicenseKey: cfg.MaxMindLicenseKey,
+				Edition:    cfg.GeoDatabaseEdition,
+				Validate:   geoip.DefaultValidate,
+			},
+			Reloader: geoDB,
+			DstPath:  cfg.GeoDatabasePath,
+			Interval: cfg.MaxMindUpdateInterval,
+			Logger:   logger,
+		}
+		go updater.Run(sigCtx)
+		logger.Infof("Авто-обновление базы %s включено, интервал: %v", cfg.GeoDatabaseEdition, cfg.MaxMindUpdateInterval)
+	}
+
+	if webhookDispatcher != nil {
+		go webhookDispatcher.Run(sigCtx)
+	}
 	go bot.StartPolling(sigCtx)
+	if notifyBot != bot {
+		go notifyBot.StartPolling(sigCtx)
+	}
 	mon.Run(sigCtx)
 
 	logger.Info("Remnawave Limiter остановлен")
@@ -0,0 +1,372 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+const (
+	defaultTopN = 10
+
+	maxActionBody = 4 << 10
+
+	actionTimeout = 30 * time.Second
+)
+
+type Store interface {
+	WhitelistMembers(ctx context.Context) ([]int64, error)
+	ListTempWhitelist(ctx context.Context) ([]cache.TimedEntry, error)
+	ListCooldowns(ctx context.Context) ([]cache.TimedEntry, error)
+	ListSoftCooldowns(ctx context.Context) ([]cache.TimedEntry, error)
+	ListRestoreTimers(ctx context.Context) ([]cache.TimedEntry, error)
+	GetViolationStats(ctx context.Context, topN int) (*cache.ViolationStats, error)
+	GetConfigOverrides(ctx context.Context) (map[string]string, error)
+	GetHistory(ctx context.Context, userID int64, since time.Time) ([]cache.HistoryEntry, error)
+	ListDeadWebhooks(ctx context.Context) ([]cache.WebhookJob, error)
+	ReplayDeadWebhook(ctx context.Context, id string) (bool, error)
+	DeleteDeadWebhook(ctx context.Context, id string) (bool, error)
+}
+
+type ActionHandler func(ctx context.Context, action string, userID int64) error
+
+type Server struct {
+	store    Store
+	cfg      *config.Provider
+	token    string
+	logger   *logrus.Logger
+	onAction ActionHandler
+}
+
+func New(store Store, cfg *config.Provider, token string, logger *logrus.Logger) *Server {
+	return &Server{store: store, cfg: cfg, token: token, logger: logger}
+}
+
+func (s *Server) SetActionHandler(handler ActionHandler) {
+	s.onAction = handler
+}
+
+func (s *Server) Register(mux *http.ServeMux) {
+	mux.Handle("GET /api/v1/whitelist", s.auth(s.handleWhitelist))
+	mux.Handle("GET /api/v1/cooldowns", s.auth(s.handleCooldowns))
+	mux.Handle("GET /api/v1/restores", s.auth(s.handleRestores))
+	mux.Handle("GET /api/v1/stats", s.auth(s.handleStats))
+	mux.Handle("GET /api/v1/config", s.auth(s.handleConfig))
+	mux.Handle("GET /api/v1/users/{id}/history", s.auth(s.handleHistory))
+	mux.Handle("POST /api/v1/users/{id}/actions/{action}", s.auth(s.handleAction))
+	mux.Handle("GET /api/v1/webhooks/dead", s.auth(s.handleDeadWebhooks))
+	mux.Handle("POST /api/v1/webhooks/dead/replay", s.auth(s.handleReplayAllWebhooks))
+	mux.Handle("POST /api/v1/webhooks/dead/{id}/replay", s.auth(s.handleReplayWebhook))
+	mux.Handle("DELETE /api/v1/webhooks/dead/{id}", s.auth(s.handleDeleteWebhook))
+}
+
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="limiter"`)
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	})
+}
+
+type timedEntry struct {
+	UserID    int64      `json:"user_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func toTimed(entries []cache.TimedEntry) []timedEntry {
+	out := make([]timedEntry, len(entries))
+	for i, e := range entries {
+		out[i].UserID = e.UserID
+		if !e.ExpiresAt.IsZero() {
+			t := e.ExpiresAt.UTC()
+			out[i].ExpiresAt = &t
+		}
+	}
+	return out
+}
+
+func (s *Server) handleWhitelist(w http.ResponseWriter, r *http.Request) {
+	perm, err := s.store.WhitelistMembers(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения whitelist", err)
+		return
+	}
+	temp, err := s.store.ListTempWhitelist(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения временного whitelist", err)
+		return
+	}
+	if perm == nil {
+		perm = []int64{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"permanent": perm,
+		"temporary": toTimed(temp),
+	})
+}
+
+func (s *Server) handleCooldowns(w http.ResponseWriter, r *http.Request) {
+	hard, err := s.store.ListCooldowns(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения cooldown", err)
+		return
+	}
+	soft, err := s.store.ListSoftCooldowns(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения soft cooldown", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"hard": toTimed(hard),
+		"soft": toTimed(soft),
+	})
+}
+
+type restoreTimer struct {
+	UserID int64     `json:"user_id"`
+	DueAt  time.Time `json:"due_at"`
+}
+
+func (s *Server) handleRestores(w http.ResponseWriter, r *http.Request) {
+	timers, err := s.store.ListRestoreTimers(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения таймеров восстановления", err)
+		return
+	}
+	out := make([]restoreTimer, len(timers))
+	for i, t := range timers {
+		out[i] = restoreTimer{UserID: t.UserID, DueAt: t.ExpiresAt.UTC()}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"timers": out})
+}
+
+type violatorStat struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username,omitempty"`
+	Count    int    `json:"count"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	topN := defaultTopN
+	if raw := r.URL.Query().Get("top"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "top must be a non-negative integer")
+			return
+		}
+		topN = n
+	}
+
+	stats, err := s.store.GetViolationStats(r.Context(), topN)
+	if err != nil {
+		s.internalError(w, "Ошибка чтения статистики нарушений", err)
+		return
+	}
+	top := make([]violatorStat, len(stats.Top))
+	for i, v := range stats.Top {
+		top[i] = violatorStat{UserID: v.UserID, Username: v.Username, Count: v.Count}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"count_24h":  stats.Count24h,
+		"count_week": stats.CountWeek,
+		"top":        top,
+	})
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	overrides, err := s.store.GetConfigOverrides(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения сохранённых настроек", err)
+		return
+	}
+	if overrides == nil {
+		overrides = map[string]string{}
+	}
+
+	cfg := s.cfg.Load()
+	effective := make(map[string]string)
+	for _, f := range config.Registry() {
+		effective[f.Key] = config.Display(cfg, f.Key)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"overrides": overrides,
+		"effective": effective,
+	})
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -s.cfg.Load().ViolationHistoryDays)
+	entries, err := s.store.GetHistory(r.Context(), userID, since)
+	if err != nil {
+		s.internalError(w, "Ошибка чтения истории нарушений", err)
+		return
+	}
+	if entries == nil {
+		entries = []cache.HistoryEntry{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"user_id": userID,
+		"entries": entries,
+	})
+}
+
+func pathUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return 0, false
+	}
+	return userID, true
+}
+
+type actionRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
+	if s.onAction == nil {
+		writeError(w, http.StatusNotImplemented, "actions are not available")
+		return
+	}
+
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+	action := r.PathValue("action")
+
+	var req actionRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxActionBody)).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	// Обрыв соединения клиентом не должен прерывать действие на полпути
+	// (например, пользователь отключён, а таймер восстановления не поставлен).
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), actionTimeout)
+	defer cancel()
+	actor := audit.Actor{Source: audit.SourceAPI, Name: strings.TrimSpace(req.Actor)}
+	ctx = audit.WithReason(audit.WithActor(ctx, actor), strings.TrimSpace(req.Reason))
+
+	if err := s.onAction(ctx, action, userID); err != nil {
+		switch {
+		case errors.Is(err, audit.ErrUnknownAction):
+			writeError(w, http.StatusBadRequest, "unknown action "+strconv.Quote(action))
+		case errors.Is(err, api.ErrNotFound):
+			writeError(w, http.StatusNotFound, "user not found")
+		default:
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"action": action,
+				"userID": userID,
+			}).Error("Admin API: ошибка выполнения действия")
+			writeError(w, http.StatusBadGateway, "action failed")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"action":  action,
+		"user_id": userID,
+		"status":  "ok",
+	})
+}
+
+func (s *Server) handleDeadWebhooks(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.store.ListDeadWebhooks(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения dead-letter webhook", err)
+		return
+	}
+	if jobs == nil {
+		jobs = []cache.WebhookJob{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+func (s *Server) handleReplayWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ok, err := s.store.ReplayDeadWebhook(r.Context(), id)
+	if err != nil {
+		s.internalError(w, "Ошибка переотправки webhook", err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "dead-letter job "+strconv.Quote(id)+" not found")
+		return
+	}
+	s.logger.WithField("job", id).Info("Admin API: webhook возвращён в очередь")
+	writeJSON(w, http.StatusOK, map[string]any{"replayed": 1})
+}
+
+func (s *Server) handleReplayAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	jobs, err := s.store.ListDeadWebhooks(r.Context())
+	if err != nil {
+		s.internalError(w, "Ошибка чтения dead-letter webhook", err)
+		return
+	}
+	replayed := 0
+	for _, job := range jobs {
+		ok, err := s.store.ReplayDeadWebhook(r.Context(), job.ID)
+		if err != nil {
+			s.internalError(w, "Ошибка переотправки webhook", err)
+			return
+		}
+		if ok {
+			replayed++
+		}
+	}
+	s.logger.WithField("count", replayed).Info("Admin API: dead-letter webhook возвращены в очередь")
+	writeJSON(w, http.StatusOK, map[string]any{"replayed": replayed})
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	ok, err := s.store.DeleteDeadWebhook(r.Context(), id)
+	if err != nil {
+		s.internalError(w, "Ошибка удаления webhook из dead-letter", err)
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "dead-letter job "+strconv.Quote(id)+" not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": 1})
+}
+
+func (s *Server) internalError(w http.ResponseWriter, msg string, err error) {
+	s.logger.WithError(err).Error("Admin API: " + msg)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
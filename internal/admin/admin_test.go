@@ -0,0 +1,362 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+const testToken = "secret-token"
+
+type fakeStore struct {
+	perm      []int64
+	temp      []cache.TimedEntry
+	cooldowns []cache.TimedEntry
+	soft      []cache.TimedEntry
+	restores  []cache.TimedEntry
+	stats     *cache.ViolationStats
+	overrides map[string]string
+	history   []cache.HistoryEntry
+	gotSince  time.Time
+	err       error
+	gotTopN   int
+	dead      []cache.WebhookJob
+	replayed  []string
+}
+
+func (f *fakeStore) WhitelistMembers(context.Context) ([]int64, error) { return f.perm, f.err }
+func (f *fakeStore) ListTempWhitelist(context.Context) ([]cache.TimedEntry, error) {
+	return f.temp, f.err
+}
+func (f *fakeStore) ListCooldowns(context.Context) ([]cache.TimedEntry, error) {
+	return f.cooldowns, f.err
+}
+func (f *fakeStore) ListSoftCooldowns(context.Context) ([]cache.TimedEntry, error) {
+	return f.soft, f.err
+}
+func (f *fakeStore) ListRestoreTimers(context.Context) ([]cache.TimedEntry, error) {
+	return f.restores, f.err
+}
+func (f *fakeStore) GetViolationStats(_ context.Context, topN int) (*cache.ViolationStats, error) {
+	f.gotTopN = topN
+	if f.stats == nil {
+		return &cache.ViolationStats{}, f.err
+	}
+	return f.stats, f.err
+}
+func (f *fakeStore) GetConfigOverrides(context.Context) (map[string]string, error) {
+	return f.overrides, f.err
+}
+func (f *fakeStore) GetHistory(_ context.Context, _ int64, since time.Time) ([]cache.HistoryEntry, error) {
+	f.gotSince = since
+	return f.history, f.err
+}
+
+func (f *fakeStore) ListDeadWebhooks(context.Context) ([]cache.WebhookJob, error) {
+	return f.dead, f.err
+}
+func (f *fakeStore) ReplayDeadWebhook(_ context.Context, id string) (bool, error) {
+	for i, job := range f.dead {
+		if job.ID == id {
+			f.replayed = append(f.replayed, id)
+			f.dead = append(f.dead[:i], f.dead[i+1:]...)
+			return true, f.err
+		}
+	}
+	return false, f.err
+}
+func (f *fakeStore) DeleteDeadWebhook(_ context.Context, id string) (bool, error) {
+	for i, job := range f.dead {
+		if job.ID == id {
+			f.dead = append(f.dead[:i], f.dead[i+1:]...)
+			return true, f.err
+		}
+	}
+	return false, f.err
+}
+
+func newTestMux(store Store) *http.ServeMux {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	cfg := config.NewProvider(&config.Config{ActionMode: "auto", CheckInterval: 30, ViolationHistoryDays: 90})
+
+	mux := http.NewServeMux()
+	New(store, cfg, testToken, logger).Register(mux)
+	return mux
+}
+
+func do(t *testing.T, mux http.Handler, method, path, token string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var body map[string]any
+	if rec.Body.Len() > 0 && rec.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("невалидный JSON: %v: %s", err, rec.Body.String())
+		}
+	}
+	return rec, body
+}
+
+func TestAuth_RejectsMissingOrWrongToken(t *testing.T) {
+	mux := newTestMux(&fakeStore{})
+	for _, token := range []string{"", "wrong"} {
+		rec, _ := do(t, mux, "GET", "/api/v1/whitelist", token)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: status = %d, want 401", token, rec.Code)
+		}
+	}
+}
+
+func TestWhitelist(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	mux := newTestMux(&fakeStore{
+		perm: []int64{1, 2},
+		temp: []cache.TimedEntry{{UserID: 3, ExpiresAt: expires}},
+	})
+
+	rec, body := do(t, mux, "GET", "/api/v1/whitelist", testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if perm := body["permanent"].([]any); len(perm) != 2 {
+		t.Errorf("permanent = %v", perm)
+	}
+	temp := body["temporary"].([]any)[0].(map[string]any)
+	if temp["user_id"] != float64(3) || temp["expires_at"] != "2030-01-02T03:04:05Z" {
+		t.Errorf("temporary = %v", temp)
+	}
+}
+
+// Пустые списки отдаются как [], а не null — панели не нужно отдельно
+// обрабатывать отсутствие данных.
+func TestWhitelist_EmptyListsAreArrays(t *testing.T) {
+	rec, _ := do(t, newTestMux(&fakeStore{}), "GET", "/api/v1/whitelist", testToken)
+	if want := `{"permanent":[],"temporary":[]}` + "\n"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestRestores(t *testing.T) {
+	due := time.Date(2030, 5, 6, 7, 8, 9, 0, time.UTC)
+	mux := newTestMux(&fakeStore{restores: []cache.TimedEntry{{UserID: 42, ExpiresAt: due}}})
+
+	_, body := do(t, mux, "GET", "/api/v1/restores", testToken)
+	timer := body["timers"].([]any)[0].(map[string]any)
+	if timer["user_id"] != float64(42) || timer["due_at"] != "2030-05-06T07:08:09Z" {
+		t.Errorf("timer = %v", timer)
+	}
+}
+
+func TestStats_TopParam(t *testing.T) {
+	store := &fakeStore{stats: &cache.ViolationStats{
+		Count24h: 2, CountWeek: 5,
+		Top: []cache.ViolatorStat{{UserID: "7", Username: "alice", Count: 4}},
+	}}
+	mux := newTestMux(store)
+
+	_, body := do(t, mux, "GET", "/api/v1/stats?top=3", testToken)
+	if store.gotTopN != 3 {
+		t.Errorf("topN = %d, want 3", store.gotTopN)
+	}
+	if body["count_week"] != float64(5) || len(body["top"].([]any)) != 1 {
+		t.Errorf("body = %v", body)
+	}
+
+	if rec, _ := do(t, mux, "GET", "/api/v1/stats?top=abc", testToken); rec.Code != http.StatusBadRequest {
+		t.Errorf("top=abc: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestConfig_OverridesAndEffective(t *testing.T) {
+	mux := newTestMux(&fakeStore{overrides: map[string]string{"ACTION_MODE": "auto"}})
+
+	_, body := do(t, mux, "GET", "/api/v1/config", testToken)
+	if body["overrides"].(map[string]any)["ACTION_MODE"] != "auto" {
+		t.Errorf("overrides = %v", body["overrides"])
+	}
+	effective := body["effective"].(map[string]any)
+	if effective["CHECK_INTERVAL"] != "30" {
+		t.Errorf("effective CHECK_INTERVAL = %v, want 30", effective["CHECK_INTERVAL"])
+	}
+	if _, ok := effective["REDIS_URL"]; ok {
+		t.Error("в effective попал параметр вне реестра")
+	}
+}
+
+func TestStoreError_Returns500(t *testing.T) {
+	mux := newTestMux(&fakeStore{err: errors.New("redis down")})
+	rec, body := do(t, mux, "GET", "/api/v1/cooldowns", testToken)
+	if rec.Code != http.StatusInternalServerError || body["error"] == nil {
+		t.Errorf("status = %d, body = %v", rec.Code, body)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	rec, _ := do(t, newTestMux(&fakeStore{}), "POST", "/api/v1/whitelist", testToken)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestAction_PassesActorAndReason(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	srv := New(&fakeStore{}, config.NewProvider(&config.Config{}), testToken, logger)
+
+	var gotAction string
+	var gotUser int64
+	var gotActor audit.Actor
+	var gotReason string
+	srv.SetActionHandler(func(ctx context.Context, action string, userID int64) error {
+		gotAction, gotUser = action, userID
+		gotActor, gotReason = audit.ActorFrom(ctx), audit.ReasonFrom(ctx)
+		return nil
+	})
+	mux := http.NewServeMux()
+	srv.Register(mux)
+
+	req := httptest.NewRequest("POST", "/api/v1/users/42/actions/ignore_temp",
+		strings.NewReader(`{"actor":"support-bot","reason":"тикет 17"}`))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if gotAction != "ignore_temp" || gotUser != 42 {
+		t.Errorf("action = %q, user = %d", gotAction, gotUser)
+	}
+	if gotActor.Source != audit.SourceAPI || gotActor.Name != "support-bot" || gotReason != "тикет 17" {
+		t.Errorf("actor = %+v, reason = %q", gotActor, gotReason)
+	}
+}
+
+func TestAction_Errors(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	srv := New(&fakeStore{}, config.NewProvider(&config.Config{}), testToken, logger)
+	srv.SetActionHandler(func(ctx context.Context, action string, userID int64) error {
+		switch {
+		case action == "enable":
+			return fmt.Errorf("panel: %w", api.ErrNotFound)
+		case action == "disable":
+			return errors.New("dial tcp 10.0.0.1:3000: connection refused")
+		}
+		return audit.ErrUnknownAction
+	})
+	mux := http.NewServeMux()
+	srv.Register(mux)
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/users/abc/actions/disable", http.StatusBadRequest},
+		{"/api/v1/users/42/actions/explode", http.StatusBadRequest},
+		{"/api/v1/users/42/actions/enable", http.StatusNotFound},
+		{"/api/v1/users/42/actions/disable", http.StatusBadGateway},
+	}
+	for _, tc := range cases {
+		rec, body := do(t, mux, "POST", tc.path, testToken)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.path, rec.Code, tc.want)
+		}
+		if msg, _ := body["error"].(string); strings.Contains(msg, "10.0.0.1") {
+			t.Errorf("%s: внутренняя ошибка попала в ответ: %q", tc.path, msg)
+		}
+	}
+}
+
+// Без обработчика действий POST-эндпоинты отвечают 501, а не падают.
+func TestAction_NoHandler(t *testing.T) {
+	rec, _ := do(t, newTestMux(&fakeStore{}), "POST", "/api/v1/users/42/actions/drop", testToken)
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rec.Code)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	store := &fakeStore{history: []cache.HistoryEntry{{
+		Kind:   cache.HistoryViolation,
+		Action: "disabled",
+		IPs:    []cache.HistoryIP{{IP: "1.2.3.4", NodeName: "DE-1"}},
+	}}}
+	mux := newTestMux(store)
+
+	_, body := do(t, mux, "GET", "/api/v1/users/42/history", testToken)
+	entries := body["entries"].([]any)
+	if len(entries) != 1 {
+		t.Fatalf("entries = %v", entries)
+	}
+	ip := entries[0].(map[string]any)["ips"].([]any)[0].(map[string]any)
+	if ip["ip"] != "1.2.3.4" || ip["node_name"] != "DE-1" {
+		t.Errorf("ip = %v", ip)
+	}
+	// Записи старше VIOLATION_HISTORY_DAYS не отдаются.
+	if age := time.Since(store.gotSince); age < 89*24*time.Hour || age > 91*24*time.Hour {
+		t.Errorf("since = %v назад, want ~90 дней", age)
+	}
+}
+
+func TestDeadWebhooks_ListReplayDelete(t *testing.T) {
+	store := &fakeStore{dead: []cache.WebhookJob{
+		{ID: "1", Endpoint: "crm", Event: "violation_detected", Body: json.RawMessage(`{"event":"violation_detected"}`), Attempts: 10},
+		{ID: "2", Endpoint: "crm", Event: "violation_detected", Body: json.RawMessage(`{}`)},
+		{ID: "3", Endpoint: "slack", Event: "soft_violation_detected", Body: json.RawMessage(`{}`)},
+	}}
+	mux := newTestMux(store)
+
+	rec, body := do(t, mux, "GET", "/api/v1/webhooks/dead", testToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	jobs := body["jobs"].([]any)
+	first := jobs[0].(map[string]any)
+	if len(jobs) != 3 || first["endpoint"] != "crm" || first["attempts"] != float64(10) {
+		t.Errorf("jobs = %v", jobs)
+	}
+	// тело события отдаётся как JSON, а не строкой
+	if payload, ok := first["body"].(map[string]any); !ok || payload["event"] != "violation_detected" {
+		t.Errorf("body = %v", first["body"])
+	}
+
+	rec, _ = do(t, mux, "POST", "/api/v1/webhooks/dead/1/replay", testToken)
+	if rec.Code != http.StatusOK || len(store.replayed) != 1 {
+		t.Errorf("replay status = %d, replayed = %v", rec.Code, store.replayed)
+	}
+	rec, _ = do(t, mux, "POST", "/api/v1/webhooks/dead/1/replay", testToken)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("repeated replay status = %d, want 404", rec.Code)
+	}
+
+	rec, _ = do(t, mux, "DELETE", "/api/v1/webhooks/dead/3", testToken)
+	if rec.Code != http.StatusOK || len(store.dead) != 1 {
+		t.Errorf("delete status = %d, dead = %v", rec.Code, store.dead)
+	}
+
+	rec, body = do(t, mux, "POST", "/api/v1/webhooks/dead/replay", testToken)
+	if rec.Code != http.StatusOK || body["replayed"] != float64(1) || len(store.dead) != 0 {
+		t.Errorf("replay all status = %d, body = %v, dead = %v", rec.Code, body, store.dead)
+	}
+}
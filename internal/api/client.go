@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand/v2"
@@ -14,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -30,6 +33,20 @@ const (
 	maxErrBodyLen = 512
 )
 
+// ErrNotFound — панель ответила 404: запрошенного объекта нет.
+var ErrNotFound = errors.New("не найдено")
+
+var (
+	apiRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "limiter_api_retries_total",
+		Help: "Повторные запросы к панели Remnawave.",
+	}, []string{"method"})
+	apiFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "limiter_api_failures_total",
+		Help: "Запросы к панели, завершившиеся ошибкой после всех повторов.",
+	}, []string{"method"})
+)
+
 type Client struct {
 	baseURL    string
 	token      string
@@ -203,6 +220,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 				"of":      maxRetries,
 				"wait":    wait,
 			}).Warn("Повтор запроса к панели")
+			apiRetries.WithLabelValues(method).Inc()
 
 			select {
 			case <-ctx.Done():
@@ -233,13 +251,20 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 			continue
 		}
 
+		// 404 — ожидаемый ответ (пользователь удалён), а не сбой панели: в
+		// limiter_api_failures_total не считается.
+		if status == http.StatusNotFound {
+			return nil, fmt.Errorf("API %s %s returned status %d: %s: %w", method, path, status, truncateBody(respBody), ErrNotFound)
+		}
 		if status < 200 || status >= 300 {
+			apiFailures.WithLabelValues(method).Inc()
 			return nil, fmt.Errorf("API %s %s returned status %d: %s", method, path, status, truncateBody(respBody))
 		}
 
 		return respBody, nil
 	}
 
+	apiFailures.WithLabelValues(method).Inc()
 	return nil, fmt.Errorf("API %s %s failed after %d retries: %w", method, path, maxRetries, lastErr)
 }
 
@@ -387,6 +412,54 @@ func (c *Client) GetUserByID(ctx context.Context, userID int64) (*UserData, erro
 	return &resp.Response, nil
 }
 
+func (c *Client) GetUserByUsername(ctx context.Context, username string) (*UserData, error) {
+	data, err := c.doRequest(ctx, http.MethodGet, "/api/users/by-username/"+url.PathEscape(username), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get user by username %q: %w", username, err)
+	}
+
+	var resp UserResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode user response: %w", err)
+	}
+
+	return &resp.Response, nil
+}
+
+// GetUsers — страница списка пользователей панели: size записей, начиная со
+// start. total — сколько пользователей всего.
+func (c *Client) GetUsers(ctx context.Context, start, size int) ([]UserData, int, error) {
+	query := url.Values{}
+	query.Set("start", strconv.Itoa(start))
+	query.Set("size", strconv.Itoa(size))
+	data, err := c.doRequest(ctx, http.MethodGet, "/api/users?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("get users (start %d): %w", start, err)
+	}
+
+	var resp UsersResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, 0, fmt.Errorf("decode users response: %w", err)
+	}
+
+	return resp.Response.Users, resp.Response.Total, nil
+}
+
+// GetUserHWIDDevices — HWID-устройства, зарегистрированные у пользователя.
+func (c *Client) GetUserHWIDDevices(ctx context.Context, userUUID string) ([]HWIDDevice, error) {
+	data, err := c.doRequest(ctx, http.MethodGet, "/api/hwid/devices/"+url.PathEscape(userUUID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get hwid devices %s: %w", userUUID, err)
+	}
+
+	var resp HWIDDevicesResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode hwid devices response: %w", err)
+	}
+
+	return resp.Response.Devices, nil
+}
+
 func (c *Client) DisableUser(ctx context.Context, userID int64) error {
 	_, err := c.doRequest(ctx, http.MethodPost, "/api/users/"+strconv.FormatInt(userID, 10)+"/actions/disable", nil)
 	if err != nil {
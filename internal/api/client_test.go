@@ -183,6 +183,104 @@ func TestClient_GetUserByID(t *testing.T) {
 	}
 }
 
+func TestClient_GetUserByUsername(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/users/by-username/john%20doe" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"User not found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(UserResponse{Response: UserData{ID: 7, Username: "john doe"}})
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "test-token")
+	user, err := client.GetUserByUsername(context.Background(), "john doe")
+	if err != nil {
+		t.Fatalf("GetUserByUsername returned error: %v", err)
+	}
+	if user.ID != 7 {
+		t.Errorf("expected id 7, got %d", user.ID)
+	}
+
+	// 404 не ретраится и распознаётся через errors.Is.
+	if _, err := client.GetUserByUsername(context.Background(), "ghost"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_GetUsers(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/users" || r.Method != http.MethodGet {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("start") != "500" || r.URL.Query().Get("size") != "500" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"response":{"total":502,"users":[
+			{"id":501,"uuid":"u-501","username":"a","status":"ACTIVE","hwidDeviceLimit":2},
+			{"id":502,"uuid":"u-502","username":"b","status":"DISABLED","hwidDeviceLimit":null}
+		]}}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "test-token")
+	users, total, err := client.GetUsers(context.Background(), 500, 500)
+	if err != nil {
+		t.Fatalf("GetUsers returned error: %v", err)
+	}
+	if total != 502 || len(users) != 2 {
+		t.Fatalf("total = %d, users = %d; want 502, 2", total, len(users))
+	}
+	if users[0].ID != 501 || users[0].UUID != "u-501" || users[0].HWIDDeviceLimit == nil || *users[0].HWIDDeviceLimit != 2 {
+		t.Errorf("unexpected first user: %+v", users[0])
+	}
+	if users[1].HWIDDeviceLimit != nil {
+		t.Errorf("expected nil hwidDeviceLimit, got %v", *users[1].HWIDDeviceLimit)
+	}
+}
+
+func TestClient_GetUserHWIDDevices(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/hwid/devices/0f1e2d3c-aaaa-bbbb-cccc-000000000042" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// platform и osVersion у старых клиентов приходят null.
+		w.Write([]byte(`{"response":{"total":2,"devices":[
+			{"hwid":"a1","platform":"iOS","osVersion":"17.4","deviceModel":"iPhone15,2","userAgent":"Happ/1.0","createdAt":"2026-01-02T03:04:05Z"},
+			{"hwid":"b2","platform":null,"osVersion":null,"deviceModel":"Pixel 8","userAgent":"v2rayNG"}
+		]}}`))
+	})
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client := NewClient(srv.URL, "test-token")
+	devices, err := client.GetUserHWIDDevices(context.Background(), "0f1e2d3c-aaaa-bbbb-cccc-000000000042")
+	if err != nil {
+		t.Fatalf("GetUserHWIDDevices returned error: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+	if devices[0].Platform != "iOS" || devices[0].DeviceModel != "iPhone15,2" || devices[0].CreatedAt.IsZero() {
+		t.Errorf("unexpected first device: %+v", devices[0])
+	}
+	if devices[1].Platform != "" || devices[1].DeviceModel != "Pixel 8" {
+		t.Errorf("unexpected second device: %+v", devices[1])
+	}
+}
+
 func TestClient_DisableUser(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/users/42/actions/disable" {
@@ -50,13 +50,45 @@ type UserResponse struct {
 }
 
 type UserData struct {
-	ID              int64   `json:"id"`
-	Username        string  `json:"username"`
-	Status          string  `json:"status"`
-	Email           *string `json:"email"`
-	TelegramID      *int64  `json:"telegramId"`
-	HWIDDeviceLimit *int    `json:"hwidDeviceLimit"`
-	SubscriptionURL string  `json:"subscriptionUrl,omitempty"`
+	ID                   int64           `json:"id"`
+	UUID                 string          `json:"uuid"`
+	Username             string          `json:"username"`
+	Status               string          `json:"status"`
+	Email                *string         `json:"email"`
+	TelegramID           *int64          `json:"telegramId"`
+	HWIDDeviceLimit      *int            `json:"hwidDeviceLimit"`
+	SubscriptionURL      string          `json:"subscriptionUrl,omitempty"`
+	Tag                  *string         `json:"tag"`
+	ActiveInternalSquads []InternalSquad `json:"activeInternalSquads"`
+}
+
+type HWIDDevicesResponse struct {
+	Response struct {
+		Total   int          `json:"total"`
+		Devices []HWIDDevice `json:"devices"`
+	} `json:"response"`
+}
+
+// HWIDDevice — устройство, зарегистрированное в панели по HWID клиента.
+type HWIDDevice struct {
+	HWID        string    `json:"hwid"`
+	Platform    string    `json:"platform"`
+	OSVersion   string    `json:"osVersion"`
+	DeviceModel string    `json:"deviceModel"`
+	UserAgent   string    `json:"userAgent"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type UsersResponse struct {
+	Response struct {
+		Users []UserData `json:"users"`
+		Total int        `json:"total"`
+	} `json:"response"`
+}
+
+type InternalSquad struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
 }
 
 type DropConnectionsRequest struct {
@@ -80,14 +112,29 @@ type ActiveIP struct {
 	NodeUUID string
 	ASN      uint32
 	ASNOrg   string
+	// ASNClass — класс ASN (geoip.ClassMobile, …), пусто — не задан.
+	ASNClass string
+	// Country — страна клиента по GeoIP, NodeCountry — страна ноды из панели.
+	Country     string
+	NodeCountry string
+	// Latitude/Longitude — координаты по GeoIP, если HasCoords (GeoLite2-City).
+	Latitude  float64
+	Longitude float64
+	HasCoords bool
+	// Cached — IP из прошлого результата ноды: опрошенной в одной из прошлых
+	// проверок (NODE_INTERVALS) или не ответившей сейчас (NODE_STALE_MAX_AGE).
+	Cached bool
 }
 
 type CachedUser struct {
-	UserID          int64  `json:"user_id"`
-	Username        string `json:"username"`
-	Email           string `json:"email"`
-	TelegramID      int64  `json:"telegram_id"`
-	HWIDDeviceLimit int    `json:"hwid_device_limit"`
-	Status          string `json:"status"`
-	SubscriptionURL string `json:"subscription_url"`
+	UserID          int64           `json:"user_id"`
+	UUID            string          `json:"uuid,omitempty"`
+	Username        string          `json:"username"`
+	Email           string          `json:"email"`
+	TelegramID      int64           `json:"telegram_id"`
+	HWIDDeviceLimit int             `json:"hwid_device_limit"`
+	Status          string          `json:"status"`
+	SubscriptionURL string          `json:"subscription_url"`
+	Tag             string          `json:"tag,omitempty"`
+	InternalSquads  []InternalSquad `json:"internal_squads,omitempty"`
 }
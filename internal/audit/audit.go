@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrUnknownAction — запрошено действие, которого нет ни в боте, ни в admin API.
+var ErrUnknownAction = errors.New("неизвестное действие")
+
+const (
+	SourceTelegram = "telegram"
+	SourceAPI      = "api"
+	SourceSystem   = "system"
+)
+
+// Actor — кто инициировал действие: администратор в Telegram, клиент admin API
+// или сам лимитер.
+type Actor struct {
+	Source string `json:"source"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type actorKey struct{}
+
+type reasonKey struct{}
+
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFrom возвращает инициатора из контекста; без него действие считается
+// системным.
+func ActorFrom(ctx context.Context) Actor {
+	if a, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return a
+	}
+	return Actor{Source: SourceSystem}
+}
+
+func WithReason(ctx context.Context, reason string) context.Context {
+	if reason == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+func ReasonFrom(ctx context.Context) string {
+	r, _ := ctx.Value(reasonKey{}).(string)
+	return r
+}
+
+// Fields — поля для записи в лог аудита.
+func Fields(ctx context.Context) logrus.Fields {
+	a := ActorFrom(ctx)
+	f := logrus.Fields{"actorSource": a.Source}
+	if a.ID != "" {
+		f["actorID"] = a.ID
+	}
+	if a.Name != "" {
+		f["actor"] = a.Name
+	}
+	if r := ReasonFrom(ctx); r != "" {
+		f["reason"] = r
+	}
+	return f
+}
@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestActorFrom_DefaultsToSystem(t *testing.T) {
+	if a := ActorFrom(context.Background()); a.Source != SourceSystem {
+		t.Errorf("Source = %q, want %q", a.Source, SourceSystem)
+	}
+}
+
+func TestFields(t *testing.T) {
+	ctx := WithActor(context.Background(), Actor{Source: SourceAPI, Name: "support"})
+	ctx = WithReason(ctx, "тикет 42")
+
+	f := Fields(ctx)
+	if f["actorSource"] != SourceAPI || f["actor"] != "support" || f["reason"] != "тикет 42" {
+		t.Errorf("Fields = %v", f)
+	}
+	if _, ok := f["actorID"]; ok {
+		t.Error("пустой actorID не должен попадать в лог")
+	}
+}
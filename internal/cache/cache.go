@@ -15,6 +15,7 @@ import (
 
 const (
 	prefixUser               = "user:"
+	prefixHWID               = "hwid:"
 	prefixCooldown           = "cooldown:"
 	prefixSoftCooldown       = "cooldown:soft:"
 	prefixViolationCount     = "violations:count:"
@@ -77,6 +78,58 @@ func (c *Cache) GetUser(ctx context.Context, userID int64) (*api.CachedUser, err
 	return &user, nil
 }
 
+// SetUsers кэширует пачку пользователей одним pipeline (фоновая загрузка
+// USER_PREFETCH).
+func (c *Cache) SetUsers(ctx context.Context, users []*api.CachedUser, ttl time.Duration) error {
+	pipe := c.client.Pipeline()
+	for _, user := range users {
+		data, err := json.Marshal(user)
+		if err != nil {
+			return fmt.Errorf("marshal user %d: %w", user.UserID, err)
+		}
+		pipe.Set(ctx, prefixUser+formatUserID(user.UserID), data, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("set users: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser сбрасывает кэш пользователя вместе с его HWID-устройствами.
+func (c *Cache) DeleteUser(ctx context.Context, userID int64) error {
+	id := formatUserID(userID)
+	return c.client.Del(ctx, prefixUser+id, prefixHWID+id).Err()
+}
+
+// SetHWIDDevices кэширует HWID-устройства пользователя; пустой список тоже
+// кэшируется, чтобы не спрашивать панель каждый цикл.
+func (c *Cache) SetHWIDDevices(ctx context.Context, userID int64, devices []api.HWIDDevice, ttl time.Duration) error {
+	if devices == nil {
+		devices = []api.HWIDDevice{}
+	}
+	data, err := json.Marshal(devices)
+	if err != nil {
+		return fmt.Errorf("marshal hwid devices: %w", err)
+	}
+	return c.client.Set(ctx, prefixHWID+formatUserID(userID), data, ttl).Err()
+}
+
+// GetHWIDDevices возвращает nil без ошибки, если в кэше ничего нет.
+func (c *Cache) GetHWIDDevices(ctx context.Context, userID int64) ([]api.HWIDDevice, error) {
+	data, err := c.client.Get(ctx, prefixHWID+formatUserID(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get hwid devices: %w", err)
+	}
+	devices := []api.HWIDDevice{}
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("unmarshal hwid devices: %w", err)
+	}
+	return devices, nil
+}
+
 func (c *Cache) SetCooldown(ctx context.Context, userID int64, ttl time.Duration) error {
 	return c.client.Set(ctx, prefixCooldown+formatUserID(userID), "1", ttl).Err()
 }
@@ -107,6 +160,29 @@ func (c *Cache) IsSoftCooldownActive(ctx context.Context, userID int64) (bool, e
 	return true, nil
 }
 
+// Виды отдельных алертов со своим cooldown, независимым от cooldown нарушений.
+const (
+	AlertTravel      = "travel"
+	AlertVPNReseller = "reseller"
+	AlertHWID        = "hwid"
+)
+
+// SetAlertCooldown ставит cooldown алерта вида kind (cooldown:<kind>:<id>).
+func (c *Cache) SetAlertCooldown(ctx context.Context, kind string, userID int64, ttl time.Duration) error {
+	return c.client.Set(ctx, prefixCooldown+kind+":"+formatUserID(userID), "1", ttl).Err()
+}
+
+func (c *Cache) IsAlertCooldownActive(ctx context.Context, kind string, userID int64) (bool, error) {
+	_, err := c.client.Get(ctx, prefixCooldown+kind+":"+formatUserID(userID)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get %s cooldown: %w", kind, err)
+	}
+	return true, nil
+}
+
 func (c *Cache) AddToWhitelist(ctx context.Context, userID int64) error {
 	return c.client.SAdd(ctx, keyWhitelist, formatUserID(userID)).Err()
 }
@@ -130,6 +206,117 @@ func (c *Cache) IsWhitelisted(ctx context.Context, userID int64) (bool, error) {
 	return permCmd.Val() || tempCmd.Val() > 0, nil
 }
 
+func (c *Cache) WhitelistMembers(ctx context.Context) ([]int64, error) {
+	members, err := c.client.SMembers(ctx, keyWhitelist).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get whitelist: %w", err)
+	}
+	out := make([]int64, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseInt(m, 10, 64); err == nil {
+			out = append(out, id)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
+
+func (c *Cache) ListTempWhitelist(ctx context.Context) ([]TimedEntry, error) {
+	return c.scanTimed(ctx, prefixWhitelistTemp)
+}
+
+func (c *Cache) ListCooldowns(ctx context.Context) ([]TimedEntry, error) {
+	return c.scanTimed(ctx, prefixCooldown)
+}
+
+func (c *Cache) ListSoftCooldowns(ctx context.Context) ([]TimedEntry, error) {
+	return c.scanTimed(ctx, prefixSoftCooldown)
+}
+
+// scanTimed собирает ключи вида <prefix><userID> вместе с моментом истечения.
+// Ключи, у которых после префикса не число (например cooldown:soft:* при
+// prefix = cooldown:), пропускаются.
+func (c *Cache) scanTimed(ctx context.Context, prefix string) ([]TimedEntry, error) {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("scan %s*: %w", prefix, err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := c.client.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, k := range keys {
+		ttls[i] = pipe.PTTL(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("ttl %s*: %w", prefix, err)
+	}
+
+	now := time.Now()
+	out := make([]TimedEntry, 0, len(keys))
+	for i, k := range keys {
+		id, err := strconv.ParseInt(strings.TrimPrefix(k, prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		// go-redis отдаёт -2 (ключ успел истечь) и -1 (без TTL) как есть, без множителя.
+		ttl := ttls[i].Val()
+		if ttl == -2 {
+			continue
+		}
+		e := TimedEntry{UserID: id}
+		if ttl > 0 {
+			e.ExpiresAt = now.Add(ttl).Truncate(time.Second)
+		}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out, nil
+}
+
+// UserState — состояние пользователя в лимитере. Нулевое время означает,
+// что соответствующей записи нет (у постоянного whitelist срока нет вовсе).
+type UserState struct {
+	Whitelisted    bool
+	WhitelistUntil time.Time
+	CooldownUntil  time.Time
+	RestoreAt      time.Time
+}
+
+func (c *Cache) GetUserState(ctx context.Context, userID int64) (*UserState, error) {
+	id := formatUserID(userID)
+	pipe := c.client.Pipeline()
+	permCmd := pipe.SIsMember(ctx, keyWhitelist, id)
+	tempCmd := pipe.PTTL(ctx, prefixWhitelistTemp+id)
+	cooldownCmd := pipe.PTTL(ctx, prefixCooldown+id)
+	restoreCmd := pipe.ZScore(ctx, keyRestoreQ, id)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("user state pipeline: %w", err)
+	}
+
+	now := time.Now()
+	st := &UserState{Whitelisted: permCmd.Val()}
+	if ttl := tempCmd.Val(); ttl > 0 {
+		st.Whitelisted = true
+		if !permCmd.Val() {
+			st.WhitelistUntil = now.Add(ttl).Truncate(time.Second)
+		}
+	}
+	if ttl := cooldownCmd.Val(); ttl > 0 {
+		st.CooldownUntil = now.Add(ttl).Truncate(time.Second)
+	}
+	if restoreCmd.Err() == nil {
+		st.RestoreAt = time.Unix(int64(restoreCmd.Val()), 0)
+	}
+	return st, nil
+}
+
 func (c *Cache) InitWhitelist(ctx context.Context, userIDs []string) error {
 	if len(userIDs) == 0 {
 		return nil
@@ -169,6 +356,46 @@ func (c *Cache) SetRestoreTimer(ctx context.Context, userID int64, duration time
 	}).Err()
 }
 
+// CancelRestoreTimer убирает пользователя из очереди восстановления.
+// Возвращает false, если таймера не было.
+func (c *Cache) CancelRestoreTimer(ctx context.Context, userID int64) (bool, error) {
+	n, err := c.client.ZRem(ctx, keyRestoreQ, formatUserID(userID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("cancel restore timer: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (c *Cache) RestoreQueueLen(ctx context.Context) (int64, error) {
+	n, err := c.client.ZCard(ctx, keyRestoreQ).Result()
+	if err != nil {
+		return 0, fmt.Errorf("restore queue len: %w", err)
+	}
+	return n, nil
+}
+
+type TimedEntry struct {
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+func (c *Cache) ListRestoreTimers(ctx context.Context) ([]TimedEntry, error) {
+	res, err := c.client.ZRangeWithScores(ctx, keyRestoreQ, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list restore timers: %w", err)
+	}
+	out := make([]TimedEntry, 0, len(res))
+	for _, z := range res {
+		member, _ := z.Member.(string)
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, TimedEntry{UserID: id, ExpiresAt: time.Unix(int64(z.Score), 0)})
+	}
+	return out, nil
+}
+
 var popExpiredRestore = redis.NewScript(`
 	local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
 	if #expired > 0 then
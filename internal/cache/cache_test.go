@@ -159,6 +159,71 @@ func TestCache_UserData(t *testing.T) {
 	}
 }
 
+func TestCache_SetUsers(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	users := []*api.CachedUser{
+		{UserID: 501, Username: "a", HWIDDeviceLimit: 2},
+		{UserID: 502, Username: "b", HWIDDeviceLimit: -1},
+	}
+	if err := c.SetUsers(ctx, users, 10*time.Second); err != nil {
+		t.Fatalf("SetUsers error: %v", err)
+	}
+	for _, want := range users {
+		got, err := c.GetUser(ctx, want.UserID)
+		if err != nil || got == nil {
+			t.Fatalf("GetUser(%d) = %v, %v", want.UserID, got, err)
+		}
+		if got.Username != want.Username || got.HWIDDeviceLimit != want.HWIDDeviceLimit {
+			t.Errorf("GetUser(%d) = %+v, want %+v", want.UserID, got, want)
+		}
+	}
+	// Пустая пачка — не ошибка.
+	if err := c.SetUsers(ctx, nil, time.Second); err != nil {
+		t.Errorf("SetUsers(nil) error: %v", err)
+	}
+}
+
+func TestCache_HWIDDevices(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	got, err := c.GetHWIDDevices(ctx, 456)
+	if err != nil {
+		t.Fatalf("GetHWIDDevices error: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil for missing key, got %+v", got)
+	}
+
+	// Пустой список кэшируется и отличается от промаха.
+	if err := c.SetHWIDDevices(ctx, 456, nil, 10*time.Second); err != nil {
+		t.Fatalf("SetHWIDDevices error: %v", err)
+	}
+	got, err = c.GetHWIDDevices(ctx, 456)
+	if err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("GetHWIDDevices = %+v, %v; want empty non-nil", got, err)
+	}
+
+	devices := []api.HWIDDevice{{HWID: "a1", Platform: "iOS", DeviceModel: "iPhone15,2"}}
+	if err := c.SetHWIDDevices(ctx, 456, devices, 10*time.Second); err != nil {
+		t.Fatalf("SetHWIDDevices error: %v", err)
+	}
+	got, err = c.GetHWIDDevices(ctx, 456)
+	if err != nil || len(got) != 1 || got[0].DeviceModel != "iPhone15,2" {
+		t.Fatalf("GetHWIDDevices = %+v, %v", got, err)
+	}
+
+	// Сброс кэша пользователя забывает и устройства.
+	if err := c.DeleteUser(ctx, 456); err != nil {
+		t.Fatalf("DeleteUser error: %v", err)
+	}
+	if got, _ := c.GetHWIDDevices(ctx, 456); got != nil {
+		t.Errorf("after DeleteUser: got %+v, want nil", got)
+	}
+}
+
 func TestCache_Cooldown(t *testing.T) {
 	c := setupTestCache(t)
 	ctx := context.Background()
@@ -525,3 +590,377 @@ func TestCache_RestoreTimer_CanBeRequeued(t *testing.T) {
 		t.Errorf("после возврата в очередь получено %v, want 1 запись", requeued)
 	}
 }
+
+// Обычный и soft cooldown делят префикс cooldown:, но списки не должны
+// смешиваться.
+func TestCache_ListCooldowns_SeparatesSoft(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	c.SetCooldown(ctx, 1, time.Minute)
+	c.SetSoftCooldown(ctx, 2, time.Minute)
+
+	hard, err := c.ListCooldowns(ctx)
+	if err != nil {
+		t.Fatalf("ListCooldowns error: %v", err)
+	}
+	if len(hard) != 1 || hard[0].UserID != 1 {
+		t.Errorf("hard = %+v, want [1]", hard)
+	}
+	if until := time.Until(hard[0].ExpiresAt); until <= 0 || until > time.Minute {
+		t.Errorf("ExpiresAt через %v, want (0, 1m]", until)
+	}
+
+	soft, err := c.ListSoftCooldowns(ctx)
+	if err != nil {
+		t.Fatalf("ListSoftCooldowns error: %v", err)
+	}
+	if len(soft) != 1 || soft[0].UserID != 2 {
+		t.Errorf("soft = %+v, want [2]", soft)
+	}
+}
+
+func TestCache_ListWhitelistAndRestoreTimers(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	c.InitWhitelist(ctx, []string{"20", "10", "not-a-number"})
+	c.AddToWhitelistTemp(ctx, 30, time.Hour)
+	c.SetRestoreTimer(ctx, 40, time.Hour)
+
+	perm, err := c.WhitelistMembers(ctx)
+	if err != nil {
+		t.Fatalf("WhitelistMembers error: %v", err)
+	}
+	if len(perm) != 2 || perm[0] != 10 || perm[1] != 20 {
+		t.Errorf("perm = %v, want [10 20]", perm)
+	}
+
+	temp, err := c.ListTempWhitelist(ctx)
+	if err != nil {
+		t.Fatalf("ListTempWhitelist error: %v", err)
+	}
+	if len(temp) != 1 || temp[0].UserID != 30 {
+		t.Errorf("temp = %+v, want [30]", temp)
+	}
+
+	timers, err := c.ListRestoreTimers(ctx)
+	if err != nil {
+		t.Fatalf("ListRestoreTimers error: %v", err)
+	}
+	if len(timers) != 1 || timers[0].UserID != 40 || time.Until(timers[0].ExpiresAt) < 59*time.Minute {
+		t.Errorf("timers = %+v, want [40 через ~1h]", timers)
+	}
+}
+
+func TestCache_History_CappedAndFiltered(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	const userID int64 = 900
+	now := time.Now()
+
+	for i := 5; i >= 1; i-- {
+		entry := &HistoryEntry{At: now.Add(-time.Duration(i) * 24 * time.Hour), Kind: HistoryViolation, DeviceCount: i}
+		if err := c.AppendHistory(ctx, userID, entry, 3, time.Hour); err != nil {
+			t.Fatalf("AppendHistory error: %v", err)
+		}
+	}
+
+	all, err := c.GetHistory(ctx, userID, time.Time{})
+	if err != nil {
+		t.Fatalf("GetHistory error: %v", err)
+	}
+	// Осталось 3 самых свежих записи, от новых к старым.
+	if len(all) != 3 || all[0].DeviceCount != 1 || all[2].DeviceCount != 3 {
+		t.Errorf("history = %+v, want device counts [1 2 3]", all)
+	}
+
+	recent, err := c.GetHistory(ctx, userID, now.Add(-36*time.Hour))
+	if err != nil {
+		t.Fatalf("GetHistory error: %v", err)
+	}
+	if len(recent) != 1 {
+		t.Errorf("len(recent) = %d, want 1", len(recent))
+	}
+
+	if ttl := c.client.TTL(ctx, prefixHistory+"900").Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL = %v, want (0, 1h]", ttl)
+	}
+}
+
+func TestCache_GetUserState(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	const userID int64 = 910
+
+	st, err := c.GetUserState(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserState error: %v", err)
+	}
+	if st.Whitelisted || !st.CooldownUntil.IsZero() || !st.RestoreAt.IsZero() {
+		t.Errorf("пустое состояние = %+v", st)
+	}
+
+	c.AddToWhitelistTemp(ctx, userID, time.Hour)
+	c.SetCooldown(ctx, userID, time.Minute)
+	c.SetRestoreTimer(ctx, userID, 2*time.Hour)
+
+	st, err = c.GetUserState(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserState error: %v", err)
+	}
+	if !st.Whitelisted || st.WhitelistUntil.IsZero() {
+		t.Errorf("ожидался временный whitelist: %+v", st)
+	}
+	if st.CooldownUntil.IsZero() || time.Until(st.RestoreAt) < time.Hour {
+		t.Errorf("cooldown/restore не заполнены: %+v", st)
+	}
+}
+
+func TestCache_ShadowDiff(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+	const day = "2026-01-02"
+
+	added, err := c.AddShadowDiff(ctx, day, ShadowOnly, 20, "bob")
+	if err != nil || !added {
+		t.Fatalf("AddShadowDiff = %v, %v; want true, nil", added, err)
+	}
+	// повторное попадание за тот же день не считается новым
+	if added, _ := c.AddShadowDiff(ctx, day, ShadowOnly, 20, "bob"); added {
+		t.Error("second AddShadowDiff for the same user must return false")
+	}
+	if _, err := c.AddShadowDiff(ctx, day, ShadowOnly, 10, "alice"); err != nil {
+		t.Fatalf("AddShadowDiff error: %v", err)
+	}
+	if _, err := c.AddShadowDiff(ctx, day, LiveOnly, 30, "carol"); err != nil {
+		t.Fatalf("AddShadowDiff error: %v", err)
+	}
+
+	diff, err := c.GetShadowDiff(ctx, day)
+	if err != nil {
+		t.Fatalf("GetShadowDiff error: %v", err)
+	}
+	only := diff[ShadowOnly]
+	if len(only) != 2 || only[0].UserID != 10 || only[1].Username != "bob" {
+		t.Errorf("shadow_only = %+v, want [10 alice, 20 bob]", only)
+	}
+	if len(diff[LiveOnly]) != 1 || len(diff[ShadowDisable]) != 0 {
+		t.Errorf("diff = %+v", diff)
+	}
+	if ttl := c.client.TTL(ctx, shadowKey(day, ShadowOnly)).Val(); ttl <= 0 {
+		t.Errorf("TTL = %v, want > 0", ttl)
+	}
+}
+
+func TestCache_NotifyOptOutAndSlot(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	if err := c.SetNotifyOptOut(ctx, 555, true); err != nil {
+		t.Fatalf("SetNotifyOptOut error: %v", err)
+	}
+	if out, _ := c.IsNotifyOptedOut(ctx, 555); !out {
+		t.Error("expected opted out")
+	}
+	if err := c.SetNotifyOptOut(ctx, 555, false); err != nil {
+		t.Fatalf("SetNotifyOptOut error: %v", err)
+	}
+	if out, _ := c.IsNotifyOptedOut(ctx, 555); out {
+		t.Error("expected opted in again")
+	}
+
+	if ok, err := c.TryNotifySlot(ctx, 1, time.Hour); err != nil || !ok {
+		t.Fatalf("first TryNotifySlot = %v, %v; want true", ok, err)
+	}
+	if ok, _ := c.TryNotifySlot(ctx, 1, time.Hour); ok {
+		t.Error("second TryNotifySlot within interval must return false")
+	}
+}
+
+func TestCache_WebhookOutbox(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	job := &WebhookJob{Endpoint: "crm", Event: "violation_detected", Body: []byte(`{"event":"violation_detected"}`)}
+	if err := c.EnqueueWebhook(ctx, job); err != nil || job.ID == "" {
+		t.Fatalf("EnqueueWebhook = %q, %v", job.ID, err)
+	}
+
+	claimed, err := c.ClaimDueWebhooks(ctx, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].Endpoint != "crm" {
+		t.Fatalf("ClaimDueWebhooks = %+v, %v", claimed, err)
+	}
+	// взятое задание сдвинуто на lease и повторно не выдаётся
+	if again, _ := c.ClaimDueWebhooks(ctx, time.Minute, 10); len(again) != 0 {
+		t.Errorf("leased job claimed twice: %+v", again)
+	}
+
+	claimed[0].Attempts = 3
+	if err := c.DeadLetterWebhook(ctx, &claimed[0]); err != nil {
+		t.Fatalf("DeadLetterWebhook error: %v", err)
+	}
+	if pending, dead, _ := c.WebhookOutboxLen(ctx); pending != 0 || dead != 1 {
+		t.Errorf("pending=%d dead=%d, want 0/1", pending, dead)
+	}
+
+	ok, err := c.ReplayDeadWebhook(ctx, job.ID)
+	if err != nil || !ok {
+		t.Fatalf("ReplayDeadWebhook = %v, %v", ok, err)
+	}
+	claimed, _ = c.ClaimDueWebhooks(ctx, time.Minute, 10)
+	if len(claimed) != 1 || claimed[0].Attempts != 0 || claimed[0].DeadAt != nil {
+		t.Fatalf("replayed job = %+v, want fresh attempts", claimed)
+	}
+	if err := c.CompleteWebhook(ctx, job.ID); err != nil {
+		t.Fatalf("CompleteWebhook error: %v", err)
+	}
+	if pending, dead, _ := c.WebhookOutboxLen(ctx); pending != 0 || dead != 0 {
+		t.Errorf("pending=%d dead=%d after complete", pending, dead)
+	}
+	if ok, _ := c.ReplayDeadWebhook(ctx, "missing"); ok {
+		t.Error("replay of unknown job must return false")
+	}
+}
+
+func TestCache_DeadLetterCapped(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < 12; i++ {
+		job := &WebhookJob{Endpoint: "crm", Event: "violation_detected", Body: []byte(`{}`)}
+		if err := c.EnqueueWebhook(ctx, job); err != nil {
+			t.Fatalf("EnqueueWebhook error: %v", err)
+		}
+		if err := c.deadLetterWebhook(ctx, job, 3); err != nil {
+			t.Fatalf("deadLetterWebhook error: %v", err)
+		}
+	}
+
+	dead, err := c.ListDeadWebhooks(ctx)
+	if err != nil {
+		t.Fatalf("ListDeadWebhooks error: %v", err)
+	}
+	var ids []string
+	for _, job := range dead {
+		ids = append(ids, job.ID)
+	}
+	// Вытесняются по числовому ID: "10" новее "9".
+	if len(ids) != 3 || ids[0] != "10" || ids[2] != "12" {
+		t.Errorf("dead-letter = %v, want [10 11 12]", ids)
+	}
+	if pending, _, _ := c.WebhookOutboxLen(ctx); pending != 0 {
+		t.Errorf("pending = %d, want 0", pending)
+	}
+}
+
+func TestCache_DeleteUserAndCancelRestore(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	if err := c.SetUser(ctx, 5, &api.CachedUser{UserID: 5}, time.Minute); err != nil {
+		t.Fatalf("SetUser error: %v", err)
+	}
+	if err := c.DeleteUser(ctx, 5); err != nil {
+		t.Fatalf("DeleteUser error: %v", err)
+	}
+	if u, _ := c.GetUser(ctx, 5); u != nil {
+		t.Errorf("user still cached: %+v", u)
+	}
+
+	if err := c.SetRestoreTimer(ctx, 5, time.Hour); err != nil {
+		t.Fatalf("SetRestoreTimer error: %v", err)
+	}
+	if ok, err := c.CancelRestoreTimer(ctx, 5); err != nil || !ok {
+		t.Errorf("CancelRestoreTimer = %v, %v; want true", ok, err)
+	}
+	if ok, _ := c.CancelRestoreTimer(ctx, 5); ok {
+		t.Error("second cancel must report no timer")
+	}
+}
+
+// Cooldown отдельных алертов хранится по видам и не попадает в список
+// обычных cooldown.
+func TestCache_AlertCooldown(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	active, err := c.IsAlertCooldownActive(ctx, AlertTravel, 7)
+	if err != nil || active {
+		t.Fatalf("IsAlertCooldownActive до установки = %v, %v", active, err)
+	}
+	if err := c.SetAlertCooldown(ctx, AlertTravel, 7, time.Minute); err != nil {
+		t.Fatalf("SetAlertCooldown error: %v", err)
+	}
+	active, err = c.IsAlertCooldownActive(ctx, AlertTravel, 7)
+	if err != nil || !active {
+		t.Fatalf("IsAlertCooldownActive после установки = %v, %v", active, err)
+	}
+	if active, _ := c.IsAlertCooldownActive(ctx, AlertVPNReseller, 7); active {
+		t.Error("cooldown перемещения не должен включать cooldown другого вида")
+	}
+	if active, _ := c.IsCooldownActive(ctx, 7); active {
+		t.Error("cooldown алерта не должен включать обычный cooldown")
+	}
+	if n, _ := c.client.Exists(ctx, "cooldown:travel:7").Result(); n != 1 {
+		t.Error("ожидался ключ cooldown:travel:7")
+	}
+
+	list, err := c.ListCooldowns(ctx)
+	if err != nil {
+		t.Fatalf("ListCooldowns error: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("ListCooldowns = %v, want empty", list)
+	}
+}
+
+func TestCache_Score(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	if s, err := c.GetScore(ctx, 5); err != nil || s != nil {
+		t.Fatalf("GetScore до установки = %v, %v", s, err)
+	}
+	want := &Score{Value: 72, Groups: 35, Persistence: 20, History: 17, At: time.Unix(1700000000, 0).UTC()}
+	if err := c.SetScore(ctx, 5, want, time.Minute); err != nil {
+		t.Fatalf("SetScore error: %v", err)
+	}
+	got, err := c.GetScore(ctx, 5)
+	if err != nil || got == nil || *got != *want {
+		t.Fatalf("GetScore = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+// Начало превышения не сдвигается повторными отметками и сбрасывается, когда
+// ключ истёк.
+func TestCache_TouchExcess(t *testing.T) {
+	c := setupTestCache(t)
+	ctx := context.Background()
+
+	first, err := c.TouchExcess(ctx, 5, time.Minute)
+	if err != nil {
+		t.Fatalf("TouchExcess error: %v", err)
+	}
+	if time.Since(first) > 5*time.Second {
+		t.Errorf("first = %v, ожидалось сейчас", first)
+	}
+
+	old := time.Now().Add(-10 * time.Minute).Unix()
+	c.client.Set(ctx, "excess:since:5", old, time.Minute)
+	since, err := c.TouchExcess(ctx, 5, time.Minute)
+	if err != nil || since.Unix() != old {
+		t.Errorf("TouchExcess = %v, %v, want %d", since, err, old)
+	}
+	if ttl := c.client.TTL(ctx, "excess:since:5").Val(); ttl <= 0 {
+		t.Errorf("TTL = %v, ожидалось продление", ttl)
+	}
+
+	c.client.Del(ctx, "excess:since:5")
+	since, _ = c.TouchExcess(ctx, 5, time.Minute)
+	if since.Unix() == old {
+		t.Error("после истечения отсчёт должен начаться заново")
+	}
+}
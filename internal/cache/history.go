@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const prefixHistory = "history:"
+
+const (
+	HistoryViolation = "violation"
+	HistoryAlert     = "alert"
+	HistoryAction    = "action"
+)
+
+// Причины нарушения в HistoryEntry.Reason и webhook ViolationPayload.Reason.
+// У превышения лимита устройств Reason в истории пуст.
+const (
+	ReasonDeviceLimit      = "device_limit"
+	ReasonImpossibleTravel = "impossible_travel"
+	ReasonVPNReseller      = "vpn_reseller"
+	ReasonHWIDMismatch     = "hwid_mismatch"
+)
+
+type HistoryIP struct {
+	IP       string    `json:"ip"`
+	NodeName string    `json:"node_name,omitempty"`
+	NodeUUID string    `json:"node_uuid,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+	ASN      uint32    `json:"asn,omitempty"`
+	ASNOrg   string    `json:"asn_org,omitempty"`
+	Country  string    `json:"country,omitempty"`
+	ASNClass string    `json:"asn_class,omitempty"`
+}
+
+// HistoryEntry — запись в истории пользователя: обнаруженное нарушение со всеми
+// IP, из-за которых оно сработало, или действие администратора над пользователем.
+type HistoryEntry struct {
+	At           time.Time   `json:"at"`
+	Kind         string      `json:"kind"`
+	Action       string      `json:"action"`
+	Actor        string      `json:"actor,omitempty"`
+	Reason       string      `json:"reason,omitempty"`
+	Username     string      `json:"username,omitempty"`
+	DeviceCount  int         `json:"device_count,omitempty"`
+	Limit        int         `json:"limit,omitempty"`
+	LimitSource  string      `json:"limit_source,omitempty"`
+	GroupingMode string      `json:"grouping_mode,omitempty"`
+	DurationMin  int         `json:"duration_min,omitempty"`
+	Escalation   int         `json:"escalation_step,omitempty"`
+	Score        int         `json:"score,omitempty"`
+	HWIDDevices  int         `json:"hwid_devices,omitempty"`
+	IPs          []HistoryIP `json:"ips,omitempty"`
+}
+
+// AppendHistory добавляет запись в начало истории, обрезает её до maxEntries
+// и продлевает срок хранения ключа до retention.
+func (c *Cache) AppendHistory(ctx context.Context, userID int64, entry *HistoryEntry, maxEntries int, retention time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal history entry: %w", err)
+	}
+	key := prefixHistory + formatUserID(userID)
+
+	pipe := c.client.Pipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, int64(maxEntries-1))
+	pipe.Expire(ctx, key, retention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("append history: %w", err)
+	}
+	return nil
+}
+
+// GetHistory возвращает записи новее since, от свежих к старым.
+func (c *Cache) GetHistory(ctx context.Context, userID int64, since time.Time) ([]HistoryEntry, error) {
+	raw, err := c.client.LRange(ctx, prefixHistory+formatUserID(userID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get history: %w", err)
+	}
+	out := make([]HistoryEntry, 0, len(raw))
+	for _, item := range raw {
+		var e HistoryEntry
+		if err := json.Unmarshal([]byte(item), &e); err != nil {
+			continue
+		}
+		if e.At.Before(since) {
+			break
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
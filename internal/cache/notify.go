@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	keyNotifyOptOut    = "notify:optout"
+	prefixNotifyRecent = "notify:recent:"
+)
+
+// SetNotifyOptOut включает или выключает отказ подписчика (по Telegram ID)
+// от личных уведомлений.
+func (c *Cache) SetNotifyOptOut(ctx context.Context, telegramID int64, optOut bool) error {
+	member := strconv.FormatInt(telegramID, 10)
+	if optOut {
+		return c.client.SAdd(ctx, keyNotifyOptOut, member).Err()
+	}
+	return c.client.SRem(ctx, keyNotifyOptOut, member).Err()
+}
+
+func (c *Cache) IsNotifyOptedOut(ctx context.Context, telegramID int64) (bool, error) {
+	return c.client.SIsMember(ctx, keyNotifyOptOut, strconv.FormatInt(telegramID, 10)).Result()
+}
+
+// TryNotifySlot занимает слот уведомления пользователя на interval. Возвращает
+// false, если уведомление уже отправлялось в течение interval.
+func (c *Cache) TryNotifySlot(ctx context.Context, userID int64, interval time.Duration) (bool, error) {
+	ok, err := c.client.SetNX(ctx, prefixNotifyRecent+formatUserID(userID), "1", interval).Result()
+	if err != nil {
+		return false, fmt.Errorf("notify slot: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyWebhookSeq  = "webhook:outbox:seq"
+	keyWebhookJobs = "webhook:outbox"
+	keyWebhookDue  = "webhook:outbox:due"
+	keyWebhookDead = "webhook:dead"
+
+	// maxDeadWebhooks — сколько заданий держит dead-letter; старые вытесняются.
+	maxDeadWebhooks = 1000
+)
+
+// WebhookJob — событие, ожидающее доставки в один endpoint. Body хранится
+// готовым к отправке, подпись считается в момент доставки.
+type WebhookJob struct {
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint"`
+	Event    string `json:"event"`
+	// ContentType зависит от формата endpoint'а (json, CloudEvents, Slack, Discord).
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	DeadAt      *time.Time      `json:"dead_at,omitempty"`
+}
+
+func (c *Cache) EnqueueWebhook(ctx context.Context, job *WebhookJob) error {
+	seq, err := c.client.Incr(ctx, keyWebhookSeq).Result()
+	if err != nil {
+		return fmt.Errorf("webhook seq: %w", err)
+	}
+	job.ID = strconv.FormatInt(seq, 10)
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	return c.scheduleWebhook(ctx, job, time.Now())
+}
+
+func (c *Cache) scheduleWebhook(ctx context.Context, job *WebhookJob, at time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("marshal webhook job: %w", err)
+	}
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keyWebhookJobs, job.ID, data)
+		pipe.ZAdd(ctx, keyWebhookDue, redis.Z{Score: float64(at.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("schedule webhook: %w", err)
+	}
+	return nil
+}
+
+// claimDueWebhooks выбирает созревшие задания и сдвигает их срок на lease:
+// если процесс упадёт посреди доставки, задание вернётся в работу само.
+var claimDueWebhooks = redis.NewScript(`
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+	local out = {}
+	for _, id in ipairs(ids) do
+		local job = redis.call('HGET', KEYS[2], id)
+		if job then
+			redis.call('ZADD', KEYS[1], ARGV[2], id)
+			table.insert(out, job)
+		else
+			redis.call('ZREM', KEYS[1], id)
+		end
+	end
+	return out
+`)
+
+func (c *Cache) ClaimDueWebhooks(ctx context.Context, lease time.Duration, limit int) ([]WebhookJob, error) {
+	now := time.Now()
+	res, err := claimDueWebhooks.Run(ctx, c.client, []string{keyWebhookDue, keyWebhookJobs},
+		now.UnixMilli(), now.Add(lease).UnixMilli(), limit).StringSlice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim webhooks: %w", err)
+	}
+	jobs := make([]WebhookJob, 0, len(res))
+	for _, raw := range res {
+		var job WebhookJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (c *Cache) CompleteWebhook(ctx context.Context, id string) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, keyWebhookJobs, id)
+		pipe.ZRem(ctx, keyWebhookDue, id)
+		return nil
+	})
+	return err
+}
+
+func (c *Cache) RetryWebhook(ctx context.Context, job *WebhookJob, at time.Time) error {
+	return c.scheduleWebhook(ctx, job, at)
+}
+
+// moveToDeadLetter переносит задание в dead-letter и вытесняет самые старые
+// (с меньшим ID) задания сверх ARGV[3].
+var moveToDeadLetter = redis.NewScript(`
+	redis.call('HDEL', KEYS[1], ARGV[1])
+	redis.call('ZREM', KEYS[2], ARGV[1])
+	redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+	local excess = redis.call('HLEN', KEYS[3]) - tonumber(ARGV[3])
+	if excess > 0 then
+		local ids = redis.call('HKEYS', KEYS[3])
+		table.sort(ids, function(a, b) return tonumber(a) < tonumber(b) end)
+		for i = 1, excess do
+			redis.call('HDEL', KEYS[3], ids[i])
+		end
+	end
+	return 0
+`)
+
+// DeadLetterWebhook убирает задание из очереди в dead-letter, откуда его
+// можно переотправить через ReplayDeadWebhook. Хранятся последние
+// maxDeadWebhooks заданий.
+func (c *Cache) DeadLetterWebhook(ctx context.Context, job *WebhookJob) error {
+	return c.deadLetterWebhook(ctx, job, maxDeadWebhooks)
+}
+
+func (c *Cache) deadLetterWebhook(ctx context.Context, job *WebhookJob, maxDead int) error {
+	now := time.Now()
+	job.DeadAt = &now
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("marshal webhook job: %w", err)
+	}
+	err = moveToDeadLetter.Run(ctx, c.client, []string{keyWebhookJobs, keyWebhookDue, keyWebhookDead},
+		job.ID, data, maxDead).Err()
+	if err != nil {
+		return fmt.Errorf("dead-letter webhook: %w", err)
+	}
+	return nil
+}
+
+// ListDeadWebhooks возвращает dead-letter от старых заданий к новым.
+func (c *Cache) ListDeadWebhooks(ctx context.Context) ([]WebhookJob, error) {
+	res, err := c.client.HGetAll(ctx, keyWebhookDead).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list dead webhooks: %w", err)
+	}
+	jobs := make([]WebhookJob, 0, len(res))
+	for _, raw := range res {
+		var job WebhookJob
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, _ := strconv.ParseInt(jobs[i].ID, 10, 64)
+		b, _ := strconv.ParseInt(jobs[j].ID, 10, 64)
+		return a < b
+	})
+	return jobs, nil
+}
+
+// ReplayDeadWebhook возвращает задание из dead-letter в очередь со сброшенным
+// счётчиком попыток. false — задания с таким ID нет.
+func (c *Cache) ReplayDeadWebhook(ctx context.Context, id string) (bool, error) {
+	raw, err := c.client.HGet(ctx, keyWebhookDead, id).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get dead webhook: %w", err)
+	}
+	var job WebhookJob
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return false, fmt.Errorf("unmarshal dead webhook: %w", err)
+	}
+	job.Attempts = 0
+	job.LastError = ""
+	job.DeadAt = nil
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return false, fmt.Errorf("marshal webhook job: %w", err)
+	}
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, keyWebhookDead, id)
+		pipe.HSet(ctx, keyWebhookJobs, id, data)
+		pipe.ZAdd(ctx, keyWebhookDue, redis.Z{Score: float64(time.Now().UnixMilli()), Member: id})
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("replay webhook: %w", err)
+	}
+	return true, nil
+}
+
+func (c *Cache) DeleteDeadWebhook(ctx context.Context, id string) (bool, error) {
+	n, err := c.client.HDel(ctx, keyWebhookDead, id).Result()
+	if err != nil {
+		return false, fmt.Errorf("delete dead webhook: %w", err)
+	}
+	return n > 0, nil
+}
+
+// WebhookOutboxLen возвращает число заданий в очереди и в dead-letter.
+func (c *Cache) WebhookOutboxLen(ctx context.Context) (pending, dead int64, err error) {
+	pipe := c.client.Pipeline()
+	pendingCmd := pipe.HLen(ctx, keyWebhookJobs)
+	deadCmd := pipe.HLen(ctx, keyWebhookDead)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("webhook outbox len: %w", err)
+	}
+	return pendingCmd.Val(), deadCmd.Val(), nil
+}
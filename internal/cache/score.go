@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	prefixScore       = "score:"
+	prefixExcessSince = "excess:since:"
+)
+
+// Score — оценка уверенности в нарушении (SCORING) и вклад каждого сигнала в
+// баллах; Value — их сумма, 0..100.
+type Score struct {
+	Value       int       `json:"value"`
+	Groups      int       `json:"groups"`
+	Persistence int       `json:"persistence"`
+	Nodes       int       `json:"nodes"`
+	Countries   int       `json:"countries"`
+	ASN         int       `json:"asn"`
+	History     int       `json:"history"`
+	At          time.Time `json:"at"`
+}
+
+func (c *Cache) SetScore(ctx context.Context, userID int64, score *Score, ttl time.Duration) error {
+	data, err := json.Marshal(score)
+	if err != nil {
+		return fmt.Errorf("marshal score: %w", err)
+	}
+	return c.client.Set(ctx, prefixScore+formatUserID(userID), data, ttl).Err()
+}
+
+// GetScore возвращает последнюю оценку пользователя или nil, если её нет.
+func (c *Cache) GetScore(ctx context.Context, userID int64) (*Score, error) {
+	data, err := c.client.Get(ctx, prefixScore+formatUserID(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get score: %w", err)
+	}
+	var s Score
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("unmarshal score: %w", err)
+	}
+	return &s, nil
+}
+
+// TouchExcess отмечает, что пользователь сейчас превышает лимит, и возвращает
+// начало непрерывного превышения. Ключ живёт ttl с последней отметки: если
+// превышения не было дольше ttl, отсчёт начинается заново.
+func (c *Cache) TouchExcess(ctx context.Context, userID int64, ttl time.Duration) (time.Time, error) {
+	key := prefixExcessSince + formatUserID(userID)
+	pipe := c.client.TxPipeline()
+	pipe.SetNX(ctx, key, time.Now().Unix(), ttl)
+	get := pipe.Get(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("touch excess: %w", err)
+	}
+	since, err := get.Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("touch excess: %w", err)
+	}
+	return time.Unix(since, 0), nil
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	prefixShadow = "shadow:"
+	shadowTTL    = 72 * time.Hour
+)
+
+// Виды расхождений между живым и shadow-конфигом.
+const (
+	ShadowOnly    = "shadow_only"
+	LiveOnly      = "live_only"
+	ShadowDisable = "shadow_disable"
+)
+
+var ShadowKinds = []string{ShadowOnly, LiveOnly, ShadowDisable}
+
+type ShadowUser struct {
+	UserID   int64
+	Username string
+}
+
+func shadowKey(day, kind string) string {
+	return prefixShadow + day + ":" + kind
+}
+
+// AddShadowDiff отмечает пользователя в расхождениях за день. Возвращает true,
+// если за этот день он попал в kind впервые.
+func (c *Cache) AddShadowDiff(ctx context.Context, day, kind string, userID int64, username string) (bool, error) {
+	key := shadowKey(day, kind)
+	pipe := c.client.Pipeline()
+	added := pipe.HSetNX(ctx, key, formatUserID(userID), username)
+	pipe.Expire(ctx, key, shadowTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("add shadow diff: %w", err)
+	}
+	return added.Val(), nil
+}
+
+// GetShadowDiff возвращает расхождения за день по видам, пользователи
+// отсортированы по ID.
+func (c *Cache) GetShadowDiff(ctx context.Context, day string) (map[string][]ShadowUser, error) {
+	pipe := c.client.Pipeline()
+	cmds := make(map[string]*redis.MapStringStringCmd, len(ShadowKinds))
+	for _, kind := range ShadowKinds {
+		cmds[kind] = pipe.HGetAll(ctx, shadowKey(day, kind))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("get shadow diff: %w", err)
+	}
+
+	out := make(map[string][]ShadowUser, len(ShadowKinds))
+	for kind, cmd := range cmds {
+		users := make([]ShadowUser, 0, len(cmd.Val()))
+		for id, username := range cmd.Val() {
+			userID, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				continue
+			}
+			users = append(users, ShadowUser{UserID: userID, Username: username})
+		}
+		sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+		out[kind] = users
+	}
+	return out, nil
+}
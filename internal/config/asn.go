@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseASNList разбирает список ASN через запятую; префикс AS допускается
+// (AS12389 и 12389 — одно и то же).
+func ParseASNList(s string) ([]uint32, error) {
+	var out []uint32
+	for _, item := range parseList(s) {
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(item), "AS"), 10, 32)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("неверный номер ASN %q", item)
+		}
+		out = append(out, uint32(n))
+	}
+	return out, nil
+}
+
+func (l *loader) getEnvASNList(key string) []uint32 {
+	list, err := ParseASNList(l.lookup(key))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	return list
+}
+
+// validateASNClasses запрещает один ASN в нескольких классах — иначе
+// результат классификации зависел бы от порядка списков.
+func validateASNClasses(cfg *Config) error {
+	seen := make(map[uint32]string)
+	for _, class := range []struct {
+		key  string
+		asns []uint32
+	}{
+		{"ASN_MOBILE", cfg.ASNMobile},
+		{"ASN_RESIDENTIAL", cfg.ASNResidential},
+		{"ASN_HOSTING", cfg.ASNHosting},
+	} {
+		for _, asn := range class.asns {
+			if prev, ok := seen[asn]; ok && prev != class.key {
+				return fmt.Errorf("%s: AS%d уже указан в %s", class.key, asn, prev)
+			}
+			seen[asn] = class.key
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestParseASNList(t *testing.T) {
+	got, err := ParseASNList(" AS12389, 8359 ,as3216")
+	if err != nil {
+		t.Fatalf("ParseASNList error: %v", err)
+	}
+	if want := []uint32{12389, 8359, 3216}; !slices.Equal(got, want) {
+		t.Errorf("ParseASNList = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"ASX", "0", "4294967296", "-1"} {
+		if _, err := ParseASNList(bad); err == nil {
+			t.Errorf("ParseASNList(%q): expected error", bad)
+		}
+	}
+}
+
+func TestLoadConfig_ASNClasses(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	defer clearEnv()
+	os.Setenv("ASN_MOBILE", "12389,8359")
+	os.Setenv("ASN_HOSTING", "AS24940")
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !slices.Equal(cfg.ASNMobile, []uint32{12389, 8359}) || !slices.Equal(cfg.ASNHosting, []uint32{24940}) {
+		t.Errorf("ASNMobile = %v, ASNHosting = %v", cfg.ASNMobile, cfg.ASNHosting)
+	}
+	if cfg.VPNResellerAlert || cfg.VPNResellerMinIPs != 2 {
+		t.Errorf("VPN reseller defaults = %v/%d, want false/2", cfg.VPNResellerAlert, cfg.VPNResellerMinIPs)
+	}
+
+	// один ASN в двух классах — ошибка конфигурации
+	os.Setenv("ASN_HOSTING", "24940,8359")
+	if _, err := LoadConfig(""); err == nil {
+		t.Error("expected error for ASN in both ASN_MOBILE and ASN_HOSTING")
+	}
+}
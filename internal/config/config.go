@@ -23,7 +23,11 @@ type Config struct {
 	ToleranceMultiplier      float64
 	Cooldown                 int
 	UserCacheTTL             int
+	UserPrefetch             bool
+	UserPrefetchInterval     int
+	UserPrefetchPageSize     int
 	DefaultDeviceLimit       int
+	LimitPolicies            []LimitPolicy
 	ActionMode               string
 	AutoDisableDuration      int
 	AutoNotifySoft           bool
@@ -44,25 +48,95 @@ type Config struct {
 	RemnawaveHeaders         string
 	WebhookURL               string
 	WebhookSecret            string
+	Webhooks                 []WebhookEndpoint
+	WebhookMaxAttempts       int
 	SubnetGrouping           bool
 	SubnetPrefixV4           int
+	SubnetPrefixV6           int
 	ASNGrouping              bool
+	CountryGrouping          bool
+	HybridGrouping           bool
+	CountingMode             string
+	ConcurrentMinChecks      int
+	ASNMobile                []uint32
+	ASNResidential           []uint32
+	ASNHosting               []uint32
+	VPNResellerAlert         bool
+	VPNResellerMinIPs        int
+	HWIDCheck                bool
+	HWIDTolerance            int
 	ASNDatabasePath          string
 	MaxMindLicenseKey        string
 	MaxMindUpdateInterval    time.Duration
+	ImpossibleTravel         bool
+	ImpossibleTravelKm       int
+	GeoDatabasePath          string
+	GeoDatabaseEdition       string
 	ViolationThreshold       int
 	ViolationThresholdWindow int
+	Scoring                  bool
+	ScoreSoft                int
+	ScoreAlert               int
+	ScoreDisable             int
+	ViolationHistoryLimit    int
+	ViolationHistoryDays     int
+	EscalationLadder         []EscalationStep
+	EscalationWindowDays     int
 	IgnoredNodeUUIDs         []string
+	NodeInclude              []NodeSelector
+	NodeExclude              []NodeSelector
+	NodeIntervals            []NodeInterval
+	NodeStaleMaxAge          int
+	NodeFailureAlertAfter    int
 	DailyReport              bool
 	DailyReportTime          string
 	HealthAddr               string
+	AdminAPIToken            string
+	RemnawaveWebhookSecret   string
+	UserNotify               bool
+	UserNotifyBotToken       string
+	UserNotifyInterval       int
+	UserNotifyLanguage       string
+	UserNotifyTemplatesDir   string
+
+	// Shadow — кандидатный конфиг из SHADOW_*; nil, если shadow-режим выключен.
+	Shadow          *Config
+	ShadowOverrides map[string]string
 }
 
 var (
 	LogLevels  = []string{"trace", "debug", "info", "warn", "error"}
 	LogFormats = []string{"text", "json"}
+	// GeoEditions — базы MaxMind со страной IP для IMPOSSIBLE_TRAVEL и
+	// COUNTRY_GROUPING.
+	GeoEditions = []string{"GeoLite2-City", "GeoLite2-Country"}
+	// CountingModes — как считать IP: все за ACTIVE_IP_WINDOW или только
+	// одновременно активные на нескольких проверках подряд.
+	CountingModes = []string{CountingWindow, CountingConcurrent}
 )
 
+const (
+	CountingWindow     = "window"
+	CountingConcurrent = "concurrent"
+)
+
+// GroupingMode — действующий режим группировки: country, hybrid, asn, subnet
+// или ip. При нескольких включённых флагах побеждает первый в этом порядке.
+func (cfg *Config) GroupingMode() string {
+	switch {
+	case cfg.CountryGrouping:
+		return "country"
+	case cfg.HybridGrouping:
+		return "hybrid"
+	case cfg.ASNGrouping:
+		return "asn"
+	case cfg.SubnetGrouping:
+		return "subnet"
+	default:
+		return "ip"
+	}
+}
+
 func LoadConfig(envPath string) (*Config, error) {
 	return LoadConfigWithOverrides(envPath, nil)
 }
@@ -120,7 +194,11 @@ func LoadConfigWithOverrides(envPath string, overrides map[string]string) (*Conf
 		ToleranceMultiplier:      l.getEnvFloat64("TOLERANCE_MULTIPLIER", 0),
 		Cooldown:                 l.getEnvInt("COOLDOWN", 300),
 		UserCacheTTL:             l.getEnvInt("USER_CACHE_TTL", 600),
+		UserPrefetch:             l.getEnvBool("USER_PREFETCH", false),
+		UserPrefetchInterval:     l.getEnvInt("USER_PREFETCH_INTERVAL", 300),
+		UserPrefetchPageSize:     l.getEnvInt("USER_PREFETCH_PAGE_SIZE", 500),
 		DefaultDeviceLimit:       l.getEnvInt("DEFAULT_DEVICE_LIMIT", 0),
+		LimitPolicies:            l.getEnvLimitPolicies("LIMIT_POLICIES"),
 		ActionMode:               l.getEnv("ACTION_MODE", "manual"),
 		AutoDisableDuration:      l.getEnvInt("AUTO_DISABLE_DURATION", 0),
 		AutoNotifySoft:           l.getEnvBool("AUTO_NOTIFY_SOFT", false),
@@ -141,20 +219,60 @@ func LoadConfigWithOverrides(envPath string, overrides map[string]string) (*Conf
 		RemnawaveHeaders:         l.getEnv("REMNAWAVE_HEADERS", ""),
 		WebhookURL:               l.getEnv("WEBHOOK_URL", ""),
 		WebhookSecret:            l.getEnv("WEBHOOK_SECRET", ""),
+		Webhooks:                 l.getEnvWebhooks(),
+		WebhookMaxAttempts:       l.getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
 		SubnetGrouping:           l.getEnvBool("SUBNET_GROUPING", false),
 		SubnetPrefixV4:           l.getEnvInt("SUBNET_PREFIX_V4", 24),
+		SubnetPrefixV6:           l.getEnvInt("SUBNET_PREFIX_V6", 64),
 		ASNGrouping:              l.getEnvBool("ASN_GROUPING", false),
+		CountryGrouping:          l.getEnvBool("COUNTRY_GROUPING", false),
+		HybridGrouping:           l.getEnvBool("HYBRID_GROUPING", false),
+		CountingMode:             strings.ToLower(l.getEnv("COUNTING_MODE", CountingWindow)),
+		ConcurrentMinChecks:      l.getEnvInt("CONCURRENT_MIN_CHECKS", 2),
+		ASNMobile:                l.getEnvASNList("ASN_MOBILE"),
+		ASNResidential:           l.getEnvASNList("ASN_RESIDENTIAL"),
+		ASNHosting:               l.getEnvASNList("ASN_HOSTING"),
+		VPNResellerAlert:         l.getEnvBool("VPN_RESELLER_ALERT", false),
+		VPNResellerMinIPs:        l.getEnvInt("VPN_RESELLER_MIN_IPS", 2),
+		HWIDCheck:                l.getEnvBool("HWID_CHECK", false),
+		HWIDTolerance:            l.getEnvInt("HWID_TOLERANCE", 0),
 		ASNDatabasePath:          l.getEnv("ASN_DATABASE_PATH", "./geoip/GeoLite2-ASN.mmdb"),
 		MaxMindLicenseKey:        l.getEnv("MAXMIND_LICENSE_KEY", ""),
 		MaxMindUpdateInterval:    l.getEnvDuration("MAXMIND_UPDATE_INTERVAL", 168*time.Hour),
+		ImpossibleTravel:         l.getEnvBool("IMPOSSIBLE_TRAVEL", false),
+		ImpossibleTravelKm:       l.getEnvInt("IMPOSSIBLE_TRAVEL_KM", 1000),
+		GeoDatabasePath:          l.getEnv("GEO_DATABASE_PATH", "./geoip/GeoLite2-City.mmdb"),
+		GeoDatabaseEdition:       l.getEnv("GEO_DATABASE_EDITION", "GeoLite2-City"),
 		ViolationThreshold:       l.getEnvInt("VIOLATION_THRESHOLD", 1),
 		ViolationThresholdWindow: l.getEnvInt("VIOLATION_THRESHOLD_WINDOW", 3600),
+		Scoring:                  l.getEnvBool("SCORING", false),
+		ScoreSoft:                l.getEnvInt("SCORE_SOFT", 40),
+		ScoreAlert:               l.getEnvInt("SCORE_ALERT", 60),
+		ScoreDisable:             l.getEnvInt("SCORE_DISABLE", 80),
+		ViolationHistoryLimit:    l.getEnvInt("VIOLATION_HISTORY_LIMIT", 50),
+		ViolationHistoryDays:     l.getEnvInt("VIOLATION_HISTORY_DAYS", 90),
+		EscalationLadder:         l.getEnvEscalationLadder("ESCALATION_LADDER"),
+		EscalationWindowDays:     l.getEnvInt("ESCALATION_WINDOW_DAYS", 30),
 		IgnoredNodeUUIDs:         parseLowercaseList(l.getEnv("IGNORED_NODE_UUIDS", "")),
+		NodeInclude:              l.getEnvNodeSelectors("NODE_INCLUDE"),
+		NodeExclude:              l.getEnvNodeSelectors("NODE_EXCLUDE"),
+		NodeIntervals:            l.getEnvNodeIntervals("NODE_INTERVALS"),
+		NodeStaleMaxAge:          l.getEnvInt("NODE_STALE_MAX_AGE", 300),
+		NodeFailureAlertAfter:    l.getEnvInt("NODE_FAILURE_ALERT_AFTER", 3),
 		DailyReport:              l.getEnvBool("DAILY_REPORT", false),
 		DailyReportTime:          l.getEnv("DAILY_REPORT_TIME", "09:00"),
 		HealthAddr:               l.getEnv("HEALTH_ADDR", ""),
+		AdminAPIToken:            l.getEnv("ADMIN_API_TOKEN", ""),
+		RemnawaveWebhookSecret:   l.getEnv("REMNAWAVE_WEBHOOK_SECRET", ""),
+		UserNotify:               l.getEnvBool("USER_NOTIFY", false),
+		UserNotifyBotToken:       l.getEnv("USER_NOTIFY_BOT_TOKEN", ""),
+		UserNotifyInterval:       l.getEnvInt("USER_NOTIFY_INTERVAL", 60),
+		UserNotifyTemplatesDir:   l.getEnv("USER_NOTIFY_TEMPLATES_DIR", ""),
+		ShadowOverrides:          l.shadowOverrides(),
 	}
 
+	cfg.UserNotifyLanguage = l.getEnv("USER_NOTIFY_LANGUAGE", cfg.Language)
+
 	if err := l.err(); err != nil {
 		return nil, err
 	}
@@ -163,6 +281,14 @@ func LoadConfigWithOverrides(envPath string, overrides map[string]string) (*Conf
 		return nil, err
 	}
 
+	if len(cfg.ShadowOverrides) > 0 {
+		shadow, err := loadShadow(envPath, overrides, cfg.ShadowOverrides)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Shadow = shadow
+	}
+
 	return cfg, nil
 }
 
@@ -178,6 +304,9 @@ func (cfg *Config) Validate() error {
 			return err
 		}
 	}
+	if err := validateWebhooks(cfg.Webhooks); err != nil {
+		return err
+	}
 	if err := validateRedisURL(cfg.RedisURL); err != nil {
 		return err
 	}
@@ -192,6 +321,15 @@ func (cfg *Config) Validate() error {
 		{"USER_CACHE_TTL", cfg.UserCacheTTL},
 		{"VIOLATION_THRESHOLD", cfg.ViolationThreshold},
 		{"VIOLATION_THRESHOLD_WINDOW", cfg.ViolationThresholdWindow},
+		{"VIOLATION_HISTORY_DAYS", cfg.ViolationHistoryDays},
+		{"ESCALATION_WINDOW_DAYS", cfg.EscalationWindowDays},
+		{"USER_NOTIFY_INTERVAL", cfg.UserNotifyInterval},
+		{"WEBHOOK_MAX_ATTEMPTS", cfg.WebhookMaxAttempts},
+		{"IMPOSSIBLE_TRAVEL_KM", cfg.ImpossibleTravelKm},
+		{"VPN_RESELLER_MIN_IPS", cfg.VPNResellerMinIPs},
+		{"CONCURRENT_MIN_CHECKS", cfg.ConcurrentMinChecks},
+		{"USER_PREFETCH_INTERVAL", cfg.UserPrefetchInterval},
+		{"USER_PREFETCH_PAGE_SIZE", cfg.UserPrefetchPageSize},
 	}
 	for _, p := range positive {
 		if p.value <= 0 {
@@ -207,6 +345,10 @@ func (cfg *Config) Validate() error {
 		{"DEFAULT_DEVICE_LIMIT", cfg.DefaultDeviceLimit},
 		{"AUTO_DISABLE_DURATION", cfg.AutoDisableDuration},
 		{"IGNORE_DURATION", cfg.IgnoreDuration},
+		{"VIOLATION_HISTORY_LIMIT", cfg.ViolationHistoryLimit},
+		{"HWID_TOLERANCE", cfg.HWIDTolerance},
+		{"NODE_STALE_MAX_AGE", cfg.NodeStaleMaxAge},
+		{"NODE_FAILURE_ALERT_AFTER", cfg.NodeFailureAlertAfter},
 	}
 	for _, p := range nonNegative {
 		if p.value < 0 {
@@ -220,9 +362,24 @@ func (cfg *Config) Validate() error {
 	if cfg.SubnetPrefixV4 < 8 || cfg.SubnetPrefixV4 > 32 {
 		return fmt.Errorf("SUBNET_PREFIX_V4 должен быть в диапазоне 8..32, получено %d", cfg.SubnetPrefixV4)
 	}
+	if cfg.SubnetPrefixV6 < 32 || cfg.SubnetPrefixV6 > 128 {
+		return fmt.Errorf("SUBNET_PREFIX_V6 должен быть в диапазоне 32..128, получено %d", cfg.SubnetPrefixV6)
+	}
+	if len(cfg.EscalationLadder) > 0 && cfg.ViolationHistoryLimit == 0 {
+		return fmt.Errorf("ESCALATION_LADDER считает нарушения по истории — VIOLATION_HISTORY_LIMIT не может быть 0")
+	}
 	if cfg.MaxMindUpdateInterval < time.Hour {
 		return fmt.Errorf("MAXMIND_UPDATE_INTERVAL должен быть >= 1h, получено %v", cfg.MaxMindUpdateInterval)
 	}
+	if err := validateScoreThresholds(cfg); err != nil {
+		return err
+	}
+	if err := validateASNClasses(cfg); err != nil {
+		return err
+	}
+	if !contains(GeoEditions, cfg.GeoDatabaseEdition) {
+		return fmt.Errorf("GEO_DATABASE_EDITION должен быть одним из %v, получено %q", GeoEditions, cfg.GeoDatabaseEdition)
+	}
 	if _, _, err := ParseDailyReportTime(cfg.DailyReportTime); err != nil {
 		return fmt.Errorf("DAILY_REPORT_TIME: %v", err)
 	}
@@ -232,9 +389,15 @@ func (cfg *Config) Validate() error {
 	if cfg.Language != "ru" && cfg.Language != "en" {
 		return fmt.Errorf("LANGUAGE должен быть \"ru\" или \"en\", получено %q", cfg.Language)
 	}
+	if cfg.UserNotifyLanguage != "ru" && cfg.UserNotifyLanguage != "en" {
+		return fmt.Errorf("USER_NOTIFY_LANGUAGE должен быть \"ru\" или \"en\", получено %q", cfg.UserNotifyLanguage)
+	}
 	if !contains(LogLevels, cfg.LogLevel) {
 		return fmt.Errorf("LOG_LEVEL должен быть одним из %v, получено %q", LogLevels, cfg.LogLevel)
 	}
+	if !contains(CountingModes, cfg.CountingMode) {
+		return fmt.Errorf("COUNTING_MODE должен быть одним из %v, получено %q", CountingModes, cfg.CountingMode)
+	}
 	if !contains(LogFormats, cfg.LogFormat) {
 		return fmt.Errorf("LOG_FORMAT должен быть одним из %v, получено %q", LogFormats, cfg.LogFormat)
 	}
@@ -307,6 +470,28 @@ func validateRedisURL(raw string) error {
 	return nil
 }
 
+// validateScoreThresholds — пороги SCORING в 1..100 и не убывают:
+// предупреждение ≤ алерт ≤ отключение.
+func validateScoreThresholds(cfg *Config) error {
+	for _, p := range []struct {
+		key   string
+		value int
+	}{
+		{"SCORE_SOFT", cfg.ScoreSoft},
+		{"SCORE_ALERT", cfg.ScoreAlert},
+		{"SCORE_DISABLE", cfg.ScoreDisable},
+	} {
+		if p.value < 1 || p.value > 100 {
+			return fmt.Errorf("%s должен быть в диапазоне 1..100, получено %d", p.key, p.value)
+		}
+	}
+	if cfg.ScoreSoft > cfg.ScoreAlert || cfg.ScoreAlert > cfg.ScoreDisable {
+		return fmt.Errorf("пороги должны не убывать: SCORE_SOFT (%d) ≤ SCORE_ALERT (%d) ≤ SCORE_DISABLE (%d)",
+			cfg.ScoreSoft, cfg.ScoreAlert, cfg.ScoreDisable)
+	}
+	return nil
+}
+
 func contains(list []string, value string) bool {
 	for _, v := range list {
 		if v == value {
@@ -397,6 +582,42 @@ func (l *loader) getEnvDuration(key string, defaultValue time.Duration) time.Dur
 	return d
 }
 
+func (l *loader) getEnvLimitPolicies(key string) []LimitPolicy {
+	policies, err := ParseLimitPolicies(l.lookup(key))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	return policies
+}
+
+func (l *loader) getEnvNodeSelectors(key string) []NodeSelector {
+	selectors, err := ParseNodeSelectors(l.lookup(key))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	return selectors
+}
+
+func (l *loader) getEnvNodeIntervals(key string) []NodeInterval {
+	intervals, err := ParseNodeIntervals(l.lookup(key))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	return intervals
+}
+
+func (l *loader) getEnvEscalationLadder(key string) []EscalationStep {
+	ladder, err := ParseEscalationLadder(l.lookup(key))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %v", key, err))
+		return nil
+	}
+	return ladder
+}
+
 func (l *loader) getEnvBool(key string, defaultValue bool) bool {
 	value := l.lookup(key)
 	if value == "" {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -11,7 +12,8 @@ func clearEnv() {
 	vars := []string{
 		"REMNAWAVE_API_URL", "REMNAWAVE_API_TOKEN",
 		"CHECK_INTERVAL", "ACTIVE_IP_WINDOW", "TOLERANCE", "TOLERANCE_MULTIPLIER", "COOLDOWN",
-		"USER_CACHE_TTL", "DEFAULT_DEVICE_LIMIT",
+		"USER_CACHE_TTL", "DEFAULT_DEVICE_LIMIT", "LIMIT_POLICIES",
+		"USER_PREFETCH", "USER_PREFETCH_INTERVAL", "USER_PREFETCH_PAGE_SIZE",
 		"ACTION_MODE", "AUTO_DISABLE_DURATION", "IGNORE_DURATION",
 		"TELEGRAM_BOT_TOKEN", "TELEGRAM_CHAT_ID", "TELEGRAM_THREAD_ID", "TELEGRAM_ADMIN_IDS",
 		"TELEGRAM_PROXY",
@@ -19,24 +21,40 @@ func clearEnv() {
 		"REDIS_URL",
 		"TIMEZONE",
 		"LANGUAGE",
-		"WEBHOOK_URL", "WEBHOOK_SECRET",
+		"WEBHOOK_URL", "WEBHOOK_SECRET", "WEBHOOK_FORMAT", "WEBHOOK_MAX_ATTEMPTS",
 		"SUBNET_GROUPING",
-		"SUBNET_PREFIX_V4",
-		"ASN_GROUPING",
+		"SUBNET_PREFIX_V4", "SUBNET_PREFIX_V6",
+		"ASN_GROUPING", "COUNTRY_GROUPING", "HYBRID_GROUPING",
+		"COUNTING_MODE", "CONCURRENT_MIN_CHECKS",
+		"ASN_MOBILE", "ASN_RESIDENTIAL", "ASN_HOSTING", "VPN_RESELLER_ALERT", "VPN_RESELLER_MIN_IPS",
+		"HWID_CHECK", "HWID_TOLERANCE",
 		"ASN_DATABASE_PATH",
 		"MAXMIND_LICENSE_KEY", "MAXMIND_UPDATE_INTERVAL",
-		"IGNORED_NODE_UUIDS",
+		"IMPOSSIBLE_TRAVEL", "IMPOSSIBLE_TRAVEL_KM", "GEO_DATABASE_PATH", "GEO_DATABASE_EDITION",
+		"IGNORED_NODE_UUIDS", "NODE_INCLUDE", "NODE_EXCLUDE", "NODE_INTERVALS",
+		"NODE_STALE_MAX_AGE", "NODE_FAILURE_ALERT_AFTER",
 		"IP_WHITELIST",
 		"VIOLATION_THRESHOLD", "VIOLATION_THRESHOLD_WINDOW",
+		"SCORING", "SCORE_SOFT", "SCORE_ALERT", "SCORE_DISABLE",
+		"VIOLATION_HISTORY_LIMIT", "VIOLATION_HISTORY_DAYS", "ESCALATION_LADDER", "ESCALATION_WINDOW_DAYS",
 		"AUTO_NOTIFY_SOFT",
 		"DAILY_REPORT", "DAILY_REPORT_TIME",
-		"HEALTH_ADDR",
+		"HEALTH_ADDR", "ADMIN_API_TOKEN", "REMNAWAVE_WEBHOOK_SECRET",
+		"USER_NOTIFY", "USER_NOTIFY_BOT_TOKEN", "USER_NOTIFY_INTERVAL", "USER_NOTIFY_LANGUAGE", "USER_NOTIFY_TEMPLATES_DIR",
 		"LOG_LEVEL", "LOG_FORMAT",
 		"REMNAWAVE_COOKIES", "REMNAWAVE_HEADERS",
 	}
 	for _, v := range vars {
 		os.Unsetenv(v)
 	}
+	for _, key := range ShadowKeys {
+		os.Unsetenv(shadowPrefix + key)
+	}
+	for i := 1; i <= 5; i++ {
+		for _, suffix := range []string{"URL", "NAME", "SECRET", "FORMAT", "EVENTS", "USERS"} {
+			os.Unsetenv(fmt.Sprintf("WEBHOOK_%d_%s", i, suffix))
+		}
+	}
 }
 
 func setRequiredEnv() {
@@ -258,6 +276,9 @@ func TestLoadConfig_SubnetPrefix_Defaults(t *testing.T) {
 	if cfg.SubnetPrefixV4 != 24 {
 		t.Errorf("SubnetPrefixV4 = %d, want 24", cfg.SubnetPrefixV4)
 	}
+	if cfg.SubnetPrefixV6 != 64 {
+		t.Errorf("SubnetPrefixV6 = %d, want 64", cfg.SubnetPrefixV6)
+	}
 }
 
 func TestLoadConfig_SubnetPrefix_Custom(t *testing.T) {
@@ -265,6 +286,8 @@ func TestLoadConfig_SubnetPrefix_Custom(t *testing.T) {
 	setRequiredEnv()
 	os.Setenv("SUBNET_PREFIX_V4", "16")
 	defer os.Unsetenv("SUBNET_PREFIX_V4")
+	os.Setenv("SUBNET_PREFIX_V6", "56")
+	defer os.Unsetenv("SUBNET_PREFIX_V6")
 
 	cfg, err := LoadConfig("")
 	if err != nil {
@@ -274,6 +297,9 @@ func TestLoadConfig_SubnetPrefix_Custom(t *testing.T) {
 	if cfg.SubnetPrefixV4 != 16 {
 		t.Errorf("SubnetPrefixV4 = %d, want 16", cfg.SubnetPrefixV4)
 	}
+	if cfg.SubnetPrefixV6 != 56 {
+		t.Errorf("SubnetPrefixV6 = %d, want 56", cfg.SubnetPrefixV6)
+	}
 }
 
 func TestLoadConfig_SubnetPrefix_ValidationV4(t *testing.T) {
@@ -301,6 +327,21 @@ func TestLoadConfig_SubnetPrefix_ValidationV4(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_SubnetPrefix_ValidationV6(t *testing.T) {
+	for _, value := range []string{"31", "129", "0"} {
+		t.Run(value, func(t *testing.T) {
+			clearEnv()
+			setRequiredEnv()
+			os.Setenv("SUBNET_PREFIX_V6", value)
+			defer os.Unsetenv("SUBNET_PREFIX_V6")
+
+			if _, err := LoadConfig(""); err == nil {
+				t.Errorf("expected validation error for SUBNET_PREFIX_V6=%s, got nil", value)
+			}
+		})
+	}
+}
+
 func TestLoadConfig_ASNGrouping_Default(t *testing.T) {
 	clearEnv()
 	setRequiredEnv()
@@ -422,6 +463,115 @@ func TestLoadConfig_MaxMind_IntervalInvalid_Fails(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_ImpossibleTravel(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ImpossibleTravel || cfg.ImpossibleTravelKm != 1000 || cfg.GeoDatabaseEdition != "GeoLite2-City" {
+		t.Errorf("defaults = %v/%d/%q, want false/1000/GeoLite2-City", cfg.ImpossibleTravel, cfg.ImpossibleTravelKm, cfg.GeoDatabaseEdition)
+	}
+
+	os.Setenv("IMPOSSIBLE_TRAVEL", "true")
+	os.Setenv("IMPOSSIBLE_TRAVEL_KM", "500")
+	os.Setenv("GEO_DATABASE_EDITION", "GeoLite2-Country")
+	defer clearEnv()
+
+	cfg, err = LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.ImpossibleTravel || cfg.ImpossibleTravelKm != 500 || cfg.GeoDatabaseEdition != "GeoLite2-Country" {
+		t.Errorf("got %v/%d/%q, want true/500/GeoLite2-Country", cfg.ImpossibleTravel, cfg.ImpossibleTravelKm, cfg.GeoDatabaseEdition)
+	}
+}
+
+func TestLoadConfig_ImpossibleTravel_Invalid(t *testing.T) {
+	for _, tc := range []struct{ key, value string }{
+		{"IMPOSSIBLE_TRAVEL_KM", "0"},
+		{"GEO_DATABASE_EDITION", "GeoLite2-ASN"},
+	} {
+		clearEnv()
+		setRequiredEnv()
+		os.Setenv(tc.key, tc.value)
+		if _, err := LoadConfig(""); err == nil {
+			t.Errorf("%s=%s: expected validation error, got nil", tc.key, tc.value)
+		}
+	}
+	clearEnv()
+}
+
+func TestLoadConfig_Scoring(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	defer clearEnv()
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Scoring || cfg.ScoreSoft != 40 || cfg.ScoreAlert != 60 || cfg.ScoreDisable != 80 {
+		t.Errorf("defaults = %v %d/%d/%d, want false 40/60/80", cfg.Scoring, cfg.ScoreSoft, cfg.ScoreAlert, cfg.ScoreDisable)
+	}
+
+	// Пороги вне 1..100 или убывающие — ошибка.
+	for _, env := range []map[string]string{
+		{"SCORE_SOFT": "0"},
+		{"SCORE_DISABLE": "101"},
+		{"SCORE_SOFT": "70"},
+		{"SCORE_ALERT": "90"},
+	} {
+		clearEnv()
+		setRequiredEnv()
+		for k, v := range env {
+			os.Setenv(k, v)
+		}
+		if _, err := LoadConfig(""); err == nil {
+			t.Errorf("%v: expected validation error, got nil", env)
+		}
+	}
+}
+
+func TestLoadConfig_CountingMode(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	defer clearEnv()
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CountingMode != CountingWindow || cfg.ConcurrentMinChecks != 2 {
+		t.Errorf("defaults = %q/%d, want window/2", cfg.CountingMode, cfg.ConcurrentMinChecks)
+	}
+
+	os.Setenv("COUNTING_MODE", "Concurrent")
+	cfg, err = LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CountingMode != CountingConcurrent {
+		t.Errorf("CountingMode = %q, want concurrent", cfg.CountingMode)
+	}
+
+	for _, env := range []map[string]string{
+		{"COUNTING_MODE": "overlap"},
+		{"CONCURRENT_MIN_CHECKS": "0"},
+	} {
+		clearEnv()
+		setRequiredEnv()
+		for k, v := range env {
+			os.Setenv(k, v)
+		}
+		if _, err := LoadConfig(""); err == nil {
+			t.Errorf("%v: expected validation error, got nil", env)
+		}
+	}
+}
+
 func TestLoadConfig_TelegramProxy_Default(t *testing.T) {
 	clearEnv()
 	setRequiredEnv()
@@ -626,6 +776,11 @@ func TestLoadConfig_NegativeValues_Rejected(t *testing.T) {
 		{"IGNORE_DURATION", "-5"},
 		{"VIOLATION_THRESHOLD", "0"},
 		{"VIOLATION_THRESHOLD_WINDOW", "0"},
+		{"VIOLATION_HISTORY_LIMIT", "-1"},
+		{"VIOLATION_HISTORY_DAYS", "0"},
+		{"HWID_TOLERANCE", "-1"},
+		{"USER_PREFETCH_INTERVAL", "0"},
+		{"USER_PREFETCH_PAGE_SIZE", "0"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.key, func(t *testing.T) {
@@ -756,3 +911,21 @@ func TestLoadConfig_RedisURL_Invalid(t *testing.T) {
 		t.Error("expected error for non-redis REDIS_URL, got nil")
 	}
 }
+
+func TestGroupingMode(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, "ip"},
+		{Config{SubnetGrouping: true}, "subnet"},
+		{Config{ASNGrouping: true, SubnetGrouping: true}, "asn"},
+		{Config{HybridGrouping: true, ASNGrouping: true, SubnetGrouping: true}, "hybrid"},
+		{Config{CountryGrouping: true, HybridGrouping: true}, "country"},
+	}
+	for _, c := range cases {
+		if got := c.cfg.GroupingMode(); got != c.want {
+			t.Errorf("GroupingMode(%+v) = %q, want %q", c.cfg, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EscalationWarn    = "warn"
+	EscalationDrop    = "drop"
+	EscalationDisable = "disable"
+)
+
+// EscalationStep — ступень лестницы наказаний. Duration == 0 у disable
+// означает перманентное отключение.
+type EscalationStep struct {
+	Action   string
+	Duration time.Duration
+}
+
+func (s EscalationStep) String() string {
+	if s.Action == EscalationDisable && s.Duration > 0 {
+		return s.Action + ":" + formatStepDuration(s.Duration)
+	}
+	return s.Action
+}
+
+func ParseEscalationLadder(s string) ([]EscalationStep, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []EscalationStep
+	for _, raw := range strings.Split(s, ",") {
+		entry := strings.ToLower(strings.TrimSpace(raw))
+		if entry == "" {
+			continue
+		}
+
+		action, param, hasParam := strings.Cut(entry, ":")
+		switch action {
+		case EscalationWarn, EscalationDrop:
+			if hasParam {
+				return nil, fmt.Errorf("%q: у %s не бывает длительности", entry, action)
+			}
+			out = append(out, EscalationStep{Action: action})
+		case EscalationDisable:
+			step := EscalationStep{Action: action}
+			if hasParam {
+				d, err := parseStepDuration(param)
+				if err != nil || d < time.Minute {
+					return nil, fmt.Errorf("%q: длительность должна быть не меньше 1m (например 30m, 24h, 7d)", entry)
+				}
+				step.Duration = d
+			}
+			out = append(out, step)
+		default:
+			return nil, fmt.Errorf("%q: ожидается warn, drop, disable или disable:<длительность>", entry)
+		}
+	}
+	return out, nil
+}
+
+// parseStepDuration понимает всё, что умеет time.ParseDuration, плюс дни ("7d").
+func parseStepDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func formatStepDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	case d%time.Hour == 0:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	default:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseEscalationLadder(t *testing.T) {
+	got, err := ParseEscalationLadder("warn, drop,disable:30m,DISABLE:24h,disable:7d,disable")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []EscalationStep{
+		{Action: EscalationWarn},
+		{Action: EscalationDrop},
+		{Action: EscalationDisable, Duration: 30 * time.Minute},
+		{Action: EscalationDisable, Duration: 24 * time.Hour},
+		{Action: EscalationDisable, Duration: 7 * 24 * time.Hour},
+		{Action: EscalationDisable},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEscalationLadder_Invalid(t *testing.T) {
+	cases := []string{
+		"ban",
+		"warn:5m",
+		"disable:abc",
+		"disable:30s",
+		"disable:xd",
+	}
+	for _, value := range cases {
+		t.Run(value, func(t *testing.T) {
+			if _, err := ParseEscalationLadder(value); err == nil {
+				t.Errorf("expected error for %q, got nil", value)
+			}
+		})
+	}
+}
+
+func TestEscalationStep_String(t *testing.T) {
+	cases := map[string]EscalationStep{
+		"warn":        {Action: EscalationWarn},
+		"disable":     {Action: EscalationDisable},
+		"disable:30m": {Action: EscalationDisable, Duration: 30 * time.Minute},
+		"disable:12h": {Action: EscalationDisable, Duration: 12 * time.Hour},
+		"disable:2d":  {Action: EscalationDisable, Duration: 48 * time.Hour},
+		"disable:90m": {Action: EscalationDisable, Duration: 90 * time.Minute},
+	}
+	for want, step := range cases {
+		if got := step.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLoadConfig_EscalationLadderRequiresHistory(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	os.Setenv("ESCALATION_LADDER", "warn,disable")
+	os.Setenv("VIOLATION_HISTORY_LIMIT", "0")
+	defer os.Unsetenv("ESCALATION_LADDER")
+	defer os.Unsetenv("VIOLATION_HISTORY_LIMIT")
+
+	if _, err := LoadConfig(""); err == nil {
+		t.Fatal("expected error when ladder is set and history is disabled")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	NodeByUUID    = "uuid"
+	NodeByCountry = "country"
+	NodeByName    = "name"
+)
+
+// NodeSelector выбирает ноду по UUID, коду страны из панели или имени
+// (glob: *, ?, [a-z]). Сравнение без учёта регистра.
+type NodeSelector struct {
+	Kind  string
+	Match string
+}
+
+func (s NodeSelector) String() string {
+	return s.Kind + ":" + s.Match
+}
+
+// Matches — подходит ли нода; Match при разборе приведён к нижнему регистру.
+func (s NodeSelector) Matches(uuid, name, country string) bool {
+	switch s.Kind {
+	case NodeByUUID:
+		return strings.EqualFold(uuid, s.Match)
+	case NodeByCountry:
+		return strings.EqualFold(country, s.Match)
+	case NodeByName:
+		ok, _ := path.Match(s.Match, strings.ToLower(name))
+		return ok
+	}
+	return false
+}
+
+// NodeInterval — свой интервал опроса (сек) для нод, подходящих под Selector.
+type NodeInterval struct {
+	Selector NodeSelector
+	Interval int
+}
+
+// ParseNodeSelector разбирает uuid:<uuid>, country:<код> или name:<glob>.
+// Значение без префикса — UUID, как в IGNORED_NODE_UUIDS.
+func ParseNodeSelector(raw string) (NodeSelector, error) {
+	raw = strings.TrimSpace(raw)
+	kind, match, ok := strings.Cut(raw, ":")
+	if !ok {
+		kind, match = NodeByUUID, raw
+	}
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	match = strings.ToLower(strings.TrimSpace(match))
+	if match == "" {
+		return NodeSelector{}, fmt.Errorf("селектор %q: пустое значение", raw)
+	}
+	switch kind {
+	case NodeByUUID, NodeByCountry:
+	case NodeByName:
+		if _, err := path.Match(match, ""); err != nil {
+			return NodeSelector{}, fmt.Errorf("селектор %q: неверный шаблон имени: %v", raw, err)
+		}
+	default:
+		return NodeSelector{}, fmt.Errorf("селектор %q: ожидается uuid:<uuid>, country:<код> или name:<шаблон>", raw)
+	}
+	return NodeSelector{Kind: kind, Match: match}, nil
+}
+
+// ParseNodeSelectors разбирает список селекторов через запятую.
+func ParseNodeSelectors(s string) ([]NodeSelector, error) {
+	var out []NodeSelector
+	for _, raw := range parseList(s) {
+		sel, err := ParseNodeSelector(raw)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sel)
+	}
+	return out, nil
+}
+
+// ParseNodeIntervals разбирает записи <селектор>=<сек> через ";".
+func ParseNodeIntervals(s string) ([]NodeInterval, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []NodeInterval
+	for _, raw := range strings.Split(s, ";") {
+		entry := strings.TrimSpace(raw)
+		if entry == "" {
+			continue
+		}
+		selector, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается вид country:<код>=<сек>, получено %q", entry)
+		}
+		sel, err := ParseNodeSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		interval, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%q: интервал должен быть целым числом > 0, получено %q", entry, value)
+		}
+		out = append(out, NodeInterval{Selector: sel, Interval: interval})
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseNodeSelectors(t *testing.T) {
+	got, err := ParseNodeSelectors("country:DE, name:EU-*, 0F1E-UUID")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []NodeSelector{
+		{Kind: NodeByCountry, Match: "de"},
+		{Kind: NodeByName, Match: "eu-*"},
+		{Kind: NodeByUUID, Match: "0f1e-uuid"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNodeSelector_Matches(t *testing.T) {
+	cases := []struct {
+		selector string
+		uuid     string
+		name     string
+		country  string
+		want     bool
+	}{
+		{"country:de", "u1", "Frankfurt", "DE", true},
+		{"country:de", "u1", "Amsterdam", "NL", false},
+		{"name:*-asia-*", "u1", "Node-Asia-Tokyo", "JP", true},
+		{"name:*-asia-*", "u1", "Node-EU-1", "DE", false},
+		{"uuid:ABC", "abc", "x", "", true},
+	}
+	for _, tc := range cases {
+		sel, err := ParseNodeSelector(tc.selector)
+		if err != nil {
+			t.Fatalf("ParseNodeSelector(%q): %v", tc.selector, err)
+		}
+		if got := sel.Matches(tc.uuid, tc.name, tc.country); got != tc.want {
+			t.Errorf("%s.Matches(%q, %q, %q) = %v, want %v", tc.selector, tc.uuid, tc.name, tc.country, got, tc.want)
+		}
+	}
+}
+
+func TestParseNodeIntervals(t *testing.T) {
+	got, err := ParseNodeIntervals("country:JP=600; name:*-asia-*=300")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].Selector.String() != "country:jp" || got[0].Interval != 600 || got[1].Interval != 300 {
+		t.Errorf("NodeIntervals = %+v", got)
+	}
+
+	for _, value := range []string{
+		"country:JP",
+		"country:JP=0",
+		"country:JP=abc",
+		"region:EU=60",
+		"name:[=60",
+		"country:=60",
+	} {
+		if _, err := ParseNodeIntervals(value); err == nil {
+			t.Errorf("expected error for %q, got nil", value)
+		}
+	}
+}
+
+func TestLoadConfig_NodeRules_Invalid(t *testing.T) {
+	for _, env := range []map[string]string{
+		{"NODE_INCLUDE": "region:EU"},
+		{"NODE_EXCLUDE": "name:["},
+		{"NODE_INTERVALS": "country:JP=-1"},
+		{"NODE_STALE_MAX_AGE": "-1"},
+	} {
+		clearEnv()
+		setRequiredEnv()
+		for k, v := range env {
+			os.Setenv(k, v)
+		}
+		if _, err := LoadConfig(""); err == nil {
+			t.Errorf("%v: expected validation error, got nil", env)
+		}
+	}
+	clearEnv()
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	PolicySquad = "squad"
+	PolicyTag   = "tag"
+)
+
+type LimitPolicy struct {
+	Kind       string
+	Match      string
+	Limit      int
+	Tolerance  int
+	ActionMode string
+}
+
+func (p LimitPolicy) Source() string {
+	return p.Kind + ":" + p.Match
+}
+
+func ParseLimitPolicies(s string) ([]LimitPolicy, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var out []LimitPolicy
+	for _, raw := range strings.Split(s, ";") {
+		entry := strings.TrimSpace(raw)
+		if entry == "" {
+			continue
+		}
+
+		selector, params, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается вид squad:<имя>=<лимит>[:допуск[:режим]], получено %q", entry)
+		}
+
+		kind, match, ok := strings.Cut(strings.TrimSpace(selector), ":")
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		match = strings.TrimSpace(match)
+		if !ok || match == "" || (kind != PolicySquad && kind != PolicyTag) {
+			return nil, fmt.Errorf("селектор %q: ожидается squad:<имя или UUID> или tag:<тег>", selector)
+		}
+
+		p := LimitPolicy{Kind: kind, Match: match, Tolerance: -1}
+
+		fields := strings.Split(params, ":")
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("%q: слишком много параметров, ожидается <лимит>[:допуск[:режим]]", entry)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("%q: лимит должен быть целым числом >= 0, получено %q", entry, fields[0])
+		}
+		p.Limit = limit
+
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			tol, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+			if err != nil || tol < 0 {
+				return nil, fmt.Errorf("%q: допуск должен быть целым числом >= 0, получено %q", entry, fields[1])
+			}
+			p.Tolerance = tol
+		}
+
+		if len(fields) > 2 {
+			mode := strings.ToLower(strings.TrimSpace(fields[2]))
+			if mode != "" && mode != "manual" && mode != "auto" {
+				return nil, fmt.Errorf("%q: режим должен быть manual или auto, получено %q", entry, fields[2])
+			}
+			p.ActionMode = mode
+		}
+
+		out = append(out, p)
+	}
+	return out, nil
+}
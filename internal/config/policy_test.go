@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseLimitPolicies(t *testing.T) {
+	got, err := ParseLimitPolicies("squad:Family=5:1; squad:Trial=1::auto ;tag:VIP=0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []LimitPolicy{
+		{Kind: PolicySquad, Match: "Family", Limit: 5, Tolerance: 1},
+		{Kind: PolicySquad, Match: "Trial", Limit: 1, Tolerance: -1, ActionMode: "auto"},
+		{Kind: PolicyTag, Match: "VIP", Limit: 0, Tolerance: -1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseLimitPolicies_Invalid(t *testing.T) {
+	cases := []string{
+		"squad:Family",
+		"group:Family=3",
+		"squad:=3",
+		"squad:Family=abc",
+		"squad:Family=-1",
+		"squad:Family=3:x",
+		"squad:Family=3:1:shadow",
+		"squad:Family=3:1:auto:extra",
+	}
+	for _, value := range cases {
+		t.Run(value, func(t *testing.T) {
+			if _, err := ParseLimitPolicies(value); err == nil {
+				t.Errorf("expected error for %q, got nil", value)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_LimitPolicies(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	os.Setenv("LIMIT_POLICIES", "tag:VIP=10")
+	defer os.Unsetenv("LIMIT_POLICIES")
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.LimitPolicies) != 1 || cfg.LimitPolicies[0].Source() != "tag:VIP" {
+		t.Errorf("LimitPolicies = %+v", cfg.LimitPolicies)
+	}
+}
+
+func TestLoadConfig_LimitPolicies_Invalid(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	os.Setenv("LIMIT_POLICIES", "squad:Family")
+	defer os.Unsetenv("LIMIT_POLICIES")
+
+	if _, err := LoadConfig(""); err == nil {
+		t.Error("expected error for invalid LIMIT_POLICIES, got nil")
+	}
+}
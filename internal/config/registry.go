@@ -33,13 +33,25 @@ var registry = []Field{
 	{Key: "USER_CACHE_TTL", TitleKey: "setting.USER_CACHE_TTL", Kind: KindInt},
 	{Key: "VIOLATION_THRESHOLD", TitleKey: "setting.VIOLATION_THRESHOLD", Kind: KindInt},
 	{Key: "VIOLATION_THRESHOLD_WINDOW", TitleKey: "setting.VIOLATION_THRESHOLD_WINDOW", Kind: KindInt},
+	{Key: "SCORING", TitleKey: "setting.SCORING", Kind: KindBool, Allowed: []string{"true", "false"}},
+	{Key: "SCORE_SOFT", TitleKey: "setting.SCORE_SOFT", Kind: KindInt},
+	{Key: "SCORE_ALERT", TitleKey: "setting.SCORE_ALERT", Kind: KindInt},
+	{Key: "SCORE_DISABLE", TitleKey: "setting.SCORE_DISABLE", Kind: KindInt},
 	{Key: "AUTO_DISABLE_DURATION", TitleKey: "setting.AUTO_DISABLE_DURATION", Kind: KindInt},
 	{Key: "IGNORE_DURATION", TitleKey: "setting.IGNORE_DURATION", Kind: KindInt},
 	{Key: "AUTO_NOTIFY_SOFT", TitleKey: "setting.AUTO_NOTIFY_SOFT", Kind: KindBool, Allowed: []string{"true", "false"}},
 	{Key: "SUBNET_GROUPING", TitleKey: "setting.SUBNET_GROUPING", Kind: KindBool, Allowed: []string{"true", "false"}},
 	{Key: "SUBNET_PREFIX_V4", TitleKey: "setting.SUBNET_PREFIX_V4", Kind: KindInt},
+	{Key: "SUBNET_PREFIX_V6", TitleKey: "setting.SUBNET_PREFIX_V6", Kind: KindInt},
 	{Key: "ASN_GROUPING", TitleKey: "setting.ASN_GROUPING", Kind: KindBool, Allowed: []string{"true", "false"}},
+	{Key: "COUNTRY_GROUPING", TitleKey: "setting.COUNTRY_GROUPING", Kind: KindBool, Allowed: []string{"true", "false"}},
+	{Key: "HYBRID_GROUPING", TitleKey: "setting.HYBRID_GROUPING", Kind: KindBool, Allowed: []string{"true", "false"}},
+	{Key: "COUNTING_MODE", TitleKey: "setting.COUNTING_MODE", Kind: KindEnum, Allowed: CountingModes},
+	{Key: "CONCURRENT_MIN_CHECKS", TitleKey: "setting.CONCURRENT_MIN_CHECKS", Kind: KindInt},
+	{Key: "HWID_CHECK", TitleKey: "setting.HWID_CHECK", Kind: KindBool, Allowed: []string{"true", "false"}},
+	{Key: "HWID_TOLERANCE", TitleKey: "setting.HWID_TOLERANCE", Kind: KindInt},
 	{Key: "DAILY_REPORT", TitleKey: "setting.DAILY_REPORT", Kind: KindBool, Allowed: []string{"true", "false"}},
+	{Key: "USER_NOTIFY", TitleKey: "setting.USER_NOTIFY", Kind: KindBool, Allowed: []string{"true", "false"}},
 	{Key: "LOG_LEVEL", TitleKey: "setting.LOG_LEVEL", Kind: KindEnum, Allowed: LogLevels},
 }
 
@@ -111,6 +123,14 @@ func Display(cfg *Config, key string) string {
 		return strconv.Itoa(cfg.ViolationThreshold)
 	case "VIOLATION_THRESHOLD_WINDOW":
 		return strconv.Itoa(cfg.ViolationThresholdWindow)
+	case "SCORING":
+		return strconv.FormatBool(cfg.Scoring)
+	case "SCORE_SOFT":
+		return strconv.Itoa(cfg.ScoreSoft)
+	case "SCORE_ALERT":
+		return strconv.Itoa(cfg.ScoreAlert)
+	case "SCORE_DISABLE":
+		return strconv.Itoa(cfg.ScoreDisable)
 	case "AUTO_DISABLE_DURATION":
 		return strconv.Itoa(cfg.AutoDisableDuration)
 	case "IGNORE_DURATION":
@@ -121,10 +141,26 @@ func Display(cfg *Config, key string) string {
 		return strconv.FormatBool(cfg.SubnetGrouping)
 	case "SUBNET_PREFIX_V4":
 		return strconv.Itoa(cfg.SubnetPrefixV4)
+	case "SUBNET_PREFIX_V6":
+		return strconv.Itoa(cfg.SubnetPrefixV6)
 	case "ASN_GROUPING":
 		return strconv.FormatBool(cfg.ASNGrouping)
+	case "COUNTRY_GROUPING":
+		return strconv.FormatBool(cfg.CountryGrouping)
+	case "HYBRID_GROUPING":
+		return strconv.FormatBool(cfg.HybridGrouping)
+	case "COUNTING_MODE":
+		return cfg.CountingMode
+	case "CONCURRENT_MIN_CHECKS":
+		return strconv.Itoa(cfg.ConcurrentMinChecks)
+	case "HWID_CHECK":
+		return strconv.FormatBool(cfg.HWIDCheck)
+	case "HWID_TOLERANCE":
+		return strconv.Itoa(cfg.HWIDTolerance)
 	case "DAILY_REPORT":
 		return strconv.FormatBool(cfg.DailyReport)
+	case "USER_NOTIFY":
+		return strconv.FormatBool(cfg.UserNotify)
 	case "LOG_LEVEL":
 		return cfg.LogLevel
 	}
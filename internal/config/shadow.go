@@ -0,0 +1,59 @@
+package config
+
+import "fmt"
+
+const shadowPrefix = "SHADOW_"
+
+// ShadowKeys — параметры, которые можно переопределить для shadow-режима через
+// SHADOW_<KEY>. Только то, что влияет на решение «нарушение или нет» и на
+// действие; порог, cooldown и прочие таймеры shadow не моделирует.
+var ShadowKeys = []string{
+	"ACTION_MODE",
+	"DEFAULT_DEVICE_LIMIT",
+	"LIMIT_POLICIES",
+	"TOLERANCE",
+	"TOLERANCE_MULTIPLIER",
+	"SUBNET_GROUPING",
+	"SUBNET_PREFIX_V4",
+	"SUBNET_PREFIX_V6",
+	"ASN_GROUPING",
+	"COUNTRY_GROUPING",
+	"HYBRID_GROUPING",
+	"COUNTING_MODE",
+	"CONCURRENT_MIN_CHECKS",
+}
+
+func (l *loader) shadowOverrides() map[string]string {
+	var out map[string]string
+	for _, key := range ShadowKeys {
+		if v := l.lookup(shadowPrefix + key); v != "" {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// loadShadow собирает кандидатный конфиг: те же значения (включая сохранённые
+// из бота), поверх которых наложены SHADOW_*.
+func loadShadow(envPath string, base, shadow map[string]string) (*Config, error) {
+	merged := make(map[string]string, len(base)+len(shadow)+len(ShadowKeys))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range shadow {
+		merged[k] = v
+	}
+	// Пустые SHADOW_* в кандидате, чтобы он сам не строил shadow.
+	for _, key := range ShadowKeys {
+		merged[shadowPrefix+key] = ""
+	}
+
+	cfg, err := LoadConfigWithOverrides(envPath, merged)
+	if err != nil {
+		return nil, fmt.Errorf("shadow-конфиг (SHADOW_*): %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadConfig_ShadowDisabledByDefault(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Shadow != nil || cfg.ShadowOverrides != nil {
+		t.Errorf("shadow must be off without SHADOW_*, got %+v", cfg.ShadowOverrides)
+	}
+}
+
+func TestLoadConfig_ShadowOverridesOnTopOfLive(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	os.Setenv("SHADOW_ACTION_MODE", "auto")
+	os.Setenv("SHADOW_SUBNET_GROUPING", "true")
+	defer clearEnv()
+
+	// TOLERANCE приходит как сохранённая из бота настройка — shadow должен её унаследовать
+	cfg, err := LoadConfigWithOverrides("", map[string]string{"TOLERANCE": "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ActionMode != "manual" || cfg.SubnetGrouping {
+		t.Errorf("live config must not change: mode=%s subnet=%v", cfg.ActionMode, cfg.SubnetGrouping)
+	}
+	if cfg.Shadow == nil {
+		t.Fatal("expected shadow config")
+	}
+	if cfg.Shadow.ActionMode != "auto" || !cfg.Shadow.SubnetGrouping || cfg.Shadow.Tolerance != 2 {
+		t.Errorf("shadow = mode=%s subnet=%v tolerance=%d", cfg.Shadow.ActionMode, cfg.Shadow.SubnetGrouping, cfg.Shadow.Tolerance)
+	}
+	if cfg.Shadow.Shadow != nil {
+		t.Error("shadow config must not build its own shadow")
+	}
+}
+
+func TestLoadConfig_InvalidShadowRejected(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	os.Setenv("SHADOW_ACTION_MODE", "ban")
+	defer clearEnv()
+
+	if _, err := LoadConfig(""); err == nil {
+		t.Fatal("expected error for invalid SHADOW_ACTION_MODE")
+	}
+}
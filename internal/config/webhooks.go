@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	EventViolationDetected     = "violation_detected"
+	EventSoftViolationDetected = "soft_violation_detected"
+	EventUserDisabled          = "user_disabled"
+	EventUserEnabled           = "user_enabled"
+	EventUserRestoredByTimer   = "user_restored_by_timer"
+	EventRestoreFailed         = "restore_failed"
+	EventConnectionsDropped    = "connections_dropped"
+	EventUserWhitelisted       = "user_whitelisted"
+	EventConfigChanged         = "config_changed"
+)
+
+// Форматы тела webhook (WEBHOOK_FORMAT, WEBHOOK_<N>_FORMAT).
+const (
+	WebhookFormatJSON        = "json"
+	WebhookFormatCloudEvents = "cloudevents"
+	WebhookFormatSlack       = "slack"
+	WebhookFormatDiscord     = "discord"
+)
+
+var WebhookFormats = []string{
+	WebhookFormatJSON,
+	WebhookFormatCloudEvents,
+	WebhookFormatSlack,
+	WebhookFormatDiscord,
+}
+
+// WebhookEvents — события, на которые можно подписать endpoint через
+// WEBHOOK_<N>_EVENTS.
+var WebhookEvents = []string{
+	EventViolationDetected,
+	EventSoftViolationDetected,
+	EventUserDisabled,
+	EventUserEnabled,
+	EventUserRestoredByTimer,
+	EventRestoreFailed,
+	EventConnectionsDropped,
+	EventUserWhitelisted,
+	EventConfigChanged,
+}
+
+// WebhookEndpoint — получатель webhook. Пустой Events означает «все события»,
+// пустой фильтр пользователей — «все пользователи».
+type WebhookEndpoint struct {
+	Name    string
+	URL     string
+	Secret  string
+	Format  string
+	Events  []string
+	UserIDs []int64
+	Squads  []string
+	Tags    []string
+}
+
+func (e WebhookEndpoint) WantsEvent(event string) bool {
+	return len(e.Events) == 0 || contains(e.Events, event)
+}
+
+// WantsUser проверяет фильтр WEBHOOK_<N>_USERS: подходит, если совпал хотя бы
+// один ID, сквод (по имени или UUID) или тег.
+func (e WebhookEndpoint) WantsUser(userID int64, tag string, squads []string) bool {
+	if len(e.UserIDs) == 0 && len(e.Squads) == 0 && len(e.Tags) == 0 {
+		return true
+	}
+	for _, id := range e.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	for _, want := range e.Tags {
+		if tag != "" && strings.EqualFold(tag, want) {
+			return true
+		}
+	}
+	for _, want := range e.Squads {
+		for _, sq := range squads {
+			if strings.EqualFold(sq, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseWebhookUsers разбирает список вида "123,456,squad:VIP,tag:TRIAL".
+func parseWebhookUsers(e *WebhookEndpoint, s string) error {
+	for _, raw := range strings.Split(s, ",") {
+		entry := strings.TrimSpace(raw)
+		if entry == "" {
+			continue
+		}
+		if kind, match, ok := strings.Cut(entry, ":"); ok {
+			match = strings.TrimSpace(match)
+			switch strings.ToLower(strings.TrimSpace(kind)) {
+			case PolicySquad:
+				if match != "" {
+					e.Squads = append(e.Squads, match)
+					continue
+				}
+			case PolicyTag:
+				if match != "" {
+					e.Tags = append(e.Tags, match)
+					continue
+				}
+			}
+			return fmt.Errorf("ожидается ID пользователя, squad:<имя или UUID> или tag:<тег>, получено %q", entry)
+		}
+		id, err := strconv.ParseInt(entry, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается ID пользователя, squad:<имя или UUID> или tag:<тег>, получено %q", entry)
+		}
+		e.UserIDs = append(e.UserIDs, id)
+	}
+	return nil
+}
+
+// getEnvWebhooks собирает endpoint'ы: WEBHOOK_URL (имя default, все события)
+// и WEBHOOK_1_URL, WEBHOOK_2_URL, … до первого пропуска.
+func (l *loader) getEnvWebhooks() []WebhookEndpoint {
+	var out []WebhookEndpoint
+	if u := strings.TrimSpace(l.lookup("WEBHOOK_URL")); u != "" {
+		out = append(out, WebhookEndpoint{
+			Name:   "default",
+			URL:    u,
+			Secret: l.lookup("WEBHOOK_SECRET"),
+			Format: strings.ToLower(l.getEnv("WEBHOOK_FORMAT", WebhookFormatJSON)),
+		})
+	}
+
+	for i := 1; ; i++ {
+		prefix := "WEBHOOK_" + strconv.Itoa(i) + "_"
+		u := strings.TrimSpace(l.lookup(prefix + "URL"))
+		if u == "" {
+			break
+		}
+		e := WebhookEndpoint{
+			Name:   l.getEnv(prefix+"NAME", "webhook-"+strconv.Itoa(i)),
+			URL:    u,
+			Secret: l.lookup(prefix + "SECRET"),
+			Format: strings.ToLower(l.getEnv(prefix+"FORMAT", WebhookFormatJSON)),
+			Events: parseLowercaseList(l.lookup(prefix + "EVENTS")),
+		}
+		if err := parseWebhookUsers(&e, l.lookup(prefix+"USERS")); err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%sUSERS: %v", prefix, err))
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func validateWebhooks(endpoints []WebhookEndpoint) error {
+	names := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		if names[e.Name] {
+			return fmt.Errorf("webhook %q: имя endpoint'а повторяется", e.Name)
+		}
+		names[e.Name] = true
+		if err := validateBaseURL("webhook "+e.Name, e.URL); err != nil {
+			return err
+		}
+		if !contains(WebhookFormats, e.Format) {
+			return fmt.Errorf("webhook %q: неизвестный формат %q, доступны %v", e.Name, e.Format, WebhookFormats)
+		}
+		for _, ev := range e.Events {
+			if !contains(WebhookEvents, ev) {
+				return fmt.Errorf("webhook %q: неизвестное событие %q, доступны %v", e.Name, ev, WebhookEvents)
+			}
+		}
+	}
+	return nil
+}
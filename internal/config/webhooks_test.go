@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadConfig_Webhooks_LegacyAndIndexed(t *testing.T) {
+	clearEnv()
+	setRequiredEnv()
+	os.Setenv("WEBHOOK_URL", "https://example.com/hook")
+	os.Setenv("WEBHOOK_SECRET", "legacy")
+	os.Setenv("WEBHOOK_1_URL", "https://crm.example.com/limiter")
+	os.Setenv("WEBHOOK_1_NAME", "crm")
+	os.Setenv("WEBHOOK_1_SECRET", "crm-secret")
+	os.Setenv("WEBHOOK_1_EVENTS", "Violation_Detected")
+	os.Setenv("WEBHOOK_1_USERS", "42, squad:VIP ,tag:TRIAL")
+	os.Setenv("WEBHOOK_2_URL", "https://second.example.com")
+	os.Setenv("WEBHOOK_2_FORMAT", "Discord")
+	// WEBHOOK_4 не читается: нумерация прерывается на пропущенном WEBHOOK_3
+	os.Setenv("WEBHOOK_4_URL", "https://skipped.example.com")
+	defer clearEnv()
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Webhooks) != 3 {
+		t.Fatalf("expected 3 endpoints, got %+v", cfg.Webhooks)
+	}
+
+	legacy := cfg.Webhooks[0]
+	if legacy.Name != "default" || legacy.Secret != "legacy" || len(legacy.Events) != 0 {
+		t.Errorf("legacy endpoint = %+v", legacy)
+	}
+
+	crm := cfg.Webhooks[1]
+	if crm.Name != "crm" || crm.Secret != "crm-secret" {
+		t.Errorf("crm endpoint = %+v", crm)
+	}
+	if len(crm.Events) != 1 || crm.Events[0] != EventViolationDetected {
+		t.Errorf("crm events = %v", crm.Events)
+	}
+	if len(crm.UserIDs) != 1 || crm.UserIDs[0] != 42 || crm.Squads[0] != "VIP" || crm.Tags[0] != "TRIAL" {
+		t.Errorf("crm users = %v %v %v", crm.UserIDs, crm.Squads, crm.Tags)
+	}
+
+	if cfg.Webhooks[2].Name != "webhook-2" || cfg.Webhooks[2].Format != WebhookFormatDiscord {
+		t.Errorf("expected webhook-2 in discord format, got %+v", cfg.Webhooks[2])
+	}
+	if crm.Format != WebhookFormatJSON {
+		t.Errorf("expected json format by default, got %q", crm.Format)
+	}
+}
+
+func TestLoadConfig_Webhooks_Invalid(t *testing.T) {
+	cases := map[string]map[string]string{
+		"unknown event": {"WEBHOOK_1_URL": "https://a.example.com", "WEBHOOK_1_EVENTS": "user_deleted"},
+		"bad users":     {"WEBHOOK_1_URL": "https://a.example.com", "WEBHOOK_1_USERS": "alice"},
+		"empty squad":   {"WEBHOOK_1_URL": "https://a.example.com", "WEBHOOK_1_USERS": "squad:"},
+		"bad url":       {"WEBHOOK_1_URL": "not-a-url"},
+		"bad format":    {"WEBHOOK_1_URL": "https://a.example.com", "WEBHOOK_1_FORMAT": "teams"},
+		"duplicate name": {
+			"WEBHOOK_1_URL": "https://a.example.com", "WEBHOOK_1_NAME": "x",
+			"WEBHOOK_2_URL": "https://b.example.com", "WEBHOOK_2_NAME": "x",
+		},
+	}
+	for name, env := range cases {
+		t.Run(name, func(t *testing.T) {
+			clearEnv()
+			setRequiredEnv()
+			for k, v := range env {
+				os.Setenv(k, v)
+			}
+			defer clearEnv()
+
+			if _, err := LoadConfig(""); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestWebhookEndpoint_Filters(t *testing.T) {
+	all := WebhookEndpoint{}
+	if !all.WantsEvent(EventSoftViolationDetected) || !all.WantsUser(1, "", nil) {
+		t.Error("endpoint without filters must accept everything")
+	}
+
+	e := WebhookEndpoint{
+		Events:  []string{EventViolationDetected},
+		UserIDs: []int64{7},
+		Squads:  []string{"vip"},
+		Tags:    []string{"trial"},
+	}
+	if e.WantsEvent(EventSoftViolationDetected) {
+		t.Error("soft event must be filtered out")
+	}
+
+	tests := []struct {
+		name   string
+		userID int64
+		tag    string
+		squads []string
+		want   bool
+	}{
+		{"by id", 7, "", nil, true},
+		{"by tag case-insensitive", 1, "TRIAL", nil, true},
+		{"by squad name", 1, "", []string{"uuid-1", "VIP"}, true},
+		{"no match", 1, "PAID", []string{"uuid-2", "Basic"}, false},
+	}
+	for _, tt := range tests {
+		if got := e.WantsUser(tt.userID, tt.tag, tt.squads); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
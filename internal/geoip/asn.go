@@ -11,6 +11,8 @@ import (
 type ASNInfo struct {
 	Number uint32
 	Org    string
+	// Class — класс ASN по спискам ASN_* (см. Classifier); пусто — не задан.
+	Class string
 }
 
 type Resolver interface {
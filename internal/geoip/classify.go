@@ -0,0 +1,29 @@
+package geoip
+
+// Классы ASN: мобильные операторы (CGNAT, IP постоянно меняются),
+// домашние провайдеры и хостинги/дата-центры.
+const (
+	ClassMobile      = "mobile"
+	ClassResidential = "residential"
+	ClassHosting     = "hosting"
+)
+
+// Classifier — Resolver поверх другого Resolver, дописывающий класс ASN из
+// заданных списков.
+type Classifier struct {
+	next    Resolver
+	classes map[uint32]string
+}
+
+func NewClassifier(next Resolver, classes map[uint32]string) *Classifier {
+	return &Classifier{next: next, classes: classes}
+}
+
+func (c *Classifier) Lookup(ipStr string) (ASNInfo, bool) {
+	info, ok := c.next.Lookup(ipStr)
+	if !ok {
+		return info, false
+	}
+	info.Class = c.classes[info.Number]
+	return info, true
+}
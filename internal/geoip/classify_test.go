@@ -0,0 +1,31 @@
+package geoip
+
+import "testing"
+
+type stubResolver map[string]ASNInfo
+
+func (s stubResolver) Lookup(ip string) (ASNInfo, bool) {
+	info, ok := s[ip]
+	return info, ok
+}
+
+func TestClassifier_Lookup(t *testing.T) {
+	c := NewClassifier(stubResolver{
+		"1.1.1.1": {Number: 12389, Org: "Rostelecom"},
+		"2.2.2.2": {Number: 24940, Org: "Hetzner"},
+		"3.3.3.3": {Number: 3320, Org: "DTAG"},
+	}, map[uint32]string{12389: ClassMobile, 24940: ClassHosting})
+
+	cases := map[string]string{"1.1.1.1": ClassMobile, "2.2.2.2": ClassHosting, "3.3.3.3": ""}
+	for ip, want := range cases {
+		info, ok := c.Lookup(ip)
+		if !ok || info.Class != want {
+			t.Errorf("Lookup(%s) = %+v, %v; want class %q", ip, info, ok, want)
+		}
+	}
+
+	// промах нижнего резолвера не превращается в попадание
+	if _, ok := c.Lookup("9.9.9.9"); ok {
+		t.Error("Lookup(9.9.9.9): ok = true, want false")
+	}
+}
@@ -19,6 +19,9 @@ import (
 const (
 	defaultBaseURL = "https://download.maxmind.com/app/geoip_download"
 
+	// DefaultEdition — база, которую скачивает Downloader без явного Edition.
+	DefaultEdition = "GeoLite2-ASN"
+
 	maxDBSize = 512 << 20
 )
 
@@ -33,6 +36,7 @@ type Downloader struct {
 	HTTPClient *http.Client
 	LicenseKey string
 	BaseURL    string
+	Edition    string
 	Validate   func(path string) error
 	Timeout    time.Duration
 }
@@ -69,8 +73,14 @@ func (d *Downloader) Download(ctx context.Context, dstPath string) error {
 		httpClient = &http.Client{Timeout: timeout}
 	}
 
+	edition := d.Edition
+	if edition == "" {
+		edition = DefaultEdition
+	}
+	dbName := edition + ".mmdb"
+
 	params := url.Values{}
-	params.Set("edition_id", "GeoLite2-ASN")
+	params.Set("edition_id", edition)
 	params.Set("license_key", d.LicenseKey)
 	params.Set("suffix", "tar.gz")
 	reqURL := base + "?" + params.Encode()
@@ -95,7 +105,7 @@ func (d *Downloader) Download(ctx context.Context, dstPath string) error {
 	}
 
 	dstDir := filepath.Dir(dstPath)
-	tmpFile, err := os.CreateTemp(dstDir, "GeoLite2-ASN.*.mmdb.tmp")
+	tmpFile, err := os.CreateTemp(dstDir, edition+".*.mmdb.tmp")
 	if err != nil {
 		return fmt.Errorf("downloader: create tmp file: %w", err)
 	}
@@ -129,7 +139,7 @@ func (d *Downloader) Download(ctx context.Context, dstPath string) error {
 		if hdr.Typeflag != tar.TypeReg {
 			continue
 		}
-		if !strings.HasSuffix(hdr.Name, "/GeoLite2-ASN.mmdb") && !strings.HasSuffix(hdr.Name, "GeoLite2-ASN.mmdb") {
+		if !strings.HasSuffix(hdr.Name, "/"+dbName) && !strings.HasSuffix(hdr.Name, dbName) {
 			continue
 		}
 		written, err := io.Copy(tmpFile, io.LimitReader(tr, maxDBSize+1))
@@ -144,7 +154,7 @@ func (d *Downloader) Download(ctx context.Context, dstPath string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("downloader: GeoLite2-ASN.mmdb not found in archive")
+		return fmt.Errorf("downloader: %s not found in archive", dbName)
 	}
 
 	if err := tmpFile.Close(); err != nil {
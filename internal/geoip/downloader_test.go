@@ -207,3 +207,32 @@ func TestDownloader_Download_ContextCancel(t *testing.T) {
 		t.Fatal("expected error on cancelled context, got nil")
 	}
 }
+
+// Edition задаёт и edition_id запроса, и имя файла, который ищется в архиве:
+// архив ASN для GeoLite2-City не подходит.
+func TestDownloader_Download_Edition(t *testing.T) {
+	archive := buildFakeArchive(t, []byte("asn"), true)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("edition_id"); got != "GeoLite2-City" {
+			t.Errorf("edition_id = %q, want GeoLite2-City", got)
+		}
+		w.Write(archive)
+	}))
+	defer ts.Close()
+
+	dst := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+	d := &Downloader{
+		HTTPClient: ts.Client(),
+		LicenseKey: "abc",
+		BaseURL:    ts.URL,
+		Edition:    "GeoLite2-City",
+		Validate:   func(string) error { return nil },
+		Timeout:    5 * time.Second,
+	}
+
+	err := d.Download(context.Background(), dst)
+	if err == nil || !strings.Contains(err.Error(), "GeoLite2-City.mmdb not found") {
+		t.Fatalf("err = %v, want GeoLite2-City.mmdb not found", err)
+	}
+}
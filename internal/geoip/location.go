@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location — страна IP (ISO 3166-1 alpha-2) и, для базы GeoLite2-City,
+// координаты. У базы GeoLite2-Country координат нет: HasCoords == false.
+type Location struct {
+	Country   string
+	Latitude  float64
+	Longitude float64
+	HasCoords bool
+}
+
+type LocationResolver interface {
+	Locate(ipStr string) (Location, bool)
+}
+
+type NopLocationResolver struct{}
+
+func (NopLocationResolver) Locate(string) (Location, bool) { return Location{}, false }
+
+// CityResolver читает базы GeoLite2-City и GeoLite2-Country — у обеих
+// одинаковая запись country, City дополнительно содержит location.
+type CityResolver struct {
+	reader atomic.Pointer[maxminddb.Reader]
+}
+
+func NewCityResolver(path string) (*CityResolver, error) {
+	if path == "" {
+		return nil, fmt.Errorf("geoip: database path is empty")
+	}
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: open %s: %w", path, err)
+	}
+	c := &CityResolver{}
+	c.reader.Store(r)
+	return c, nil
+}
+
+func (c *CityResolver) Reload(path string) error {
+	if c == nil {
+		return fmt.Errorf("geoip: Reload called on nil CityResolver")
+	}
+	if path == "" {
+		return fmt.Errorf("geoip: Reload path is empty")
+	}
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return fmt.Errorf("geoip: reload open %s: %w", path, err)
+	}
+	old := c.reader.Swap(r)
+	if old != nil {
+		_ = old.Close()
+	}
+	return nil
+}
+
+func (c *CityResolver) Close() error {
+	if c == nil {
+		return nil
+	}
+	old := c.reader.Swap(nil)
+	if old == nil {
+		return nil
+	}
+	return old.Close()
+}
+
+func (c *CityResolver) Locate(ipStr string) (Location, bool) {
+	if c == nil {
+		return Location{}, false
+	}
+	r := c.reader.Load()
+	if r == nil {
+		return Location{}, false
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return Location{}, false
+	}
+	var rec struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		Location struct {
+			Latitude  *float64 `maxminddb:"latitude"`
+			Longitude *float64 `maxminddb:"longitude"`
+		} `maxminddb:"location"`
+	}
+	if err := r.Lookup(ip, &rec); err != nil {
+		return Location{}, false
+	}
+	if rec.Country.ISOCode == "" {
+		return Location{}, false
+	}
+	loc := Location{Country: rec.Country.ISOCode}
+	if rec.Location.Latitude != nil && rec.Location.Longitude != nil {
+		loc.Latitude = *rec.Location.Latitude
+		loc.Longitude = *rec.Location.Longitude
+		loc.HasCoords = true
+	}
+	return loc, true
+}
@@ -0,0 +1,29 @@
+package geoip
+
+import "testing"
+
+func TestNopLocationResolver_AlwaysReturnsMiss(t *testing.T) {
+	if loc, ok := (NopLocationResolver{}).Locate("8.8.8.8"); ok || loc.Country != "" {
+		t.Errorf("Locate = %+v, %v; want miss", loc, ok)
+	}
+}
+
+func TestNewCityResolver_Errors(t *testing.T) {
+	if _, err := NewCityResolver(""); err == nil {
+		t.Error("expected error for empty path, got nil")
+	}
+	if _, err := NewCityResolver("/nonexistent/path/GeoLite2-City.mmdb"); err == nil {
+		t.Error("expected error for nonexistent path, got nil")
+	}
+}
+
+// Без открытой базы Locate не паникует и возвращает промах.
+func TestCityResolver_LocateWithoutReader(t *testing.T) {
+	var nilResolver *CityResolver
+	if _, ok := nilResolver.Locate("8.8.8.8"); ok {
+		t.Error("nil resolver: ok = true, want false")
+	}
+	if _, ok := (&CityResolver{}).Locate("8.8.8.8"); ok {
+		t.Error("empty resolver: ok = true, want false")
+	}
+}
@@ -4,23 +4,50 @@ var current = "ru"
 
 var translations = map[string]map[string]string{
 	"ru": {
-		"alert.manual.title":   "⚠️ <b>Превышение лимита устройств</b>",
-		"alert.auto.title":     "🔒 <b>Подписка автоматически отключена</b>",
-		"alert.soft.title":     "🔔 <b>Превышение лимита в пределах допуска</b>",
-		"alert.soft.note":      "ℹ️ Бан не применён — превышение в пределах допуска",
-		"alert.ban_threshold":  "🚫 Порог бана",
-		"alert.user":           "👤 Пользователь",
-		"alert.limit":          "📊 Лимит",
-		"alert.detected_ips":   "Обнаружено",
-		"alert.asn_count":      "ASN",
-		"alert.asn_groups":     "ASN-групп",
-		"alert.subnets":        "Подсетей",
-		"alert.violations_24h": "📈 Нарушений за 24ч",
-		"alert.disabled_for":   "⏱ Отключена на",
-		"alert.permanent":      "Перманентно",
-		"alert.ips_header":     "📍 IP-адреса",
-		"alert.and_more":       "и ещё",
-		"alert.profile":        "🔗 Профиль",
+		"alert.manual.title":          "⚠️ <b>Превышение лимита устройств</b>",
+		"alert.auto.title":            "🔒 <b>Подписка автоматически отключена</b>",
+		"alert.soft.title":            "🔔 <b>Превышение лимита в пределах допуска</b>",
+		"alert.soft.note":             "ℹ️ Бан не применён — превышение в пределах допуска",
+		"alert.ban_threshold":         "🚫 Порог бана",
+		"alert.score":                 "🎯 Оценка",
+		"score.groups":                "устройства",
+		"score.persistence":           "длительность",
+		"score.nodes":                 "ноды",
+		"score.countries":             "страны",
+		"score.asn":                   "ASN",
+		"score.history":               "история",
+		"alert.user":                  "👤 Пользователь",
+		"alert.limit":                 "📊 Лимит",
+		"alert.detected_ips":          "Обнаружено",
+		"alert.asn_count":             "ASN",
+		"alert.asn_groups":            "ASN-групп",
+		"alert.subnets":               "Подсетей",
+		"alert.countries":             "Стран",
+		"alert.hybrid_groups":         "Групп (ASN/подсеть)",
+		"alert.device_groups":         "Устройств",
+		"alert.violations_24h":        "📈 Нарушений за 24ч",
+		"alert.disabled_for":          "⏱ Отключена на",
+		"alert.permanent":             "Перманентно",
+		"alert.escalation.warn_title": "⚠️ <b>Превышение лимита — предупреждение</b>",
+		"alert.escalation.drop_title": "✂️ <b>Превышение лимита — подключения сброшены</b>",
+		"alert.escalation.step":       "🪜 Ступень эскалации",
+		"alert.travel.title":          "🌍 <b>Невозможное перемещение</b>",
+		"alert.travel.route":          "🧭 Страны",
+		"alert.travel.note":           "ℹ️ IP пользователя одновременно активны в далёких друг от друга странах",
+		"alert.reseller.title":        "🏢 <b>Возможный VPN-реселлер</b>",
+		"alert.reseller.hosting":      "🖥 IP из хостинг-ASN",
+		"alert.reseller.note":         "ℹ️ Подписка одновременно используется с нескольких серверов дата-центров",
+		"alert.hwid.title":            "📲 <b>IP-групп больше, чем HWID-устройств</b>",
+		"alert.hwid.groups":           "🌐 Групп IP / устройств в панели",
+		"alert.hwid.devices":          "Зарегистрированные устройства",
+		"alert.hwid.note":             "ℹ️ Подписка активна из большего числа мест, чем устройств зарегистрировано по HWID",
+		"escalation.warn":             "предупреждение",
+		"escalation.drop":             "сброс подключений",
+		"escalation.disable":          "отключение",
+		"alert.ips_header":            "📍 IP-адреса",
+		"alert.groups_header":         "🧩 Группы устройств",
+		"alert.and_more":              "и ещё",
+		"alert.profile":               "🔗 Профиль",
 
 		"action.drop":         "✅ Подключения сброшены",
 		"action.disable":      "🔒 Подписка отключена навсегда",
@@ -45,17 +72,48 @@ var translations = map[string]map[string]string{
 
 		"command.settings":      "⚙️ Настройки лимитера",
 		"command.stats":         "📊 Статистика нарушений",
+		"command.user":          "👤 Статус пользователя: /user <id|username>",
 		"startup.settings_hint": "⚙️ Изменить параметры на лету: /settings",
 		"settings.open":         "⚙️ Открыть настройки",
 
-		"stats.title":        "📊 <b>Статистика нарушений</b>",
-		"stats.daily_title":  "📊 <b>Ежедневный отчёт о нарушениях</b>",
-		"stats.last_24h":     "📈 За 24 часа",
-		"stats.last_week":    "📅 За неделю",
-		"stats.top_header":   "🏆 Топ нарушителей за неделю",
-		"stats.empty":        "✅ Нарушений за неделю не зафиксировано",
-		"stats.count_suffix": "наруш.",
-		"stats.error":        "❌ Не удалось получить статистику",
+		"stats.title":           "📊 <b>Статистика нарушений</b>",
+		"stats.daily_title":     "📊 <b>Ежедневный отчёт о нарушениях</b>",
+		"stats.last_24h":        "📈 За 24 часа",
+		"stats.last_week":       "📅 За неделю",
+		"stats.top_header":      "🏆 Топ нарушителей за неделю",
+		"stats.empty":           "✅ Нарушений за неделю не зафиксировано",
+		"stats.count_suffix":    "наруш.",
+		"stats.error":           "❌ Не удалось получить статистику",
+		"shadow.title":          "🧪 <b>Shadow-режим: расхождения</b>",
+		"shadow.config":         "Кандидатный конфиг",
+		"shadow.shadow_only":    "🆕 Отмечены только кандидатом",
+		"shadow.live_only":      "➖ Отмечены только текущим конфигом",
+		"shadow.shadow_disable": "🔒 Были бы отключены кандидатом",
+		"shadow.no_diff":        "✅ Решения совпадают с текущим конфигом",
+
+		"user.title":             "👤 <b>Пользователь</b>",
+		"user.status":            "Статус",
+		"user.limit_source":      "источник",
+		"user.active_ips":        "📍 Активные IP",
+		"user.snapshot_at":       "проверка",
+		"user.no_ips":            "нет активных подключений",
+		"user.no_snapshot":       "ещё не было ни одной проверки",
+		"user.whitelist":         "🔇 Whitelist",
+		"user.cooldown":          "⏳ Cooldown",
+		"user.restore_at":        "⏱ Включение по таймеру",
+		"user.score":             "🎯 Последняя оценка",
+		"user.none":              "нет",
+		"user.forever":           "навсегда",
+		"user.until":             "до",
+		"user.history":           "🗂 История",
+		"user.history_violation": "нарушение",
+		"user.history_travel":    "перемещение",
+		"user.history_reseller":  "VPN-реселлер",
+		"user.history_hwid":      "IP-групп больше HWID",
+		"user.devices":           "устр.",
+		"user.usage":             "Использование: /user &lt;id|username&gt;",
+		"user.not_found":         "❌ Пользователь не найден",
+		"user.error":             "❌ Не удалось получить данные пользователя",
 
 		"settings.title":              "⚙️ <b>Настройки лимитера</b>\n\n♻️ — параметр изменён относительно .env",
 		"settings.reset_all":          "♻️ Сбросить всё к .env",
@@ -80,18 +138,38 @@ var translations = map[string]map[string]string{
 		"setting.USER_CACHE_TTL":             "TTL кэша юзеров (с)",
 		"setting.VIOLATION_THRESHOLD":        "Порог нарушений",
 		"setting.VIOLATION_THRESHOLD_WINDOW": "Окно порога (с)",
+		"setting.SCORING":                    "Оценка уверенности",
+		"setting.SCORE_SOFT":                 "Оценка: предупреждение",
+		"setting.SCORE_ALERT":                "Оценка: алерт",
+		"setting.SCORE_DISABLE":              "Оценка: отключение",
 		"setting.AUTO_DISABLE_DURATION":      "Авто-отключение (мин)",
 		"setting.IGNORE_DURATION":            "Длит. игнора (мин)",
 		"setting.AUTO_NOTIFY_SOFT":           "Уведомл. в пределах допуска",
 		"setting.SUBNET_GROUPING":            "Группировка подсетей",
 		"setting.SUBNET_PREFIX_V4":           "Префикс подсети IPv4",
+		"setting.SUBNET_PREFIX_V6":           "Префикс подсети IPv6",
 		"setting.ASN_GROUPING":               "Группировка по ASN",
+		"setting.COUNTRY_GROUPING":           "Группировка по странам",
+		"setting.HYBRID_GROUPING":            "Гибридная группировка (ASN + подсети)",
+		"setting.COUNTING_MODE":              "Режим подсчёта IP (window/concurrent)",
+		"setting.CONCURRENT_MIN_CHECKS":      "Проверок подряд для одновременного IP",
+		"setting.HWID_CHECK":                 "Сверка с HWID-устройствами панели",
+		"setting.HWID_TOLERANCE":             "Допуск IP-групп сверх HWID-устройств",
 		"setting.DAILY_REPORT":               "Ежедневный отчёт",
+		"setting.USER_NOTIFY":                "Уведомления пользователям",
 		"setting.LOG_LEVEL":                  "Уровень логирования",
 
 		"restore.message": "🔓 Подписка <code>%d</code> автоматически включена по таймеру",
 		"restore.failed":  "⚠️ Не удалось включить подписку <code>%d</code> по таймеру — включите её вручную в панели",
 
+		"node.down.title":       "🔴 <b>Нода не отвечает</b>",
+		"node.down.node":        "🖥 Нода",
+		"node.down.failures":    "❌ Неудачных опросов подряд",
+		"node.down.last_ok":     "✅ Последний успешный опрос",
+		"node.down.stale_until": "♻️ До %s в проверку идёт прошлый результат ноды",
+		"node.down.no_data":     "⚠️ Прошлого результата нет или он старше NODE_STALE_MAX_AGE — подключения через эту ноду не проверяются",
+		"node.recovered":        "🟢 Нода <b>%s</b> снова отвечает (неудачных опросов подряд: %d)",
+
 		"duration.forever": "навсегда",
 		"duration.min":     "мин",
 		"duration.hour":    "ч",
@@ -121,31 +199,89 @@ var translations = map[string]map[string]string{
 		"startup.disabled":            "выключен",
 		"startup.subnet_grouping":     "Группировка подсетей",
 		"startup.asn_grouping":        "Группировка по ASN",
+		"startup.hybrid_grouping":     "Гибридная группировка",
+		"startup.country_grouping":    "Группировка по странам",
 		"startup.maxmind":             "MaxMind DB",
 		"startup.maxmind_loaded":      "загружена",
 		"startup.maxmind_unavailable": "недоступна",
 		"startup.violation_threshold": "Порог нарушений",
 		"startup.threshold_window":    "Окно порога",
 		"startup.sec":                 "с",
+
+		"webhook.event.violation_detected":      "⚠️ Превышение лимита устройств",
+		"webhook.event.soft_violation_detected": "🔔 Превышение лимита в пределах допуска",
+		"webhook.event.user_disabled":           "🔒 Пользователь отключён",
+		"webhook.event.user_enabled":            "🔓 Пользователь включён",
+		"webhook.event.user_restored_by_timer":  "🔓 Пользователь включён по таймеру",
+		"webhook.event.restore_failed":          "❌ Не удалось включить пользователя по таймеру",
+		"webhook.event.connections_dropped":     "✂️ Подключения сброшены",
+		"webhook.event.user_whitelisted":        "🔇 Пользователь добавлен в whitelist",
+		"webhook.event.config_changed":          "⚙️ Настройка изменена",
+		"webhook.field.user":                    "Пользователь",
+		"webhook.field.devices":                 "Устройств / лимит",
+		"webhook.field.effective_limit":         "порог",
+		"webhook.field.violations_24h":          "Нарушений за 24ч",
+		"webhook.field.score":                   "Оценка",
+		"webhook.field.ips":                     "IP",
+		"webhook.field.groups":                  "Группы устройств",
+		"webhook.field.duration":                "Срок",
+		"webhook.field.actor":                   "Инициатор",
+		"webhook.field.reason":                  "Причина",
+		"webhook.field.setting":                 "Параметр",
+		"webhook.field.attempts":                "Попыток",
+		"webhook.field.error":                   "Ошибка",
+		"webhook.field.travel":                  "Страны",
+		"webhook.field.hosting_ips":             "IP из хостинг-ASN",
+		"webhook.field.hwid_devices":            "Групп IP / HWID-устройств",
+		"webhook.event.vpn_reseller":            "🏢 Возможный VPN-реселлер",
+		"webhook.event.hwid_mismatch":           "📲 IP-групп больше, чем HWID-устройств",
+		"webhook.event.impossible_travel":       "🌍 Невозможное перемещение",
 	},
 	"en": {
-		"alert.manual.title":   "⚠️ <b>Device limit exceeded</b>",
-		"alert.auto.title":     "🔒 <b>Subscription automatically disabled</b>",
-		"alert.soft.title":     "🔔 <b>Limit exceeded within tolerance</b>",
-		"alert.soft.note":      "ℹ️ No ban applied — exceedance is within tolerance",
-		"alert.ban_threshold":  "🚫 Ban threshold",
-		"alert.user":           "👤 User",
-		"alert.limit":          "📊 Limit",
-		"alert.detected_ips":   "Detected",
-		"alert.asn_count":      "ASN",
-		"alert.asn_groups":     "ASN groups",
-		"alert.subnets":        "Subnets",
-		"alert.violations_24h": "📈 Violations in 24h",
-		"alert.disabled_for":   "⏱ Disabled for",
-		"alert.permanent":      "Permanently",
-		"alert.ips_header":     "📍 IP addresses",
-		"alert.and_more":       "and more",
-		"alert.profile":        "🔗 Profile",
+		"alert.manual.title":          "⚠️ <b>Device limit exceeded</b>",
+		"alert.auto.title":            "🔒 <b>Subscription automatically disabled</b>",
+		"alert.soft.title":            "🔔 <b>Limit exceeded within tolerance</b>",
+		"alert.soft.note":             "ℹ️ No ban applied — exceedance is within tolerance",
+		"alert.ban_threshold":         "🚫 Ban threshold",
+		"alert.score":                 "🎯 Score",
+		"score.groups":                "devices",
+		"score.persistence":           "duration",
+		"score.nodes":                 "nodes",
+		"score.countries":             "countries",
+		"score.asn":                   "ASN",
+		"score.history":               "history",
+		"alert.user":                  "👤 User",
+		"alert.limit":                 "📊 Limit",
+		"alert.detected_ips":          "Detected",
+		"alert.asn_count":             "ASN",
+		"alert.asn_groups":            "ASN groups",
+		"alert.subnets":               "Subnets",
+		"alert.countries":             "Countries",
+		"alert.hybrid_groups":         "Groups (ASN/subnet)",
+		"alert.device_groups":         "Devices",
+		"alert.violations_24h":        "📈 Violations in 24h",
+		"alert.disabled_for":          "⏱ Disabled for",
+		"alert.permanent":             "Permanently",
+		"alert.escalation.warn_title": "⚠️ <b>Device limit exceeded — warning</b>",
+		"alert.escalation.drop_title": "✂️ <b>Device limit exceeded — connections dropped</b>",
+		"alert.escalation.step":       "🪜 Escalation step",
+		"alert.travel.title":          "🌍 <b>Impossible travel</b>",
+		"alert.travel.route":          "🧭 Countries",
+		"alert.travel.note":           "ℹ️ The user's IPs are active at the same time in countries far apart",
+		"alert.reseller.title":        "🏢 <b>Possible VPN reseller</b>",
+		"alert.reseller.hosting":      "🖥 IPs from hosting ASNs",
+		"alert.reseller.note":         "ℹ️ The subscription is used from several data-center servers at the same time",
+		"alert.hwid.title":            "📲 <b>More IP groups than HWID devices</b>",
+		"alert.hwid.groups":           "🌐 IP groups / panel devices",
+		"alert.hwid.devices":          "Registered devices",
+		"alert.hwid.note":             "ℹ️ The subscription is active from more places than devices registered by HWID",
+		"escalation.warn":             "warning",
+		"escalation.drop":             "connections dropped",
+		"escalation.disable":          "disable",
+		"alert.ips_header":            "📍 IP addresses",
+		"alert.groups_header":         "🧩 Device groups",
+		"alert.and_more":              "and more",
+		"alert.profile":               "🔗 Profile",
 
 		"action.drop":         "✅ Connections dropped",
 		"action.disable":      "🔒 Subscription disabled permanently",
@@ -170,17 +306,48 @@ var translations = map[string]map[string]string{
 
 		"command.settings":      "⚙️ Limiter settings",
 		"command.stats":         "📊 Violation statistics",
+		"command.user":          "👤 User status: /user <id|username>",
 		"startup.settings_hint": "⚙️ Change parameters on the fly: /settings",
 		"settings.open":         "⚙️ Open settings",
 
-		"stats.title":        "📊 <b>Violation statistics</b>",
-		"stats.daily_title":  "📊 <b>Daily violation report</b>",
-		"stats.last_24h":     "📈 Last 24 hours",
-		"stats.last_week":    "📅 Last week",
-		"stats.top_header":   "🏆 Top violators this week",
-		"stats.empty":        "✅ No violations recorded this week",
-		"stats.count_suffix": "viol.",
-		"stats.error":        "❌ Failed to fetch statistics",
+		"stats.title":           "📊 <b>Violation statistics</b>",
+		"stats.daily_title":     "📊 <b>Daily violation report</b>",
+		"stats.last_24h":        "📈 Last 24 hours",
+		"stats.last_week":       "📅 Last week",
+		"stats.top_header":      "🏆 Top violators this week",
+		"stats.empty":           "✅ No violations recorded this week",
+		"stats.count_suffix":    "viol.",
+		"stats.error":           "❌ Failed to fetch statistics",
+		"shadow.title":          "🧪 <b>Shadow mode: differences</b>",
+		"shadow.config":         "Candidate config",
+		"shadow.shadow_only":    "🆕 Flagged only by the candidate",
+		"shadow.live_only":      "➖ Flagged only by the live config",
+		"shadow.shadow_disable": "🔒 Would be disabled by the candidate",
+		"shadow.no_diff":        "✅ Decisions match the live config",
+
+		"user.title":             "👤 <b>User</b>",
+		"user.status":            "Status",
+		"user.limit_source":      "source",
+		"user.active_ips":        "📍 Active IPs",
+		"user.snapshot_at":       "check",
+		"user.no_ips":            "no active connections",
+		"user.no_snapshot":       "no check has run yet",
+		"user.whitelist":         "🔇 Whitelist",
+		"user.cooldown":          "⏳ Cooldown",
+		"user.restore_at":        "⏱ Timed re-enable",
+		"user.score":             "🎯 Last score",
+		"user.none":              "no",
+		"user.forever":           "permanent",
+		"user.until":             "until",
+		"user.history":           "🗂 History",
+		"user.history_violation": "violation",
+		"user.history_travel":    "travel",
+		"user.history_reseller":  "VPN reseller",
+		"user.history_hwid":      "IP groups above HWID",
+		"user.devices":           "dev.",
+		"user.usage":             "Usage: /user &lt;id|username&gt;",
+		"user.not_found":         "❌ User not found",
+		"user.error":             "❌ Failed to fetch user data",
 
 		"settings.title":              "⚙️ <b>Limiter settings</b>\n\n♻️ — value changed from .env",
 		"settings.reset_all":          "♻️ Reset all to .env",
@@ -205,18 +372,38 @@ var translations = map[string]map[string]string{
 		"setting.USER_CACHE_TTL":             "User cache TTL (s)",
 		"setting.VIOLATION_THRESHOLD":        "Violation threshold",
 		"setting.VIOLATION_THRESHOLD_WINDOW": "Threshold window (s)",
+		"setting.SCORING":                    "Confidence scoring",
+		"setting.SCORE_SOFT":                 "Score: warning",
+		"setting.SCORE_ALERT":                "Score: alert",
+		"setting.SCORE_DISABLE":              "Score: disable",
 		"setting.AUTO_DISABLE_DURATION":      "Auto-disable (min)",
 		"setting.IGNORE_DURATION":            "Ignore duration (min)",
 		"setting.AUTO_NOTIFY_SOFT":           "Within-tolerance alerts",
 		"setting.SUBNET_GROUPING":            "Subnet grouping",
 		"setting.SUBNET_PREFIX_V4":           "IPv4 subnet prefix",
+		"setting.SUBNET_PREFIX_V6":           "IPv6 subnet prefix",
 		"setting.ASN_GROUPING":               "ASN grouping",
+		"setting.COUNTRY_GROUPING":           "Country grouping",
+		"setting.HYBRID_GROUPING":            "Hybrid grouping (ASN + subnets)",
+		"setting.COUNTING_MODE":              "IP counting mode (window/concurrent)",
+		"setting.CONCURRENT_MIN_CHECKS":      "Consecutive checks for a concurrent IP",
+		"setting.HWID_CHECK":                 "Cross-check with panel HWID devices",
+		"setting.HWID_TOLERANCE":             "IP groups allowed above HWID devices",
 		"setting.DAILY_REPORT":               "Daily report",
+		"setting.USER_NOTIFY":                "User notifications",
 		"setting.LOG_LEVEL":                  "Log level",
 
 		"restore.message": "🔓 Subscription <code>%d</code> automatically enabled by timer",
 		"restore.failed":  "⚠️ Failed to enable subscription <code>%d</code> by timer — enable it manually in the panel",
 
+		"node.down.title":       "🔴 <b>Node is not responding</b>",
+		"node.down.node":        "🖥 Node",
+		"node.down.failures":    "❌ Failed polls in a row",
+		"node.down.last_ok":     "✅ Last successful poll",
+		"node.down.stale_until": "♻️ The node's previous result is used in checks until %s",
+		"node.down.no_data":     "⚠️ No previous result or it is older than NODE_STALE_MAX_AGE — connections via this node are not checked",
+		"node.recovered":        "🟢 Node <b>%s</b> is responding again (failed polls in a row: %d)",
+
 		"duration.forever": "forever",
 		"duration.min":     "min",
 		"duration.hour":    "h",
@@ -246,12 +433,43 @@ var translations = map[string]map[string]string{
 		"startup.disabled":            "disabled",
 		"startup.subnet_grouping":     "Subnet grouping",
 		"startup.asn_grouping":        "ASN grouping",
+		"startup.hybrid_grouping":     "Hybrid grouping",
+		"startup.country_grouping":    "Country grouping",
 		"startup.maxmind":             "MaxMind DB",
 		"startup.maxmind_loaded":      "loaded",
 		"startup.maxmind_unavailable": "unavailable",
 		"startup.violation_threshold": "Violation threshold",
 		"startup.threshold_window":    "Threshold window",
 		"startup.sec":                 "s",
+
+		"webhook.event.violation_detected":      "⚠️ Device limit exceeded",
+		"webhook.event.soft_violation_detected": "🔔 Limit exceeded within tolerance",
+		"webhook.event.user_disabled":           "🔒 User disabled",
+		"webhook.event.user_enabled":            "🔓 User enabled",
+		"webhook.event.user_restored_by_timer":  "🔓 User enabled by timer",
+		"webhook.event.restore_failed":          "❌ Failed to enable user by timer",
+		"webhook.event.connections_dropped":     "✂️ Connections dropped",
+		"webhook.event.user_whitelisted":        "🔇 User whitelisted",
+		"webhook.event.config_changed":          "⚙️ Setting changed",
+		"webhook.field.user":                    "User",
+		"webhook.field.devices":                 "Devices / limit",
+		"webhook.field.effective_limit":         "threshold",
+		"webhook.field.violations_24h":          "Violations in 24h",
+		"webhook.field.score":                   "Score",
+		"webhook.field.ips":                     "IP",
+		"webhook.field.groups":                  "Device groups",
+		"webhook.field.duration":                "Duration",
+		"webhook.field.actor":                   "Initiator",
+		"webhook.field.reason":                  "Reason",
+		"webhook.field.setting":                 "Setting",
+		"webhook.field.attempts":                "Attempts",
+		"webhook.field.error":                   "Error",
+		"webhook.field.travel":                  "Countries",
+		"webhook.field.hosting_ips":             "IPs from hosting ASNs",
+		"webhook.field.hwid_devices":            "IP groups / HWID devices",
+		"webhook.event.vpn_reseller":            "🏢 Possible VPN reseller",
+		"webhook.event.hwid_mismatch":           "📲 More IP groups than HWID devices",
+		"webhook.event.impossible_travel":       "🌍 Impossible travel",
 	},
 }
 
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/cache"
+)
+
+// HandleAction выполняет действие над пользователем — то же, что кнопки
+// в Telegram-алерте. Каждый вызов пишется в лог аудита с инициатором из ctx.
+func (m *Monitor) HandleAction(ctx context.Context, action string, userID int64) error {
+	err := m.runAction(ctx, action, userID)
+
+	entry := m.logger.WithFields(audit.Fields(ctx)).WithFields(logrus.Fields{
+		"action": action,
+		"userID": userID,
+	})
+	if err != nil {
+		entry.WithError(err).Warn("Аудит: действие не выполнено")
+		return err
+	}
+	entry.Info("Аудит: действие выполнено")
+	m.sendActionLifecycle(ctx, action, userID)
+
+	actor := audit.ActorFrom(ctx)
+	name := actor.Name
+	if name == "" {
+		name = actor.Source
+	}
+	m.recordHistory(ctx, userID, &cache.HistoryEntry{
+		At:     time.Now(),
+		Kind:   cache.HistoryAction,
+		Action: action,
+		Actor:  name,
+		Reason: audit.ReasonFrom(ctx),
+	})
+	return nil
+}
+
+func (m *Monitor) runAction(ctx context.Context, action string, userID int64) error {
+	cfg := m.cfg.Load()
+	switch action {
+	case "drop":
+		return m.api.DropConnections(ctx, []int64{userID})
+	case "disable":
+		return m.api.DisableUser(ctx, userID)
+	case "disable_temp":
+		if err := m.api.DisableUser(ctx, userID); err != nil {
+			return err
+		}
+		if cfg.AutoDisableDuration > 0 {
+			duration := time.Duration(cfg.AutoDisableDuration) * time.Minute
+			if err := m.cache.SetRestoreTimer(ctx, userID, duration); err != nil {
+				m.logger.WithError(err).WithField("userID", userID).Error("Ошибка установки таймера восстановления (manual disable_temp)")
+				return err
+			}
+		}
+		return nil
+	case "enable":
+		return m.api.EnableUser(ctx, userID)
+	case "ignore":
+		return m.cache.AddToWhitelist(ctx, userID)
+	case "ignore_temp":
+		ttl := time.Duration(cfg.IgnoreDuration) * time.Minute
+		return m.cache.AddToWhitelistTemp(ctx, userID, ttl)
+	}
+	return audit.ErrUnknownAction
+}
@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+func TestHandleAction_CallsPanel(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"response":{}}`))
+	}))
+	defer srv.Close()
+
+	m := &Monitor{
+		api:    api.NewClient(srv.URL, "token"),
+		cfg:    config.NewProvider(&config.Config{}),
+		logger: quietLogger(),
+	}
+
+	if err := m.HandleAction(context.Background(), "enable", 42); err != nil {
+		t.Fatalf("HandleAction error: %v", err)
+	}
+	if gotPath != "/api/users/42/actions/enable" {
+		t.Errorf("path = %q", gotPath)
+	}
+}
+
+func TestHandleAction_UnknownAction(t *testing.T) {
+	m := &Monitor{cfg: config.NewProvider(&config.Config{}), logger: quietLogger()}
+
+	err := m.HandleAction(context.Background(), "explode", 42)
+	if !errors.Is(err, audit.ErrUnknownAction) {
+		t.Errorf("err = %v, want ErrUnknownAction", err)
+	}
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+// alertAllowed проверяет и ставит cooldown алерта вида kind.
+func (m *Monitor) alertAllowed(ctx context.Context, kind string, userID int64) bool {
+	active, err := m.cache.IsAlertCooldownActive(ctx, kind, userID)
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Errorf("Ошибка проверки cooldown алерта %s", kind)
+		return false
+	}
+	if active {
+		return false
+	}
+	ttl := time.Duration(m.cfg.Load().Cooldown) * time.Second
+	if err := m.cache.SetAlertCooldown(ctx, kind, userID, ttl); err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Errorf("Ошибка установки cooldown алерта %s", kind)
+	}
+	return true
+}
+
+// raiseAlert отправляет webhook, пишет историю и присылает алерт с кнопками.
+func (m *Monitor) raiseAlert(ctx context.Context, d *detection, reason, text string, fill func(*webhook.ViolationPayload)) {
+	cfg := m.cfg.Load()
+	userID := d.user.UserID
+
+	if m.webhook != nil {
+		v := &webhook.ViolationPayload{
+			Reason:           reason,
+			IPs:              ipPayloads(d.ips),
+			IPCount:          len(d.ips),
+			DeviceLimit:      d.limit.limit,
+			LimitSource:      d.limit.source,
+			Tolerance:        d.limit.tolerance,
+			EffectiveLimit:   d.limit.banThreshold(),
+			DeviceGroupCount: d.deviceCount,
+			GroupingMode:     cfg.GroupingMode(),
+		}
+		fill(v)
+		m.dispatchWebhook(ctx, &webhook.Payload{
+			Event:      config.EventViolationDetected,
+			ActionMode: d.limit.actionMode,
+			User:       userPayload(d.user),
+			Violation:  v,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	m.recordHistory(ctx, userID, &cache.HistoryEntry{
+		At:          time.Now(),
+		Kind:        cache.HistoryAlert,
+		Action:      historyActionAlert,
+		Reason:      reason,
+		Username:    d.user.Username,
+		DeviceCount: d.deviceCount,
+		HWIDDevices: len(d.hwid),
+		IPs:         historyIPs(d.ips),
+	})
+
+	if err := m.bot.SendManualAlert(ctx, text, userID, cfg.AutoDisableDuration, cfg.IgnoreDuration); err != nil {
+		m.logger.WithError(err).WithFields(logrus.Fields{"userID": userID, "reason": reason}).Error("Ошибка отправки алерта")
+	}
+}
@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/telegram"
+)
+
+const (
+	historyActionWarned     = "warned"
+	historyActionDropped    = "dropped"
+	historyActionDropFailed = "drop_failed"
+)
+
+// escalation — ступень лестницы, выбранная по числу прошлых нарушений
+// пользователя за ESCALATION_WINDOW_DAYS.
+type escalation struct {
+	config.EscalationStep
+	step  int
+	total int
+	prior int
+}
+
+func (e *escalation) durationMin() int {
+	return int(e.Duration / time.Minute)
+}
+
+func (e *escalation) info() telegram.EscalationInfo {
+	return telegram.EscalationInfo{
+		Step:        e.step,
+		Total:       e.total,
+		Action:      e.Action,
+		DurationMin: e.durationMin(),
+	}
+}
+
+// pickEscalation выбирает ступень по числу прошлых нарушений; после последней
+// ступени все следующие нарушения получают её же.
+func pickEscalation(ladder []config.EscalationStep, prior int) *escalation {
+	idx := min(prior, len(ladder)-1)
+	return &escalation{
+		EscalationStep: ladder[idx],
+		step:           idx + 1,
+		total:          len(ladder),
+		prior:          prior,
+	}
+}
+
+// countViolations считает только превышения лимита устройств.
+func countViolations(history []cache.HistoryEntry) int {
+	n := 0
+	for _, e := range history {
+		if e.Kind == cache.HistoryViolation {
+			n++
+		}
+	}
+	return n
+}
+
+// escalationFor возвращает nil, если лестница не настроена. При ошибке чтения
+// истории считаем нарушение первым — лучше недонаказать, чем забанить навсегда.
+func (m *Monitor) escalationFor(ctx context.Context, userID int64) *escalation {
+	cfg := m.cfg.Load()
+	if len(cfg.EscalationLadder) == 0 {
+		return nil
+	}
+
+	since := time.Now().AddDate(0, 0, -cfg.EscalationWindowDays)
+	history, err := m.cache.GetHistory(ctx, userID, since)
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка чтения истории для эскалации, применяю первую ступень")
+	}
+	return pickEscalation(cfg.EscalationLadder, countViolations(history))
+}
+
+func (m *Monitor) handleEscalation(ctx context.Context, d *detection, violationCount int64) (action string, durationMin int) {
+	cfg := m.cfg.Load()
+	user := d.user
+	esc := d.escalation
+
+	fields := logrus.Fields{
+		"userID":     user.UserID,
+		"username":   user.Username,
+		"escalation": esc.String(),
+		"step":       esc.step,
+		"prior":      esc.prior,
+	}
+
+	switch esc.Action {
+	case config.EscalationWarn:
+		action = historyActionWarned
+	case config.EscalationDrop:
+		if err := m.api.DropConnections(ctx, []int64{user.UserID}); err != nil {
+			m.logger.WithError(err).WithFields(fields).Error("Ошибка сброса подключений")
+			return historyActionDropFailed, 0
+		}
+		action = historyActionDropped
+		m.sendLifecycle(ctx, config.EventConnectionsDropped, user.UserID, user, reasonDeviceLimit, nil)
+	default:
+		if err := m.api.DisableUser(ctx, user.UserID); err != nil {
+			m.logger.WithError(err).WithFields(fields).Error("Ошибка отключения пользователя")
+			return historyActionDisableFailed, 0
+		}
+		action, durationMin = historyActionDisabled, esc.durationMin()
+		m.setRestoreTimer(ctx, user.UserID, durationMin)
+		m.sendLifecycle(ctx, config.EventUserDisabled, user.UserID, user, reasonDeviceLimit, durationDetails(durationMin))
+	}
+	m.logger.WithFields(fields).Info("Применена ступень эскалации")
+
+	text := telegram.FormatEscalationAlert(user, d.ips, d.limit.limit, esc.info(), violationCount, m.location, d.grouping(cfg), d.score)
+	var err error
+	if esc.Action == config.EscalationDisable {
+		err = m.bot.SendAutoAlert(ctx, text, user.UserID)
+	} else {
+		err = m.bot.SendManualAlert(ctx, text, user.UserID, cfg.AutoDisableDuration, cfg.IgnoreDuration)
+	}
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", user.UserID).Error("Ошибка отправки auto alert")
+	}
+	return action, durationMin
+}
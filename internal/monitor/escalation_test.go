@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+func TestPickEscalation(t *testing.T) {
+	ladder := []config.EscalationStep{
+		{Action: config.EscalationWarn},
+		{Action: config.EscalationDrop},
+		{Action: config.EscalationDisable, Duration: 30 * time.Minute},
+		{Action: config.EscalationDisable},
+	}
+
+	cases := []struct {
+		prior       int
+		wantStep    int
+		wantAction  string
+		wantMinutes int
+	}{
+		{0, 1, config.EscalationWarn, 0},
+		{1, 2, config.EscalationDrop, 0},
+		{2, 3, config.EscalationDisable, 30},
+		{3, 4, config.EscalationDisable, 0},
+		// после последней ступени остаёмся на ней
+		{10, 4, config.EscalationDisable, 0},
+	}
+	for _, c := range cases {
+		esc := pickEscalation(ladder, c.prior)
+		if esc.step != c.wantStep || esc.Action != c.wantAction || esc.durationMin() != c.wantMinutes {
+			t.Errorf("prior=%d: got step=%d action=%s min=%d, want step=%d action=%s min=%d",
+				c.prior, esc.step, esc.Action, esc.durationMin(), c.wantStep, c.wantAction, c.wantMinutes)
+		}
+		if esc.total != len(ladder) || esc.prior != c.prior {
+			t.Errorf("prior=%d: total=%d prior=%d", c.prior, esc.total, esc.prior)
+		}
+	}
+}
+
+func TestCountViolations_SkipsAdminActions(t *testing.T) {
+	history := []cache.HistoryEntry{
+		{Kind: cache.HistoryViolation},
+		{Kind: cache.HistoryAction, Action: "enable"},
+		{Kind: cache.HistoryViolation},
+	}
+	if got := countViolations(history); got != 2 {
+		t.Errorf("countViolations = %d, want 2", got)
+	}
+	if got := countViolations(nil); got != 0 {
+		t.Errorf("countViolations(nil) = %d, want 0", got)
+	}
+}
+
+func TestCountViolations_AlertsDoNotEscalate(t *testing.T) {
+	ladder, err := config.ParseEscalationLadder("warn,drop,disable:30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := []cache.HistoryEntry{
+		{Kind: cache.HistoryViolation},
+		{Kind: cache.HistoryAlert, Action: "alert", Reason: cache.ReasonImpossibleTravel},
+		{Kind: cache.HistoryAlert, Action: "alert", Reason: cache.ReasonHWIDMismatch},
+	}
+	if got := countViolations(history); got != 1 {
+		t.Errorf("countViolations = %d, want 1", got)
+	}
+	// Алерты не сдвигают ступень: одно прошлое нарушение — вторая ступень.
+	if esc := pickEscalation(ladder, countViolations(history)); esc.step != 2 || esc.Action != config.EscalationDrop {
+		t.Errorf("step = %d action = %s, want 2 drop", esc.step, esc.Action)
+	}
+}
@@ -57,8 +57,8 @@ func TestFetchNodes_PartialFailure(t *testing.T) {
 		{UUID: "good-2", Name: "Good 2"},
 	})
 
-	if failed != 1 {
-		t.Errorf("failed = %d, want 1", failed)
+	if len(failed) != 1 || failed[0].UUID != "bad-1" {
+		t.Errorf("failed = %+v, want [bad-1]", failed)
 	}
 	if len(results) != 2 {
 		t.Fatalf("len(results) = %d, want 2", len(results))
@@ -82,10 +82,10 @@ func TestFetchNodes_AllFailed(t *testing.T) {
 	nodes := []api.Node{{UUID: "bad-1"}, {UUID: "bad-2"}}
 	results, failed := m.fetchNodes(context.Background(), nodes)
 
-	// check() опирается на failed == len(nodes), чтобы не отмечать
+	// check() опирается на len(failed) == len(nodes), чтобы не отмечать
 	// проверку успешной и не врать в /healthz.
-	if failed != len(nodes) {
-		t.Errorf("failed = %d, want %d", failed, len(nodes))
+	if len(failed) != len(nodes) {
+		t.Errorf("failed = %d, want %d", len(failed), len(nodes))
 	}
 	if len(results) != 0 {
 		t.Errorf("len(results) = %d, want 0", len(results))
@@ -103,7 +103,7 @@ func TestFetchNodes_CancelledContext(t *testing.T) {
 	cancel()
 
 	results, failed := m.fetchNodes(ctx, []api.Node{{UUID: "good-1"}})
-	if len(results) != 0 || failed != 1 {
-		t.Errorf("results = %d, failed = %d; want 0 and 1", len(results), failed)
+	if len(results) != 0 || len(failed) != 1 {
+		t.Errorf("results = %d, failed = %d; want 0 and 1", len(results), len(failed))
 	}
 }
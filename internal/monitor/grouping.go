@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/telegram"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+// deviceGroup — одно «устройство» и IP, которые в него попали. key — результат
+// deviceKey: asn:<n>, subnet:<cidr>, country:<cc>, mobile:<asn> или ip:<ip>.
+type deviceGroup struct {
+	key string
+	ips []string
+}
+
+// grouping — режим и разбивка для алертов в Telegram.
+func (d *detection) grouping(cfg *config.Config) telegram.Grouping {
+	g := telegram.Grouping{Mode: cfg.GroupingMode(), Count: d.deviceCount, ModeCount: d.modeGroups}
+	for _, dg := range d.groups {
+		g.Groups = append(g.Groups, telegram.DeviceGroup{Key: dg.key, IPs: dg.ips})
+	}
+	return g
+}
+
+func groupPayloads(groups []deviceGroup) []webhook.GroupPayload {
+	out := make([]webhook.GroupPayload, len(groups))
+	for i, g := range groups {
+		out[i] = webhook.GroupPayload{Key: g.key, IPs: g.ips}
+	}
+	return out
+}
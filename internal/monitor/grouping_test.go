@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/geoip"
+)
+
+// В режиме стран семья с домашним и мобильным провайдером в одной стране —
+// одно «устройство»; IP без страны считаются по отдельности.
+func TestNewDetection_CountryGrouping(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, HWIDDeviceLimit: 1}
+	lim := limitDecision{limit: 1, actionMode: "manual"}
+	cfg := &config.Config{CountryGrouping: true, ASNGrouping: true}
+
+	family := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "10.0.0.1", ASN: 100, Country: "DE"},
+		{IP: "10.0.1.1", ASN: 200, Country: "DE"},
+	}, lim)
+	if family.deviceCount != 1 || family.countryGroups != 1 || family.exceeded() {
+		t.Errorf("одна страна: devices=%d exceeded=%v, want 1 false", family.deviceCount, family.exceeded())
+	}
+
+	shared := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "10.0.0.1", Country: "DE"},
+		{IP: "10.0.1.1", Country: "BR"},
+		{IP: "10.0.2.1"},
+	}, lim)
+	if shared.deviceCount != 3 || !shared.exceeded() {
+		t.Errorf("две страны + неизвестная: devices=%d exceeded=%v, want 3 true", shared.deviceCount, shared.exceeded())
+	}
+}
+
+// Классы ASN не дробят страну: домашний и мобильный провайдер в одной стране —
+// одно устройство, хостинг-IP этой страны тоже в её группе.
+func TestNewDetection_CountryGroupingIgnoresASNClasses(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, HWIDDeviceLimit: 1}
+	lim := limitDecision{limit: 1, actionMode: "manual"}
+	cfg := &config.Config{CountryGrouping: true, SubnetPrefixV4: 24, SubnetPrefixV6: 64}
+
+	d := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "10.0.0.1", ASN: 100, Country: "DE", ASNClass: geoip.ClassResidential},
+		{IP: "10.8.0.1", ASN: 200, Country: "DE", ASNClass: geoip.ClassMobile},
+		{IP: "192.0.2.1", ASN: 300, Country: "DE", ASNClass: geoip.ClassHosting},
+		{IP: "192.0.2.2", ASN: 300, Country: "DE", ASNClass: geoip.ClassHosting},
+	}, lim)
+	want := []deviceGroup{{key: "country:DE", ips: []string{"10.0.0.1", "10.8.0.1", "192.0.2.1", "192.0.2.2"}}}
+	if d.deviceCount != 1 || d.exceeded() || !reflect.DeepEqual(d.groups, want) {
+		t.Errorf("devices=%d exceeded=%v groups=%+v, want 1 false %+v", d.deviceCount, d.exceeded(), d.groups, want)
+	}
+}
+
+// Мобильный ASN (CGNAT) — одно устройство на ASN, хостинг — каждый IP отдельно,
+// даже если включена группировка по подсетям.
+func TestNewDetection_ASNClasses(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, HWIDDeviceLimit: 2}
+	lim := limitDecision{limit: 2, actionMode: "manual"}
+	cfg := &config.Config{SubnetGrouping: true, SubnetPrefixV4: 24, SubnetPrefixV6: 64}
+
+	d := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "10.0.0.1", ASN: 100, ASNClass: geoip.ClassMobile},
+		{IP: "10.9.0.1", ASN: 100, ASNClass: geoip.ClassMobile},
+		{IP: "10.8.0.1", ASN: 200, ASNClass: geoip.ClassMobile},
+		{IP: "192.0.2.1", ASN: 300, ASNClass: geoip.ClassHosting},
+		{IP: "192.0.2.2", ASN: 300, ASNClass: geoip.ClassHosting},
+		{IP: "198.51.100.1", ASN: 400, ASNClass: geoip.ClassResidential},
+		{IP: "198.51.100.2", ASN: 400, ASNClass: geoip.ClassResidential},
+	}, lim)
+	// 2 мобильных ASN + 2 хостинг-IP + одна домашняя /24.
+	if d.deviceCount != 5 {
+		t.Errorf("devices = %d, want 5", d.deviceCount)
+	}
+	// Подсетей — сколько их на самом деле, без классов ASN.
+	if d.subnetGroups != 5 || d.modeGroups != 5 {
+		t.Errorf("subnetGroups = %d, want 5", d.subnetGroups)
+	}
+
+	mobile := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "10.0.0.1", ASN: 100, ASNClass: geoip.ClassMobile},
+		{IP: "10.9.0.1", ASN: 100, ASNClass: geoip.ClassMobile},
+		{IP: "10.8.0.1", ASN: 100, ASNClass: geoip.ClassMobile},
+	}, lim)
+	if mobile.deviceCount != 1 || mobile.subnetGroups != 3 {
+		t.Errorf("devices = %d subnetGroups = %d, want 1 3", mobile.deviceCount, mobile.subnetGroups)
+	}
+}
+
+// Домашний провайдер без группировки — IP одной подсети одно устройство.
+func TestNewDetection_ResidentialSubnet(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, HWIDDeviceLimit: 1}
+	lim := limitDecision{limit: 1, actionMode: "manual"}
+	cfg := &config.Config{SubnetPrefixV4: 24, SubnetPrefixV6: 64}
+
+	d := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "198.51.100.1", ASN: 400, ASNClass: geoip.ClassResidential},
+		{IP: "198.51.100.2", ASN: 400, ASNClass: geoip.ClassResidential},
+		{IP: "203.0.113.1", ASN: 500},
+		{IP: "203.0.113.2", ASN: 500},
+	}, lim)
+	// Одна домашняя /24 + два IP без класса.
+	if d.deviceCount != 3 {
+		t.Errorf("devices = %d, want 3", d.deviceCount)
+	}
+}
+
+// Гибрид: ASN, если известен, иначе подсеть; IPv6 без ASN — по /64.
+func TestNewDetection_HybridGrouping(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, HWIDDeviceLimit: 2}
+	lim := limitDecision{limit: 2, actionMode: "manual"}
+	cfg := &config.Config{HybridGrouping: true, ASNGrouping: true, SubnetGrouping: true, SubnetPrefixV4: 24, SubnetPrefixV6: 48}
+
+	d := newDetection(cfg, user, []api.ActiveIP{
+		{IP: "1.1.1.1", ASN: 100},
+		{IP: "9.9.9.9", ASN: 100},
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+		{IP: "2001:db8:0:1::1"},
+		{IP: "2001:db8:0:1::2"},
+		{IP: "2001:db8:0:2::1"},
+	}, lim)
+
+	want := []deviceGroup{
+		{key: "asn:100", ips: []string{"1.1.1.1", "9.9.9.9"}},
+		{key: "subnet:10.0.0.0/24", ips: []string{"10.0.0.1", "10.0.0.2"}},
+		{key: "subnet:2001:db8:0:1::/64", ips: []string{"2001:db8:0:1::1", "2001:db8:0:1::2"}},
+		{key: "subnet:2001:db8:0:2::/64", ips: []string{"2001:db8:0:2::1"}},
+	}
+	if d.deviceCount != len(want) || !reflect.DeepEqual(d.groups, want) {
+		t.Errorf("groups = %+v, want %+v", d.groups, want)
+	}
+	if d.asnGroups != 0 || d.subnetGroups != 0 {
+		t.Errorf("asnGroups=%d subnetGroups=%d, want 0 0", d.asnGroups, d.subnetGroups)
+	}
+	if got := cfg.GroupingMode(); got != "hybrid" {
+		t.Errorf("GroupingMode = %q, want hybrid", got)
+	}
+}
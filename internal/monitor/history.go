@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+const (
+	historyActionAlert         = "alert"
+	historyActionDisabled      = "disabled"
+	historyActionDisableFailed = "disable_failed"
+)
+
+func historyRetention(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.ViolationHistoryDays) * 24 * time.Hour
+}
+
+func (m *Monitor) violationEntry(d *detection) *cache.HistoryEntry {
+	entry := &cache.HistoryEntry{
+		At:           time.Now(),
+		Kind:         cache.HistoryViolation,
+		Username:     d.user.Username,
+		DeviceCount:  d.deviceCount,
+		Limit:        d.limit.limit,
+		LimitSource:  d.limit.source,
+		GroupingMode: m.cfg.Load().GroupingMode(),
+		IPs:          historyIPs(d.ips),
+	}
+	if d.score != nil {
+		entry.Score = d.score.Value
+	}
+	return entry
+}
+
+func historyIPs(ips []api.ActiveIP) []cache.HistoryIP {
+	out := make([]cache.HistoryIP, len(ips))
+	for i, ip := range ips {
+		out[i] = cache.HistoryIP{
+			IP:       ip.IP,
+			NodeName: ip.NodeName,
+			NodeUUID: ip.NodeUUID,
+			LastSeen: ip.LastSeen,
+			ASN:      ip.ASN,
+			ASNOrg:   ip.ASNOrg,
+			Country:  ip.Country,
+			ASNClass: ip.ASNClass,
+		}
+	}
+	return out
+}
+
+func (m *Monitor) recordHistory(ctx context.Context, userID int64, entry *cache.HistoryEntry) {
+	cfg := m.cfg.Load()
+	if cfg.ViolationHistoryLimit == 0 {
+		return
+	}
+	if err := m.cache.AppendHistory(ctx, userID, entry, cfg.ViolationHistoryLimit, historyRetention(cfg)); err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка записи истории нарушений")
+	}
+}
+
+// History возвращает историю пользователя за VIOLATION_HISTORY_DAYS, от свежих
+// записей к старым.
+func (m *Monitor) History(ctx context.Context, userID int64) ([]cache.HistoryEntry, error) {
+	since := time.Now().Add(-historyRetention(m.cfg.Load()))
+	return m.cache.GetHistory(ctx, userID, since)
+}
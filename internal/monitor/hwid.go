@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/telegram"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+// hwidExceeded — IP-групп больше, чем HWID-устройств с допуском HWID_TOLERANCE.
+func hwidExceeded(groups, registered, tolerance int) bool {
+	return registered > 0 && groups > registered+tolerance
+}
+
+// checkHWID сверяет число IP-групп с HWID-устройствами пользователя.
+func (m *Monitor) checkHWID(ctx context.Context, d *detection) {
+	cfg := m.cfg.Load()
+	if d.deviceCount <= 1+cfg.HWIDTolerance {
+		return
+	}
+	if d.user.UUID == "" {
+		m.logger.WithField("userID", d.user.UserID).Debug("HWID: нет UUID пользователя, сверка пропущена")
+		return
+	}
+
+	devices, err := m.hwidDevices(ctx, d.user)
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", d.user.UserID).Warn("Ошибка получения HWID-устройств")
+		return
+	}
+	if !hwidExceeded(d.deviceCount, len(devices), cfg.HWIDTolerance) {
+		return
+	}
+	if !m.alertAllowed(ctx, cache.AlertHWID, d.user.UserID) {
+		return
+	}
+
+	d.hwid = devices
+	hwidAlertsTotal.Inc()
+	m.logger.WithFields(logrus.Fields{
+		"userID":   d.user.UserID,
+		"username": d.user.Username,
+		"groups":   d.deviceCount,
+		"hwid":     len(devices),
+	}).Warn("HWID: IP-групп больше, чем зарегистрированных устройств")
+
+	text := telegram.FormatHWIDAlert(d.user, d.ips, devices, d.grouping(cfg), m.location)
+	m.raiseAlert(ctx, d, cache.ReasonHWIDMismatch, text, func(v *webhook.ViolationPayload) {
+		v.HWIDDeviceCount = len(devices)
+		v.HWIDDevices = hwidPayloads(devices)
+		v.Groups = groupPayloads(d.groups)
+	})
+}
+
+// hwidDevices — устройства пользователя из кэша или панели.
+func (m *Monitor) hwidDevices(ctx context.Context, user *api.CachedUser) ([]api.HWIDDevice, error) {
+	cached, err := m.cache.GetHWIDDevices(ctx, user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cache get hwid devices: %w", err)
+	}
+	if cached != nil {
+		return cached, nil
+	}
+
+	devices, err := m.api.GetUserHWIDDevices(ctx, user.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("api get hwid devices: %w", err)
+	}
+
+	ttl := time.Duration(m.cfg.Load().UserCacheTTL) * time.Second
+	if err := m.cache.SetHWIDDevices(ctx, user.UserID, devices, ttl); err != nil {
+		m.logger.WithError(err).WithField("userID", user.UserID).Warn("Ошибка кэширования HWID-устройств")
+	}
+	return devices, nil
+}
+
+func hwidPayloads(devices []api.HWIDDevice) []webhook.HWIDPayload {
+	out := make([]webhook.HWIDPayload, len(devices))
+	for i, dev := range devices {
+		out[i] = webhook.HWIDPayload{Platform: dev.Platform, OSVersion: dev.OSVersion, Model: dev.DeviceModel}
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package monitor
+
+import "testing"
+
+func TestHWIDExceeded(t *testing.T) {
+	cases := []struct {
+		name                          string
+		groups, registered, tolerance int
+		want                          bool
+	}{
+		{"within_devices", 2, 2, 0, false},
+		{"above_devices", 3, 2, 0, true},
+		{"tolerance", 3, 2, 1, false},
+		{"above_tolerance", 4, 2, 1, true},
+		// Без зарегистрированных устройств сверять не с чем.
+		{"no_devices", 5, 0, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hwidExceeded(tc.groups, tc.registered, tc.tolerance); got != tc.want {
+				t.Errorf("hwidExceeded(%d, %d, %d) = %v, want %v", tc.groups, tc.registered, tc.tolerance, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+// Причины системных действий в поле reason событий жизненного цикла.
+const (
+	reasonDeviceLimit  = "device_limit_exceeded"
+	reasonRestoreTimer = "restore_timer"
+)
+
+// actionEvents сопоставляет действия HandleAction событиям webhook.
+var actionEvents = map[string]string{
+	"drop":         config.EventConnectionsDropped,
+	"disable":      config.EventUserDisabled,
+	"disable_temp": config.EventUserDisabled,
+	"enable":       config.EventUserEnabled,
+	"ignore":       config.EventUserWhitelisted,
+	"ignore_temp":  config.EventUserWhitelisted,
+}
+
+// sendLifecycle отправляет событие жизненного цикла. Инициатор и причина
+// берутся из ctx (audit), без причины в ctx используется defaultReason.
+// user может быть nil — тогда данные пользователя подтягиваются по userID.
+func (m *Monitor) sendLifecycle(ctx context.Context, event string, userID int64, user *api.CachedUser, defaultReason string, details *webhook.LifecyclePayload) {
+	if m.webhook == nil {
+		return
+	}
+
+	var up *webhook.UserPayload
+	if userID != 0 {
+		if user == nil {
+			var err error
+			if user, err = m.getUser(ctx, userID); err != nil {
+				m.logger.WithError(err).WithField("userID", userID).Debug("Webhook: данные пользователя недоступны, отправляется только ID")
+			}
+		}
+		if user != nil {
+			up = userPayload(user)
+		} else {
+			up = &webhook.UserPayload{UserID: userID}
+		}
+	}
+
+	actor := audit.ActorFrom(ctx)
+	reason := audit.ReasonFrom(ctx)
+	if reason == "" {
+		reason = defaultReason
+	}
+
+	m.dispatchWebhook(ctx, &webhook.Payload{
+		Event:      event,
+		ActionMode: m.cfg.Load().ActionMode,
+		User:       up,
+		Actor:      &actor,
+		Reason:     reason,
+		Details:    details,
+		Timestamp:  time.Now(),
+	})
+}
+
+// sendActionLifecycle отправляет событие для выполненного действия HandleAction.
+func (m *Monitor) sendActionLifecycle(ctx context.Context, action string, userID int64) {
+	event, ok := actionEvents[action]
+	if !ok {
+		return
+	}
+	cfg := m.cfg.Load()
+
+	var details *webhook.LifecyclePayload
+	switch action {
+	case "disable", "ignore":
+		details = &webhook.LifecyclePayload{Permanent: true}
+	case "disable_temp":
+		details = durationDetails(cfg.AutoDisableDuration)
+	case "ignore_temp":
+		details = durationDetails(cfg.IgnoreDuration)
+	}
+	m.sendLifecycle(ctx, event, userID, nil, "", details)
+}
+
+// ConfigChanged отправляет config_changed при изменении настройки из бота.
+func (m *Monitor) ConfigChanged(ctx context.Context, key, oldValue, newValue string) {
+	m.sendLifecycle(ctx, config.EventConfigChanged, 0, nil, "", &webhook.LifecyclePayload{
+		Setting:  key,
+		OldValue: oldValue,
+		NewValue: newValue,
+	})
+}
+
+// durationDetails описывает срок отключения или whitelist; 0 — бессрочно.
+func durationDetails(durationMin int) *webhook.LifecyclePayload {
+	if durationMin <= 0 {
+		return &webhook.LifecyclePayload{Permanent: true}
+	}
+	return &webhook.LifecyclePayload{DurationMin: durationMin}
+}
@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+func lifecycleMonitor(t *testing.T) (*Monitor, func() []map[string]any) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		got = append(got, body)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	m := &Monitor{
+		cfg:     config.NewProvider(&config.Config{ActionMode: "auto"}),
+		logger:  quietLogger(),
+		webhook: webhook.NewDispatcher([]config.WebhookEndpoint{{Name: "test", URL: srv.URL}}, nil, 1, quietLogger()),
+	}
+	return m, func() []map[string]any {
+		m.webhookWG.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return got
+	}
+}
+
+func TestSendLifecycle_ActorAndReason(t *testing.T) {
+	m, received := lifecycleMonitor(t)
+
+	ctx := audit.WithActor(context.Background(), audit.Actor{Source: audit.SourceTelegram, ID: "111", Name: "@admin"})
+	ctx = audit.WithReason(ctx, "тикет 17")
+	user := &api.CachedUser{UserID: 42, Username: "alice", Tag: "TRIAL"}
+	m.sendLifecycle(ctx, config.EventUserDisabled, 42, user, reasonDeviceLimit, durationDetails(30))
+
+	got := received()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 webhook, got %d", len(got))
+	}
+	p := got[0]
+	if p["event"] != config.EventUserDisabled || p["reason"] != "тикет 17" {
+		t.Errorf("event/reason = %v/%v", p["event"], p["reason"])
+	}
+	actor := p["actor"].(map[string]any)
+	if actor["source"] != audit.SourceTelegram || actor["id"] != "111" {
+		t.Errorf("actor = %v", actor)
+	}
+	if p["user"].(map[string]any)["username"] != "alice" {
+		t.Errorf("user = %v", p["user"])
+	}
+	if p["details"].(map[string]any)["duration_min"] != float64(30) {
+		t.Errorf("details = %v", p["details"])
+	}
+	// у событий жизненного цикла нет блоков обнаружения
+	if _, ok := p["violation"]; ok {
+		t.Error("lifecycle event must not carry violation")
+	}
+}
+
+func TestSendLifecycle_SystemActorAndDefaultReason(t *testing.T) {
+	m, received := lifecycleMonitor(t)
+
+	m.sendLifecycle(context.Background(), config.EventConnectionsDropped, 42, &api.CachedUser{UserID: 42}, reasonDeviceLimit, nil)
+
+	p := received()[0]
+	if p["reason"] != reasonDeviceLimit {
+		t.Errorf("reason = %v, want %s", p["reason"], reasonDeviceLimit)
+	}
+	if p["actor"].(map[string]any)["source"] != audit.SourceSystem {
+		t.Errorf("actor = %v", p["actor"])
+	}
+}
+
+func TestConfigChanged_HasNoUser(t *testing.T) {
+	m, received := lifecycleMonitor(t)
+
+	m.ConfigChanged(context.Background(), "COOLDOWN", "300", "600")
+
+	p := received()[0]
+	if _, ok := p["user"]; ok {
+		t.Errorf("config_changed must not carry user: %v", p["user"])
+	}
+	details := p["details"].(map[string]any)
+	if details["setting"] != "COOLDOWN" || details["old_value"] != "300" || details["new_value"] != "600" {
+		t.Errorf("details = %v", details)
+	}
+}
+
+func TestDurationDetails(t *testing.T) {
+	if d := durationDetails(0); !d.Permanent || d.DurationMin != 0 {
+		t.Errorf("durationDetails(0) = %+v, want permanent", d)
+	}
+	if d := durationDetails(15); d.Permanent || d.DurationMin != 15 {
+		t.Errorf("durationDetails(15) = %+v", d)
+	}
+}
+
+// Ступень warn никого не отключает — AUTO_DISABLE_DURATION в webhook не попадает.
+func TestSendWebhook_EscalationWarnHasNoDuration(t *testing.T) {
+	m, received := lifecycleMonitor(t)
+	m.cfg = config.NewProvider(&config.Config{ActionMode: "auto", AutoDisableDuration: 30})
+
+	ladder, err := config.ParseEscalationLadder("warn,disable:1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &detection{user: &api.CachedUser{UserID: 42}, escalation: pickEscalation(ladder, 0)}
+	m.sendWebhook(context.Background(), config.EventViolationDetected, d, "auto", 1)
+
+	got := received()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 webhook, got %d", len(got))
+	}
+	if dur := got[0]["action"].(map[string]any)["auto_disable_duration_min"]; dur != float64(0) {
+		t.Errorf("auto_disable_duration_min = %v, want 0", dur)
+	}
+}
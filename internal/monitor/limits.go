@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"strings"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+const (
+	limitSourceUser    = "user"
+	limitSourceDefault = "default"
+)
+
+type limitDecision struct {
+	limit      int
+	tolerance  int
+	actionMode string
+	source     string
+}
+
+func (d limitDecision) banThreshold() int {
+	return d.limit + d.tolerance
+}
+
+func resolveLimit(cfg *config.Config, user *api.CachedUser) limitDecision {
+	d := limitDecision{
+		limit:      user.HWIDDeviceLimit,
+		actionMode: cfg.ActionMode,
+		source:     limitSourceUser,
+	}
+	tolerance := cfg.Tolerance
+
+	if user.HWIDDeviceLimit == -1 {
+		d.limit = cfg.DefaultDeviceLimit
+		d.source = limitSourceDefault
+		if p, ok := matchPolicy(cfg.LimitPolicies, user); ok {
+			d.limit = p.Limit
+			d.source = p.Source()
+			if p.Tolerance >= 0 {
+				tolerance = p.Tolerance
+			}
+			if p.ActionMode != "" {
+				d.actionMode = p.ActionMode
+			}
+		}
+	}
+
+	d.tolerance = tolerance + int(float64(d.limit)*cfg.ToleranceMultiplier)
+	return d
+}
+
+func matchPolicy(policies []config.LimitPolicy, user *api.CachedUser) (config.LimitPolicy, bool) {
+	for _, p := range policies {
+		switch p.Kind {
+		case config.PolicySquad:
+			for _, sq := range user.InternalSquads {
+				if strings.EqualFold(sq.UUID, p.Match) || strings.EqualFold(sq.Name, p.Match) {
+					return p, true
+				}
+			}
+		case config.PolicyTag:
+			if user.Tag != "" && strings.EqualFold(user.Tag, p.Match) {
+				return p, true
+			}
+		}
+	}
+	return config.LimitPolicy{}, false
+}
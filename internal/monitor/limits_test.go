@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+func TestResolveLimit(t *testing.T) {
+	cfg := &config.Config{
+		ActionMode:         "manual",
+		Tolerance:          1,
+		DefaultDeviceLimit: 2,
+		LimitPolicies: []config.LimitPolicy{
+			{Kind: config.PolicySquad, Match: "Family", Limit: 5, Tolerance: 0, ActionMode: "auto"},
+			{Kind: config.PolicyTag, Match: "vip", Limit: 0, Tolerance: -1},
+		},
+	}
+	family := []api.InternalSquad{{UUID: "sq-1", Name: "Family"}}
+
+	cases := []struct {
+		name string
+		user api.CachedUser
+		want limitDecision
+	}{
+		{"own_limit_wins", api.CachedUser{HWIDDeviceLimit: 3, InternalSquads: family},
+			limitDecision{limit: 3, tolerance: 1, actionMode: "manual", source: "user"}},
+		{"default", api.CachedUser{HWIDDeviceLimit: -1},
+			limitDecision{limit: 2, tolerance: 1, actionMode: "manual", source: "default"}},
+		{"squad_by_name", api.CachedUser{HWIDDeviceLimit: -1, InternalSquads: family},
+			limitDecision{limit: 5, tolerance: 0, actionMode: "auto", source: "squad:Family"}},
+		{"squad_by_uuid", api.CachedUser{HWIDDeviceLimit: -1, InternalSquads: []api.InternalSquad{{UUID: "Family"}}},
+			limitDecision{limit: 5, tolerance: 0, actionMode: "auto", source: "squad:Family"}},
+		{"tag_case_insensitive", api.CachedUser{HWIDDeviceLimit: -1, Tag: "VIP"},
+			limitDecision{limit: 0, tolerance: 1, actionMode: "manual", source: "tag:vip"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resolveLimit(cfg, &tc.user)
+			if got != tc.want {
+				t.Errorf("resolveLimit = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Пропорциональный допуск считается от лимита политики, а не от DEFAULT_DEVICE_LIMIT.
+func TestResolveLimit_MultiplierUsesPolicyLimit(t *testing.T) {
+	cfg := &config.Config{
+		DefaultDeviceLimit:  2,
+		ToleranceMultiplier: 0.5,
+		LimitPolicies:       []config.LimitPolicy{{Kind: config.PolicyTag, Match: "big", Limit: 10, Tolerance: -1}},
+	}
+	got := resolveLimit(cfg, &api.CachedUser{HWIDDeviceLimit: -1, Tag: "big"})
+	if got.tolerance != 5 || got.banThreshold() != 15 {
+		t.Errorf("tolerance = %d, banThreshold = %d, want 5 and 15", got.tolerance, got.banThreshold())
+	}
+}
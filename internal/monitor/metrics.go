@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	checksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "limiter_checks_total",
+		Help: "Циклы проверки по результату: ok, partial, failed, no_nodes.",
+	}, []string{"result"})
+	checkDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "limiter_check_duration_seconds",
+		Help:    "Длительность цикла проверки.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+	lastCheckTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_last_check_timestamp_seconds",
+		Help: "Unix-время последней успешной проверки.",
+	})
+
+	nodesPolled = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_nodes_polled",
+		Help: "Ноды, успешно опрошенные в последнем цикле.",
+	})
+	nodesFailed = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_nodes_failed",
+		Help: "Ноды, не ответившие в последнем цикле.",
+	})
+	nodesSkipped = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_nodes_ignored",
+		Help: "Ноды, пропущенные по IGNORED_NODE_UUIDS, NODE_EXCLUDE и NODE_INCLUDE в последнем цикле.",
+	})
+	nodesCached = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_nodes_cached",
+		Help: "Ноды, чей результат взят из прошлого опроса по NODE_INTERVALS, в последнем цикле.",
+	})
+	nodesStale = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_nodes_stale",
+		Help: "Не ответившие ноды, вместо которых взят прошлый результат (NODE_STALE_MAX_AGE), в последнем цикле.",
+	})
+	nodeDownAlertsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "limiter_node_down_alerts_total",
+		Help: "Алерты о нодах, не отвечающих NODE_FAILURE_ALERT_AFTER проверок подряд.",
+	})
+	activeUsers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_active_users",
+		Help: "Пользователи с активными IP в последнем цикле.",
+	})
+	staleIPsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_stale_ips",
+		Help: "IP, отброшенные по ACTIVE_IP_WINDOW в последнем цикле.",
+	})
+	whitelistedIPsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_whitelisted_ips",
+		Help: "IP, отброшенные по IP_WHITELIST в последнем цикле.",
+	})
+	transientIPsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_transient_ips",
+		Help: "IP, не учтённые как одновременные (COUNTING_MODE=concurrent) в последнем цикле.",
+	})
+
+	violationsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "limiter_violations_total",
+		Help: "Нарушения, прошедшие порог VIOLATION_THRESHOLD.",
+	})
+	travelViolationsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "limiter_impossible_travel_total",
+		Help: "Алерты о невозможном перемещении (IMPOSSIBLE_TRAVEL).",
+	})
+	resellerAlertsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "limiter_vpn_reseller_alerts_total",
+		Help: "Алерты о VPN-реселлерах (VPN_RESELLER_ALERT).",
+	})
+	hwidAlertsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "limiter_hwid_alerts_total",
+		Help: "Алерты о превышении HWID-устройств (HWID_CHECK).",
+	})
+	softAlertsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "limiter_soft_alerts_total",
+		Help: "Предупреждения в пределах допуска (AUTO_NOTIFY_SOFT).",
+	})
+	scoreDecisionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "limiter_score_decisions_total",
+		Help: "Решения SCORING по достигнутому порогу: soft, alert, disable.",
+	}, []string{"level"})
+	userPrefetchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "limiter_user_prefetch_total",
+		Help: "Фоновые загрузки пользователей (USER_PREFETCH): ok, failed.",
+	}, []string{"result"})
+	userPrefetchUsers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_user_prefetch_users",
+		Help: "Пользователи, загруженные в кэш последней успешной загрузкой.",
+	})
+	restoresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "limiter_restores_total",
+		Help: "Восстановления по таймеру: ok, retry, failed.",
+	}, []string{"result"})
+	restoreQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_restore_queue_depth",
+		Help: "Пользователи, ожидающие включения по таймеру.",
+	})
+
+	shadowFlaggedUsers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_shadow_flagged_users",
+		Help: "Пользователи сверх порога бана по shadow-конфигу в последнем цикле.",
+	})
+	shadowOnlyUsers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_shadow_only_users",
+		Help: "Пользователи, превышающие порог только по shadow-конфигу, в последнем цикле.",
+	})
+	shadowLiveOnlyUsers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_shadow_live_only_users",
+		Help: "Пользователи, превышающие порог только по живому конфигу, в последнем цикле.",
+	})
+)
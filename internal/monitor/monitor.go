@@ -16,6 +16,7 @@ import (
 	"github.com/remnawave/limiter/internal/config"
 	"github.com/remnawave/limiter/internal/geoip"
 	"github.com/remnawave/limiter/internal/i18n"
+	"github.com/remnawave/limiter/internal/notify"
 	"github.com/remnawave/limiter/internal/telegram"
 	"github.com/remnawave/limiter/internal/webhook"
 )
@@ -39,18 +40,30 @@ type Monitor struct {
 	api          *api.Client
 	cache        *cache.Cache
 	bot          *telegram.Bot
-	webhook      *webhook.Client
+	webhook      *webhook.Dispatcher
 	logger       *logrus.Logger
 	location     *time.Location
 	resolver     geoip.Resolver
+	locator      geoip.LocationResolver
 	ignoredNodes map[string]struct{}
 	ipWhitelist  *ipFilter
 
 	lastCheckUnix atomic.Int64
 	webhookWG     sync.WaitGroup
+
+	notifier *notify.Notifier
+
+	timeline *ipTimeline
+
+	nodeResults  map[string]nodeResult
+	nodeFailures map[string]int
+
+	snapshotMu sync.RWMutex
+	snapshot   map[int64][]api.ActiveIP
+	snapshotAt time.Time
 }
 
-func New(provider *config.Provider, apiClient *api.Client, c *cache.Cache, bot *telegram.Bot, wh *webhook.Client, resolver geoip.Resolver, logger *logrus.Logger) (*Monitor, error) {
+func New(provider *config.Provider, apiClient *api.Client, c *cache.Cache, bot *telegram.Bot, wh *webhook.Dispatcher, resolver geoip.Resolver, logger *logrus.Logger) (*Monitor, error) {
 	cfg := provider.Load()
 
 	loc, err := time.LoadLocation(cfg.Timezone)
@@ -79,6 +92,7 @@ func New(provider *config.Provider, apiClient *api.Client, c *cache.Cache, bot *
 		resolver:     resolver,
 		ignoredNodes: ignored,
 		ipWhitelist:  ipWhitelist,
+		timeline:     newIPTimeline(),
 	}, nil
 }
 
@@ -92,6 +106,10 @@ func (m *Monitor) Run(ctx context.Context) {
 
 	m.logger.Info(i18n.T("log.monitoring_started"))
 
+	if m.cfg.Load().UserPrefetch {
+		go m.userPrefetchLoop(ctx)
+	}
+
 	m.check(ctx)
 
 	for {
@@ -120,13 +138,15 @@ func (m *Monitor) check(ctx context.Context) {
 			return
 		}
 		m.logger.WithError(err).Error("Ошибка получения активных нод")
+		checksTotal.WithLabelValues("failed").Inc()
 		return
 	}
 
+	cfg := m.cfg.Load()
 	nodes := make([]api.Node, 0, len(allNodes))
 	skipped := 0
 	for _, n := range allNodes {
-		if _, ignored := m.ignoredNodes[strings.ToLower(n.UUID)]; ignored {
+		if m.nodeSkipped(cfg, n) {
 			skipped++
 			continue
 		}
@@ -136,35 +156,63 @@ func (m *Monitor) check(ctx context.Context) {
 	if skipped > 0 {
 		m.logger.WithField("skipped", skipped).Debug("Игнорируемые ноды пропущены")
 	}
+	nodesSkipped.Set(float64(skipped))
 
 	if len(nodes) == 0 {
 		m.logger.Debug("Нет активных нод")
-		m.lastCheckUnix.Store(time.Now().Unix())
+		checksTotal.WithLabelValues("no_nodes").Inc()
+		m.markChecked()
 		return
 	}
 
-	results, failed := m.fetchNodes(ctx, nodes)
-
-	if failed == len(nodes) {
+	now := time.Now()
+	due, reused := m.planNodes(cfg, nodes, now)
+	results, failedNodes := m.fetchNodes(ctx, due)
+	failed := len(failedNodes)
+	stale := m.staleNodes(cfg, failedNodes, now)
+	down, recovered := m.trackNodeFailures(cfg, due, failedNodes)
+	m.rememberNodes(nodes, results)
+	nodesPolled.Set(float64(len(due) - failed))
+	nodesFailed.Set(float64(failed))
+	nodesCached.Set(float64(len(reused)))
+	nodesStale.Set(float64(len(stale)))
+	m.notifyNodeOutages(ctx, cfg, down, recovered)
+
+	polled := len(results) > 0 || len(reused) > 0
+	if !polled && len(stale) == 0 {
 		m.logger.WithField("nodes", failed).Error("Не удалось опросить ни одну ноду, проверка пропущена")
+		checksTotal.WithLabelValues("failed").Inc()
 		return
 	}
 	if failed > 0 {
-		m.logger.WithFields(logrus.Fields{
+		fields := logrus.Fields{
 			"failed": failed,
 			"total":  len(nodes),
-		}).Warn("Часть нод не опрошена, проверка выполнена по неполным данным")
+		}
+		if len(stale) > 0 {
+			names := make([]string, len(stale))
+			for i, res := range stale {
+				names[i] = fmt.Sprintf("%s (%s)", res.nodeName, now.Sub(res.fetchedAt).Truncate(time.Second))
+			}
+			fields["staleNodes"] = strings.Join(names, ", ")
+		}
+		m.logger.WithFields(fields).Warn("Часть нод не опрошена, проверка выполнена по неполным данным")
 	}
+	results = append(results, reused...)
+	results = append(results, stale...)
 
-	m.lastCheckUnix.Store(time.Now().Unix())
+	if polled {
+		m.markChecked()
+	}
 
-	cfg := m.cfg.Load()
-	cutoff := time.Now().Add(-time.Duration(cfg.ActiveIPWindow) * time.Second)
+	window := time.Duration(cfg.ActiveIPWindow) * time.Second
 	aggregated := make(map[int64][]api.ActiveIP)
 	whitelistedIPs := 0
 	staleIPs := 0
 
 	for _, res := range results {
+		// Окно считается от момента опроса ноды.
+		cutoff := res.fetchedAt.Add(-window)
 		for _, entry := range res.entries {
 			for _, ip := range entry.IPs {
 				if ip.LastSeen.Before(cutoff) {
@@ -176,44 +224,95 @@ func (m *Monitor) check(ctx context.Context) {
 					continue
 				}
 				aggregated[entry.UserID] = append(aggregated[entry.UserID], api.ActiveIP{
-					IP:       ip.IP,
-					LastSeen: ip.LastSeen,
-					NodeName: res.nodeName,
-					NodeUUID: res.nodeUUID,
+					IP:          ip.IP,
+					LastSeen:    ip.LastSeen,
+					NodeName:    res.nodeName,
+					NodeUUID:    res.nodeUUID,
+					NodeCountry: res.nodeCountry,
+					Cached:      res.cached,
 				})
 			}
 		}
 	}
 
+	m.snapshotMu.Lock()
+	m.snapshot = aggregated
+	m.snapshotAt = time.Now()
+	m.snapshotMu.Unlock()
+
+	if countingConcurrent(cfg) {
+		m.timeline.observe(aggregated)
+	} else {
+		m.timeline.reset()
+	}
+
 	var st checkStats
 	m.checkUsers(ctx, aggregated, &st)
 
+	took := time.Since(started)
+	if failed > 0 {
+		checksTotal.WithLabelValues("partial").Inc()
+	} else {
+		checksTotal.WithLabelValues("ok").Inc()
+	}
+	checkDuration.Observe(took.Seconds())
+	activeUsers.Set(float64(len(aggregated)))
+	staleIPsGauge.Set(float64(staleIPs))
+	whitelistedIPsGauge.Set(float64(whitelistedIPs))
+	transientIPsGauge.Set(float64(st.transient.Load()))
+	if cfg.Shadow != nil {
+		shadowFlaggedUsers.Set(float64(st.shadowFlagged.Load()))
+		shadowOnlyUsers.Set(float64(st.shadowOnly.Load()))
+		shadowLiveOnlyUsers.Set(float64(st.shadowLiveOnly.Load()))
+	}
+
 	m.logger.WithFields(logrus.Fields{
 		"nodes":      fmt.Sprintf("%d/%d", len(nodes)-failed, len(nodes)),
 		"users":      len(aggregated),
 		"violations": st.violations.Load(),
-		"took":       time.Since(started).Truncate(time.Millisecond).String(),
+		"took":       took.Truncate(time.Millisecond).String(),
 	}).Info("Проверка")
 
 	m.logger.WithFields(logrus.Fields{
 		"staleIPs":    staleIPs,
 		"whitelisted": whitelistedIPs,
 		"softAlerts":  st.soft.Load(),
+		"cachedNodes": len(reused),
+		"staleNodes":  len(stale),
+		"transient":   st.transient.Load(),
 	}).Debug("Детали проверки")
 }
 
+func (m *Monitor) markChecked() {
+	now := time.Now().Unix()
+	m.lastCheckUnix.Store(now)
+	lastCheckTimestamp.Set(float64(now))
+}
+
 type checkStats struct {
 	violations atomic.Int64
 	soft       atomic.Int64
+	transient  atomic.Int64
+
+	shadowFlagged  atomic.Int64
+	shadowOnly     atomic.Int64
+	shadowLiveOnly atomic.Int64
 }
 
 type nodeResult struct {
-	nodeName string
-	nodeUUID string
-	entries  []api.UserIPEntry
+	nodeName    string
+	nodeUUID    string
+	nodeCountry string
+	entries     []api.UserIPEntry
+	fetchedAt   time.Time
+	// cached — результат прошлого опроса, а не свежий ответ ноды.
+	cached bool
+	// stale — нода не ответила, взят её прошлый результат (NODE_STALE_MAX_AGE).
+	stale bool
 }
 
-func (m *Monitor) fetchNodes(ctx context.Context, nodes []api.Node) ([]nodeResult, int) {
+// fetchNodes опрашивает ноды параллельно и возвращает также не ответившие.
+func (m *Monitor) fetchNodes(ctx context.Context, nodes []api.Node) ([]nodeResult, []api.Node) {
 	results := make([]nodeResult, len(nodes))
 	ok := make([]bool, len(nodes))
 
@@ -243,7 +342,7 @@ func (m *Monitor) fetchNodes(ctx context.Context, nodes []api.Node) ([]nodeResul
 				return
 			}
 
-			results[idx] = nodeResult{nodeName: n.Name, nodeUUID: n.UUID, entries: entries}
+			results[idx] = nodeResult{nodeName: n.Name, nodeUUID: n.UUID, nodeCountry: n.CountryCode, entries: entries, fetchedAt: time.Now()}
 			ok[idx] = true
 		}(i, node)
 	}
@@ -251,13 +350,13 @@ func (m *Monitor) fetchNodes(ctx context.Context, nodes []api.Node) ([]nodeResul
 	wg.Wait()
 
 	out := make([]nodeResult, 0, len(nodes))
-	failed := 0
+	var failed []api.Node
 	for i := range results {
 		if ok[i] {
 			out = append(out, results[i])
 			continue
 		}
-		failed++
+		failed = append(failed, nodes[i])
 	}
 	return out, failed
 }
@@ -303,6 +402,113 @@ func (m *Monitor) checkUsers(ctx context.Context, aggregated map[int64][]api.Act
 	wg.Wait()
 }
 
+type detection struct {
+	user          *api.CachedUser
+	ips           []api.ActiveIP
+	limit         limitDecision
+	deviceCount   int
+	subnetGroups  int
+	asnGroups     int
+	countryGroups int
+	// modeGroups — группы по режиму группировки без учёта классов ASN.
+	modeGroups int
+	groups     []deviceGroup
+	score      *cache.Score
+	escalation *escalation
+	// hwid — устройства пользователя в панели, если сработал HWID_CHECK.
+	hwid []api.HWIDDevice
+}
+
+// newDetection считает устройства по группировке из cfg. Чистая функция —
+// используется и для живого конфига, и для shadow.
+func newDetection(cfg *config.Config, user *api.CachedUser, uniqueIPs []api.ActiveIP, lim limitDecision) *detection {
+	d := &detection{
+		user:  user,
+		ips:   uniqueIPs,
+		limit: lim,
+	}
+	index := make(map[string]int, len(uniqueIPs))
+	for _, ip := range uniqueIPs {
+		key := deviceKey(cfg, ip)
+		i, ok := index[key]
+		if !ok {
+			i = len(d.groups)
+			index[key] = i
+			d.groups = append(d.groups, deviceGroup{key: key})
+		}
+		d.groups[i].ips = append(d.groups[i].ips, ip.IP)
+	}
+	d.deviceCount = len(d.groups)
+
+	// Счётчики режима — без классов ASN, как их подписывают алерты и webhook.
+	modeKeys := make(map[string]struct{}, len(uniqueIPs))
+	for _, ip := range uniqueIPs {
+		modeKeys[modeKey(cfg, ip)] = struct{}{}
+	}
+	d.modeGroups = len(modeKeys)
+	switch {
+	case cfg.CountryGrouping:
+		d.countryGroups = d.modeGroups
+	case cfg.HybridGrouping:
+	case cfg.ASNGrouping:
+		d.asnGroups = d.modeGroups
+	case cfg.SubnetGrouping:
+		d.subnetGroups = d.modeGroups
+	}
+	return d
+}
+
+// HybridPrefixV6 — префикс IPv6 гибридной группировки: /64 выдаётся одной
+// квартире или телефону, privacy-адреса внутри него — одно устройство.
+const HybridPrefixV6 = 64
+
+// deviceKey — ключ «устройства» для IP с учётом класса ASN. Классы меняют
+// только ключи по IP и подсети: ASN, гибрид и страна уже не мельче ASN.
+func deviceKey(cfg *config.Config, ip api.ActiveIP) string {
+	key := modeKey(cfg, ip)
+	if !strings.HasPrefix(key, "ip:") && !strings.HasPrefix(key, "subnet:") {
+		return key
+	}
+	switch ip.ASNClass {
+	case geoip.ClassMobile:
+		return "mobile:" + strconv.FormatUint(uint64(ip.ASN), 10)
+	case geoip.ClassHosting:
+		return "ip:" + ip.IP
+	case geoip.ClassResidential:
+		if key == "ip:"+ip.IP {
+			return "subnet:" + subnetPrefix(ip.IP, cfg.SubnetPrefixV4, cfg.SubnetPrefixV6)
+		}
+	}
+	return key
+}
+
+// modeKey — ключ IP по режиму группировки без учёта классов ASN.
+func modeKey(cfg *config.Config, ip api.ActiveIP) string {
+	switch {
+	case cfg.CountryGrouping:
+		if ip.Country != "" {
+			return "country:" + ip.Country
+		}
+	case cfg.HybridGrouping:
+		if ip.ASN != 0 {
+			return "asn:" + strconv.FormatUint(uint64(ip.ASN), 10)
+		}
+		return "subnet:" + subnetPrefix(ip.IP, cfg.SubnetPrefixV4, HybridPrefixV6)
+	case cfg.ASNGrouping:
+		if ip.ASN != 0 {
+			return "asn:" + strconv.FormatUint(uint64(ip.ASN), 10)
+		}
+	case cfg.SubnetGrouping:
+		return "subnet:" + subnetPrefix(ip.IP, cfg.SubnetPrefixV4, cfg.SubnetPrefixV6)
+	}
+	return "ip:" + ip.IP
+}
+
+// exceeded — превышен ли порог бана (без учёта VIOLATION_THRESHOLD и cooldown).
+func (d *detection) exceeded() bool {
+	return d.limit.limit > 0 && d.deviceCount > d.limit.banThreshold()
+}
+
 func (m *Monitor) checkUser(ctx context.Context, userID int64, activeIPs []api.ActiveIP, st *checkStats) {
 	cfg := m.cfg.Load()
 
@@ -321,73 +527,52 @@ func (m *Monitor) checkUser(ctx context.Context, userID int64, activeIPs []api.A
 		return
 	}
 
-	limit := m.resolveLimit(cfg, user.HWIDDeviceLimit)
-	if limit == 0 {
+	lim := resolveLimit(cfg, user)
+	if lim.limit == 0 && cfg.Shadow == nil {
 		return
 	}
 
-	uniqueMap := make(map[string]api.ActiveIP, len(activeIPs))
-	for _, ip := range activeIPs {
-		existing, ok := uniqueMap[ip.IP]
-		if !ok || ip.LastSeen.After(existing.LastSeen) {
-			uniqueMap[ip.IP] = ip
-		}
-	}
+	seen := m.uniqueIPs(activeIPs)
+	counted := m.countedIPs(cfg, userID, seen)
+	st.transient.Add(int64(len(seen) - len(counted)))
 
-	uniqueIPs := make([]api.ActiveIP, 0, len(uniqueMap))
-	for _, ip := range uniqueMap {
-		uniqueIPs = append(uniqueIPs, ip)
+	d := newDetection(cfg, user, counted, lim)
+	if cfg.Shadow != nil {
+		m.observeShadow(ctx, cfg.Shadow, d, seen, st)
 	}
-
-	if m.resolver != nil {
-		for i := range uniqueIPs {
-			if info, ok := m.resolver.Lookup(uniqueIPs[i].IP); ok {
-				uniqueIPs[i].ASN = info.Number
-				uniqueIPs[i].ASNOrg = info.Org
-			}
-		}
+	if lim.limit == 0 {
+		return
 	}
 
-	deviceCount := len(uniqueIPs)
-	subnetGroups := 0
-	asnGroups := 0
-	switch {
-	case cfg.ASNGrouping:
-		seenASN := make(map[uint32]struct{})
-		unknown := 0
-		for _, ip := range uniqueIPs {
-			if ip.ASN == 0 {
-				unknown++
-				continue
-			}
-			seenASN[ip.ASN] = struct{}{}
-		}
-		asnGroups = len(seenASN) + unknown
-		deviceCount = asnGroups
-	case cfg.SubnetGrouping:
-		seen := make(map[string]struct{})
-		for _, ip := range uniqueIPs {
-			seen[subnetPrefix(ip.IP, cfg.SubnetPrefixV4)] = struct{}{}
-		}
-		subnetGroups = len(seen)
-		deviceCount = subnetGroups
+	if cfg.ImpossibleTravel {
+		m.checkTravel(ctx, d)
+	}
+	if cfg.VPNResellerAlert {
+		m.checkReseller(ctx, d)
+	}
+	if cfg.HWIDCheck {
+		m.checkHWID(ctx, d)
 	}
 
-	effectiveTolerance := cfg.Tolerance + int(float64(limit)*cfg.ToleranceMultiplier)
-	banThreshold := limit + effectiveTolerance
+	if cfg.Scoring {
+		m.checkScore(ctx, d, st)
+		return
+	}
 
-	if deviceCount > banThreshold {
-		m.handleHardViolation(ctx, user, uniqueIPs, limit, deviceCount, subnetGroups, asnGroups, st)
+	if d.deviceCount > lim.banThreshold() {
+		m.handleHardViolation(ctx, d, st, lim.actionMode)
 		return
 	}
 
-	if cfg.ActionMode == "auto" && cfg.AutoNotifySoft && deviceCount > limit {
-		m.handleSoftWarning(ctx, user, uniqueIPs, limit, deviceCount, banThreshold, subnetGroups, asnGroups, st)
+	if lim.actionMode == "auto" && cfg.AutoNotifySoft && d.deviceCount > lim.limit {
+		m.handleSoftWarning(ctx, d, st)
 	}
 }
 
-func (m *Monitor) handleHardViolation(ctx context.Context, user *api.CachedUser, uniqueIPs []api.ActiveIP, limit, deviceCount, subnetGroups, asnGroups int, st *checkStats) {
+// handleHardViolation действует по actionMode; SCORING на пороге SCORE_ALERT передаёт manual.
+func (m *Monitor) handleHardViolation(ctx context.Context, d *detection, st *checkStats, actionMode string) {
 	cfg := m.cfg.Load()
+	user := d.user
 	userID := user.UserID
 
 	active, err := m.cache.IsCooldownActive(ctx, userID)
@@ -409,6 +594,57 @@ func (m *Monitor) handleHardViolation(ctx context.Context, user *api.CachedUser,
 		violationCount = 1
 	}
 
+	// При SCORING длительность превышения уже учтена в оценке.
+	if d.score == nil && !m.thresholdReached(ctx, d) {
+		return
+	}
+
+	st.violations.Add(1)
+	violationsTotal.Inc()
+
+	fields := logrus.Fields{
+		"userID":      userID,
+		"username":    user.Username,
+		"ips":         len(d.ips),
+		"devices":     d.deviceCount,
+		"limit":       d.limit.limit,
+		"limitSource": d.limit.source,
+		"violations":  violationCount,
+	}
+	if d.score != nil {
+		fields["score"] = d.score.Value
+	}
+	m.logger.WithFields(fields).Warn(i18n.T("log.limit_exceeded"))
+
+	if err := m.cache.RecordViolation(ctx, userID, user.Username); err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка записи статистики нарушения")
+	}
+
+	if actionMode == "auto" {
+		d.escalation = m.escalationFor(ctx, userID)
+	}
+
+	m.sendWebhook(ctx, config.EventViolationDetected, d, actionMode, violationCount)
+
+	entry := m.violationEntry(d)
+	if actionMode == "auto" {
+		entry.Action, entry.DurationMin = m.handleAutoAction(ctx, d, violationCount)
+		if d.escalation != nil {
+			entry.Escalation = d.escalation.step
+		}
+	} else {
+		m.handleManualAction(ctx, d, violationCount)
+		entry.Action = historyActionAlert
+	}
+	m.recordHistory(ctx, userID, entry)
+	m.notifyViolation(ctx, d, entry.Action, entry.DurationMin)
+}
+
+// thresholdReached — набрано ли VIOLATION_THRESHOLD превышений в окне.
+func (m *Monitor) thresholdReached(ctx context.Context, d *detection) bool {
+	cfg := m.cfg.Load()
+	userID := d.user.UserID
+
 	thresholdWindow := time.Duration(cfg.ViolationThresholdWindow) * time.Second
 	thresholdCount, err := m.cache.IncrThresholdCount(ctx, userID, thresholdWindow)
 	if err != nil {
@@ -419,44 +655,23 @@ func (m *Monitor) handleHardViolation(ctx context.Context, user *api.CachedUser,
 	if thresholdCount < int64(cfg.ViolationThreshold) {
 		m.logger.WithFields(logrus.Fields{
 			"userID":    userID,
-			"username":  user.Username,
-			"devices":   deviceCount,
-			"limit":     limit,
+			"username":  d.user.Username,
+			"devices":   d.deviceCount,
+			"limit":     d.limit.limit,
 			"threshold": fmt.Sprintf("%d/%d", thresholdCount, cfg.ViolationThreshold),
 		}).Warn(i18n.T("log.threshold_not_reached"))
-		return
+		return false
 	}
 
 	if err := m.cache.ResetThresholdCount(ctx, userID); err != nil {
 		m.logger.WithError(err).WithField("userID", userID).Error("Ошибка сброса порогового счётчика")
 	}
-
-	st.violations.Add(1)
-
-	m.logger.WithFields(logrus.Fields{
-		"userID":     userID,
-		"username":   user.Username,
-		"ips":        len(uniqueIPs),
-		"devices":    deviceCount,
-		"limit":      limit,
-		"violations": violationCount,
-	}).Warn(i18n.T("log.limit_exceeded"))
-
-	if err := m.cache.RecordViolation(ctx, userID, user.Username); err != nil {
-		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка записи статистики нарушения")
-	}
-
-	m.sendWebhook(ctx, "violation_detected", user, uniqueIPs, limit, violationCount, subnetGroups, asnGroups)
-
-	if cfg.ActionMode == "auto" {
-		m.handleAutoAction(ctx, user, uniqueIPs, limit, violationCount, subnetGroups, asnGroups)
-	} else {
-		m.handleManualAction(ctx, user, uniqueIPs, limit, violationCount, subnetGroups, asnGroups)
-	}
+	return true
 }
 
-func (m *Monitor) handleSoftWarning(ctx context.Context, user *api.CachedUser, uniqueIPs []api.ActiveIP, limit, deviceCount, banThreshold, subnetGroups, asnGroups int, st *checkStats) {
+func (m *Monitor) handleSoftWarning(ctx context.Context, d *detection, st *checkStats) {
 	cfg := m.cfg.Load()
+	user := d.user
 	userID := user.UserID
 
 	active, err := m.cache.IsSoftCooldownActive(ctx, userID)
@@ -473,22 +688,25 @@ func (m *Monitor) handleSoftWarning(ctx context.Context, user *api.CachedUser, u
 	}
 
 	st.soft.Add(1)
+	softAlertsTotal.Inc()
 
+	banThreshold := d.limit.banThreshold()
 	m.logger.WithFields(logrus.Fields{
 		"userID":       userID,
 		"username":     user.Username,
-		"ips":          len(uniqueIPs),
-		"devices":      deviceCount,
-		"limit":        limit,
+		"ips":          len(d.ips),
+		"devices":      d.deviceCount,
+		"limit":        d.limit.limit,
 		"banThreshold": banThreshold,
 	}).Warn(i18n.T("log.soft_warning"))
 
-	m.sendWebhook(ctx, "soft_violation_detected", user, uniqueIPs, limit, 0, subnetGroups, asnGroups)
+	m.sendWebhook(ctx, config.EventSoftViolationDetected, d, d.limit.actionMode, 0)
 
-	text := telegram.FormatSoftAlert(user, uniqueIPs, limit, banThreshold, m.location, subnetGroups, cfg.SubnetGrouping, asnGroups, cfg.ASNGrouping)
+	text := telegram.FormatSoftAlert(user, d.ips, d.limit.limit, banThreshold, m.location, d.grouping(cfg), d.score)
 	if err := m.bot.SendMessage(ctx, text); err != nil {
 		m.logger.WithError(err).WithField("userID", userID).Error("Ошибка отправки soft alert")
 	}
+	m.notifySoft(ctx, d)
 }
 
 func (m *Monitor) getUser(ctx context.Context, userID int64) (*api.CachedUser, error) {
@@ -505,8 +723,20 @@ func (m *Monitor) getUser(ctx context.Context, userID int64) (*api.CachedUser, e
 		return nil, fmt.Errorf("api get user: %w", err)
 	}
 
+	cu := cachedUserFromData(userID, userData)
+
+	ttl := time.Duration(m.cfg.Load().UserCacheTTL) * time.Second
+	if err := m.cache.SetUser(ctx, userID, cu, ttl); err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка кэширования пользователя")
+	}
+
+	return cu, nil
+}
+
+func cachedUserFromData(userID int64, userData *api.UserData) *api.CachedUser {
 	cu := &api.CachedUser{
 		UserID:   userID,
+		UUID:     userData.UUID,
 		Username: userData.Username,
 		Status:   userData.Status,
 	}
@@ -523,119 +753,185 @@ func (m *Monitor) getUser(ctx context.Context, userID int64) (*api.CachedUser, e
 		cu.HWIDDeviceLimit = -1
 	}
 	cu.SubscriptionURL = userData.SubscriptionURL
+	if userData.Tag != nil {
+		cu.Tag = *userData.Tag
+	}
+	cu.InternalSquads = userData.ActiveInternalSquads
+	return cu
+}
 
-	ttl := time.Duration(m.cfg.Load().UserCacheTTL) * time.Second
-	if err := m.cache.SetUser(ctx, userID, cu, ttl); err != nil {
-		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка кэширования пользователя")
+// uniqueIPs схлопывает повторы одного IP с разных нод и подставляет ASN и страну.
+func (m *Monitor) uniqueIPs(activeIPs []api.ActiveIP) []api.ActiveIP {
+	uniqueMap := make(map[string]api.ActiveIP, len(activeIPs))
+	for _, ip := range activeIPs {
+		existing, ok := uniqueMap[ip.IP]
+		if !ok || ip.LastSeen.After(existing.LastSeen) {
+			uniqueMap[ip.IP] = ip
+		}
 	}
 
-	return cu, nil
-}
+	uniqueIPs := make([]api.ActiveIP, 0, len(uniqueMap))
+	for _, ip := range uniqueMap {
+		uniqueIPs = append(uniqueIPs, ip)
+	}
 
-func (m *Monitor) resolveLimit(cfg *config.Config, hwidDeviceLimit int) int {
-	if hwidDeviceLimit == 0 {
-		return 0
+	if m.resolver != nil {
+		for i := range uniqueIPs {
+			if info, ok := m.resolver.Lookup(uniqueIPs[i].IP); ok {
+				uniqueIPs[i].ASN = info.Number
+				uniqueIPs[i].ASNOrg = info.Org
+				uniqueIPs[i].ASNClass = info.Class
+			}
+		}
 	}
-	if hwidDeviceLimit == -1 {
-		return cfg.DefaultDeviceLimit
+	if m.locator != nil {
+		for i := range uniqueIPs {
+			if loc, ok := m.locator.Locate(uniqueIPs[i].IP); ok {
+				uniqueIPs[i].Country = loc.Country
+				uniqueIPs[i].Latitude = loc.Latitude
+				uniqueIPs[i].Longitude = loc.Longitude
+				uniqueIPs[i].HasCoords = loc.HasCoords
+			}
+		}
 	}
-	return hwidDeviceLimit
+	return uniqueIPs
 }
 
-func (m *Monitor) handleManualAction(ctx context.Context, user *api.CachedUser, ips []api.ActiveIP, limit int, violationCount int64, subnetGroups, asnGroups int) {
+func (m *Monitor) handleManualAction(ctx context.Context, d *detection, violationCount int64) {
 	cfg := m.cfg.Load()
-	text := telegram.FormatManualAlert(user, ips, limit, violationCount, m.location, subnetGroups, cfg.SubnetGrouping, asnGroups, cfg.ASNGrouping)
-	if err := m.bot.SendManualAlert(ctx, text, user.UserID, cfg.AutoDisableDuration, cfg.IgnoreDuration); err != nil {
-		m.logger.WithError(err).WithField("userID", user.UserID).Error("Ошибка отправки manual alert")
+	text := telegram.FormatManualAlert(d.user, d.ips, d.limit.limit, violationCount, m.location, d.grouping(cfg), d.score)
+	if err := m.bot.SendManualAlert(ctx, text, d.user.UserID, cfg.AutoDisableDuration, cfg.IgnoreDuration); err != nil {
+		m.logger.WithError(err).WithField("userID", d.user.UserID).Error("Ошибка отправки manual alert")
 	}
 }
 
-func (m *Monitor) handleAutoAction(ctx context.Context, user *api.CachedUser, ips []api.ActiveIP, limit int, violationCount int64, subnetGroups, asnGroups int) {
+func (m *Monitor) handleAutoAction(ctx context.Context, d *detection, violationCount int64) (action string, durationMin int) {
+	if d.escalation != nil {
+		return m.handleEscalation(ctx, d, violationCount)
+	}
+
 	cfg := m.cfg.Load()
+	user := d.user
 	if err := m.api.DisableUser(ctx, user.UserID); err != nil {
 		m.logger.WithError(err).WithField("userID", user.UserID).Error("Ошибка отключения пользователя")
-		return
-	}
-
-	if cfg.AutoDisableDuration > 0 {
-		duration := time.Duration(cfg.AutoDisableDuration) * time.Minute
-		if err := m.cache.SetRestoreTimer(ctx, user.UserID, duration); err != nil {
-			m.logger.WithError(err).WithFields(logrus.Fields{
-				"userID":      user.UserID,
-				"durationMin": cfg.AutoDisableDuration,
-			}).Error("Пользователь отключён, но таймер восстановления не установлен — включите вручную")
-		}
+		return historyActionDisableFailed, 0
 	}
+	m.setRestoreTimer(ctx, user.UserID, cfg.AutoDisableDuration)
+	m.sendLifecycle(ctx, config.EventUserDisabled, user.UserID, user, reasonDeviceLimit, durationDetails(cfg.AutoDisableDuration))
 
-	text := telegram.FormatAutoAlert(user, ips, limit, cfg.AutoDisableDuration, violationCount, m.location, subnetGroups, cfg.SubnetGrouping, asnGroups, cfg.ASNGrouping)
+	text := telegram.FormatAutoAlert(user, d.ips, d.limit.limit, cfg.AutoDisableDuration, violationCount, m.location, d.grouping(cfg), d.score)
 	if err := m.bot.SendAutoAlert(ctx, text, user.UserID); err != nil {
 		m.logger.WithError(err).WithField("userID", user.UserID).Error("Ошибка отправки auto alert")
 	}
+	return historyActionDisabled, cfg.AutoDisableDuration
 }
 
-func (m *Monitor) sendWebhook(ctx context.Context, event string, user *api.CachedUser, ips []api.ActiveIP, limit int, violationCount int64, subnetGroups, asnGroups int) {
+func (m *Monitor) setRestoreTimer(ctx context.Context, userID int64, durationMin int) {
+	if durationMin <= 0 {
+		return
+	}
+	if err := m.cache.SetRestoreTimer(ctx, userID, time.Duration(durationMin)*time.Minute); err != nil {
+		m.logger.WithError(err).WithFields(logrus.Fields{
+			"userID":      userID,
+			"durationMin": durationMin,
+		}).Error("Пользователь отключён, но таймер восстановления не установлен — включите вручную")
+	}
+}
+
+func (m *Monitor) sendWebhook(ctx context.Context, event string, d *detection, actionMode string, violationCount int64) {
 	if m.webhook == nil {
 		return
 	}
 
 	cfg := m.cfg.Load()
+	user := d.user
 
-	ipPayloads := make([]webhook.IPPayload, len(ips))
-	for i, ip := range ips {
-		ipPayloads[i] = webhook.IPPayload{
-			IP:       ip.IP,
-			NodeName: ip.NodeName,
-			NodeUUID: ip.NodeUUID,
-			LastSeen: ip.LastSeen,
-			ASN:      ip.ASN,
-			ASNOrg:   ip.ASNOrg,
-		}
-	}
-
-	effectiveTolerance := cfg.Tolerance + int(float64(limit)*cfg.ToleranceMultiplier)
-	deviceGroupCount := len(ips)
-	groupingMode := "ip"
-	switch {
-	case cfg.ASNGrouping:
-		groupingMode = "asn"
-		if asnGroups > 0 {
-			deviceGroupCount = asnGroups
-		}
-	case cfg.SubnetGrouping:
-		groupingMode = "subnet"
-		if subnetGroups > 0 {
-			deviceGroupCount = subnetGroups
-		}
+	groupingMode := cfg.GroupingMode()
+	var prefixV4, prefixV6 int
+	switch groupingMode {
+	case "subnet":
+		prefixV4, prefixV6 = cfg.SubnetPrefixV4, cfg.SubnetPrefixV6
+	case "hybrid":
+		prefixV4, prefixV6 = cfg.SubnetPrefixV4, HybridPrefixV6
 	}
 
 	payload := &webhook.Payload{
 		Event:      event,
-		ActionMode: cfg.ActionMode,
-		User: webhook.UserPayload{
-			UserID:          user.UserID,
-			Username:        user.Username,
-			Email:           user.Email,
-			TelegramID:      user.TelegramID,
-			SubscriptionURL: user.SubscriptionURL,
-		},
-		Violation: webhook.ViolationPayload{
-			IPs:               ipPayloads,
-			IPCount:           len(ips),
-			DeviceLimit:       limit,
-			Tolerance:         effectiveTolerance,
-			EffectiveLimit:    limit + effectiveTolerance,
+		ActionMode: actionMode,
+		User:       userPayload(user),
+		Violation: &webhook.ViolationPayload{
+			Reason:            cache.ReasonDeviceLimit,
+			IPs:               ipPayloads(d.ips),
+			IPCount:           len(d.ips),
+			DeviceLimit:       d.limit.limit,
+			LimitSource:       d.limit.source,
+			Tolerance:         d.limit.tolerance,
+			EffectiveLimit:    d.limit.banThreshold(),
 			ViolationCount24h: violationCount,
-			SubnetCount:       subnetGroups,
-			ASNGroupCount:     asnGroups,
-			DeviceGroupCount:  deviceGroupCount,
+			SubnetCount:       d.subnetGroups,
+			SubnetPrefixV4:    prefixV4,
+			SubnetPrefixV6:    prefixV6,
+			ASNGroupCount:     d.asnGroups,
+			CountryGroupCount: d.countryGroups,
+			DeviceGroupCount:  d.deviceCount,
 			GroupingMode:      groupingMode,
+			Groups:            groupPayloads(d.groups),
 		},
-		Action: webhook.ActionPayload{
+		Action: &webhook.ActionPayload{
 			AutoDisableDurationMin: cfg.AutoDisableDuration,
 		},
 		Timestamp: time.Now(),
 	}
+	if s := d.score; s != nil {
+		payload.Violation.Score = &webhook.ScorePayload{
+			Value:       s.Value,
+			Groups:      s.Groups,
+			Persistence: s.Persistence,
+			Nodes:       s.Nodes,
+			Countries:   s.Countries,
+			ASN:         s.ASN,
+			History:     s.History,
+		}
+	}
+	if esc := d.escalation; esc != nil {
+		payload.Action.Escalation = &webhook.EscalationPayload{
+			Step:            esc.step,
+			Steps:           esc.total,
+			Action:          esc.Action,
+			DurationMin:     esc.durationMin(),
+			PriorViolations: esc.prior,
+			WindowDays:      cfg.EscalationWindowDays,
+		}
+		payload.Action.AutoDisableDurationMin = 0
+		if esc.Action == config.EscalationDisable {
+			payload.Action.AutoDisableDurationMin = esc.durationMin()
+		}
+	}
+
+	m.dispatchWebhook(ctx, payload)
+}
+
+func ipPayloads(ips []api.ActiveIP) []webhook.IPPayload {
+	out := make([]webhook.IPPayload, len(ips))
+	for i, ip := range ips {
+		out[i] = webhook.IPPayload{
+			IP:          ip.IP,
+			NodeName:    ip.NodeName,
+			NodeUUID:    ip.NodeUUID,
+			LastSeen:    ip.LastSeen,
+			ASN:         ip.ASN,
+			ASNOrg:      ip.ASNOrg,
+			Country:     ip.Country,
+			NodeCountry: ip.NodeCountry,
+			ASNClass:    ip.ASNClass,
+		}
+	}
+	return out
+}
 
+// dispatchWebhook отправляет payload в фоне, не блокируя проверку; при
+// остановке Run дожидается отправки в пределах webhookGracePeriod.
+func (m *Monitor) dispatchWebhook(ctx context.Context, payload *webhook.Payload) {
 	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookGracePeriod)
 
 	m.webhookWG.Add(1)
@@ -646,6 +942,22 @@ func (m *Monitor) sendWebhook(ctx context.Context, event string, user *api.Cache
 	}()
 }
 
+func userPayload(user *api.CachedUser) *webhook.UserPayload {
+	squads := make([]webhook.SquadPayload, len(user.InternalSquads))
+	for i, sq := range user.InternalSquads {
+		squads[i] = webhook.SquadPayload{UUID: sq.UUID, Name: sq.Name}
+	}
+	return &webhook.UserPayload{
+		UserID:          user.UserID,
+		Username:        user.Username,
+		Email:           user.Email,
+		TelegramID:      user.TelegramID,
+		SubscriptionURL: user.SubscriptionURL,
+		Tag:             user.Tag,
+		Squads:          squads,
+	}
+}
+
 func (m *Monitor) LastSuccessfulCheck() time.Time {
 	ts := m.lastCheckUnix.Load()
 	if ts == 0 {
@@ -678,10 +990,13 @@ func (m *Monitor) dailyReportLoop(ctx context.Context) {
 		case <-timer.C:
 		}
 
-		if !m.cfg.Load().DailyReport {
-			continue
+		cfg = m.cfg.Load()
+		if cfg.DailyReport {
+			m.sendDailyReport(ctx)
+		}
+		if cfg.Shadow != nil {
+			m.sendShadowReport(ctx)
 		}
-		m.sendDailyReport(ctx)
 	}
 }
 
@@ -724,6 +1039,10 @@ func (m *Monitor) restoreLoop(ctx context.Context) {
 			for _, member := range expired {
 				m.restoreUser(ctx, member)
 			}
+
+			if depth, err := m.cache.RestoreQueueLen(ctx); err == nil {
+				restoreQueueDepth.Set(float64(depth))
+			}
 		}
 	}
 }
@@ -750,6 +1069,7 @@ func (m *Monitor) restoreUser(ctx context.Context, member string) {
 		}
 
 		if attempts < maxRestoreAttempts {
+			restoresTotal.WithLabelValues("retry").Inc()
 			if reqErr := m.cache.SetRestoreTimer(ctx, userID, restoreRetryDelay); reqErr != nil {
 				m.logger.WithError(reqErr).WithField("userID", userID).Error("Не удалось вернуть пользователя в очередь восстановления — включите вручную")
 			}
@@ -765,6 +1085,11 @@ func (m *Monitor) restoreUser(ctx context.Context, member string) {
 			"userID":   userID,
 			"attempts": attempts,
 		}).Error("Не удалось включить пользователя по таймеру, попытки исчерпаны — включите вручную")
+		restoresTotal.WithLabelValues("failed").Inc()
+		m.sendLifecycle(ctx, config.EventRestoreFailed, userID, nil, reasonRestoreTimer, &webhook.LifecyclePayload{
+			Attempts: int(attempts),
+			Error:    err.Error(),
+		})
 
 		if sendErr := m.bot.SendMessage(ctx, fmt.Sprintf(i18n.T("restore.failed"), userID)); sendErr != nil {
 			m.logger.WithError(sendErr).Error("Ошибка отправки уведомления о неудачном восстановлении")
@@ -779,9 +1104,12 @@ func (m *Monitor) restoreUser(ctx context.Context, member string) {
 		m.logger.WithError(err).WithField("userID", userID).Debug("Ошибка сброса счётчика попыток восстановления")
 	}
 
+	restoresTotal.WithLabelValues("ok").Inc()
 	m.logger.WithField("userID", userID).Info("Пользователь автоматически включён по таймеру")
+	m.sendLifecycle(ctx, config.EventUserRestoredByTimer, userID, nil, reasonRestoreTimer, nil)
 
 	if err := m.bot.SendMessage(ctx, fmt.Sprintf(i18n.T("restore.message"), userID)); err != nil {
 		m.logger.WithError(err).Error("Ошибка отправки уведомления о восстановлении")
 	}
+	m.notifyRestored(ctx, userID)
 }
@@ -0,0 +1,177 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/telegram"
+)
+
+// nodeSkipped — нода исключена IGNORED_NODE_UUIDS, NODE_EXCLUDE или NODE_INCLUDE.
+func (m *Monitor) nodeSkipped(cfg *config.Config, n api.Node) bool {
+	if _, ignored := m.ignoredNodes[strings.ToLower(n.UUID)]; ignored {
+		return true
+	}
+	if nodeMatches(cfg.NodeExclude, n) {
+		return true
+	}
+	return len(cfg.NodeInclude) > 0 && !nodeMatches(cfg.NodeInclude, n)
+}
+
+func nodeMatches(selectors []config.NodeSelector, n api.Node) bool {
+	for _, sel := range selectors {
+		if sel.Matches(n.UUID, n.Name, n.CountryCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeInterval — интервал опроса ноды из NODE_INTERVALS; 0 — каждую проверку.
+func nodeInterval(cfg *config.Config, n api.Node) time.Duration {
+	for _, ni := range cfg.NodeIntervals {
+		if ni.Selector.Matches(n.UUID, n.Name, n.CountryCode) {
+			return time.Duration(ni.Interval) * time.Second
+		}
+	}
+	return 0
+}
+
+// planNodes делит ноды на те, что пора опросить, и те, чей результат ещё свеж.
+func (m *Monitor) planNodes(cfg *config.Config, nodes []api.Node, now time.Time) ([]api.Node, []nodeResult) {
+	slack := time.Duration(cfg.CheckInterval) * time.Second / 2
+	due := make([]api.Node, 0, len(nodes))
+	var reused []nodeResult
+	for _, n := range nodes {
+		interval := nodeInterval(cfg, n)
+		last, ok := m.nodeResults[n.UUID]
+		if interval > 0 && ok && now.Sub(last.fetchedAt)+slack < interval {
+			last.cached = true
+			reused = append(reused, last)
+			continue
+		}
+		due = append(due, n)
+	}
+	return due, reused
+}
+
+// rememberNodes сохраняет свежие результаты и забывает исчезнувшие ноды.
+func (m *Monitor) rememberNodes(nodes []api.Node, fresh []nodeResult) {
+	if m.nodeResults == nil {
+		m.nodeResults = make(map[string]nodeResult, len(nodes))
+	}
+	for _, res := range fresh {
+		m.nodeResults[res.nodeUUID] = res
+	}
+	active := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		active[n.UUID] = struct{}{}
+	}
+	for uuid := range m.nodeResults {
+		if _, ok := active[uuid]; !ok {
+			delete(m.nodeResults, uuid)
+		}
+	}
+	for uuid := range m.nodeFailures {
+		if _, ok := active[uuid]; !ok {
+			delete(m.nodeFailures, uuid)
+		}
+	}
+}
+
+// staleNodes — прошлые результаты не ответивших нод не старше NODE_STALE_MAX_AGE.
+func (m *Monitor) staleNodes(cfg *config.Config, failed []api.Node, now time.Time) []nodeResult {
+	maxAge := time.Duration(cfg.NodeStaleMaxAge) * time.Second
+	if maxAge <= 0 {
+		return nil
+	}
+	var out []nodeResult
+	for _, n := range failed {
+		last, ok := m.nodeResults[n.UUID]
+		if !ok || now.Sub(last.fetchedAt) > maxAge {
+			continue
+		}
+		last.cached = true
+		last.stale = true
+		out = append(out, last)
+	}
+	return out
+}
+
+// nodeOutage — нода, о сбое или восстановлении которой пора сообщить.
+type nodeOutage struct {
+	node     api.Node
+	failures int
+	// lastOK — время последнего успешного опроса, нулевое — не было.
+	lastOK time.Time
+}
+
+// trackNodeFailures считает сбои опроса подряд и отдаёт упавшие и восстановившиеся ноды.
+func (m *Monitor) trackNodeFailures(cfg *config.Config, due, failed []api.Node) (down, recovered []nodeOutage) {
+	if m.nodeFailures == nil {
+		m.nodeFailures = make(map[string]int)
+	}
+	after := cfg.NodeFailureAlertAfter
+
+	failedSet := make(map[string]struct{}, len(failed))
+	for _, n := range failed {
+		failedSet[n.UUID] = struct{}{}
+		count := m.nodeFailures[n.UUID] + 1
+		m.nodeFailures[n.UUID] = count
+		if after > 0 && count == after {
+			down = append(down, nodeOutage{node: n, failures: count, lastOK: m.nodeResults[n.UUID].fetchedAt})
+		}
+	}
+	for _, n := range due {
+		if _, ok := failedSet[n.UUID]; ok {
+			continue
+		}
+		count, ok := m.nodeFailures[n.UUID]
+		if !ok {
+			continue
+		}
+		delete(m.nodeFailures, n.UUID)
+		if after > 0 && count >= after {
+			recovered = append(recovered, nodeOutage{node: n, failures: count})
+		}
+	}
+	return down, recovered
+}
+
+// notifyNodeOutages сообщает в Telegram о сбоях и восстановлении нод.
+func (m *Monitor) notifyNodeOutages(ctx context.Context, cfg *config.Config, down, recovered []nodeOutage) {
+	maxAge := time.Duration(cfg.NodeStaleMaxAge) * time.Second
+	for _, o := range down {
+		nodeDownAlertsTotal.Inc()
+		m.logger.WithFields(logrus.Fields{
+			"node":     o.node.Name,
+			"nodeUUID": o.node.UUID,
+			"failures": o.failures,
+		}).Warn("Нода не отвечает несколько проверок подряд")
+
+		var staleUntil time.Time
+		if !o.lastOK.IsZero() && maxAge > 0 {
+			staleUntil = o.lastOK.Add(maxAge)
+		}
+		text := telegram.FormatNodeDownAlert(o.node.Name, o.node.UUID, o.failures, o.lastOK, staleUntil, m.location)
+		if err := m.bot.SendMessage(ctx, text); err != nil {
+			m.logger.WithError(err).Error("Ошибка отправки алерта о недоступной ноде")
+		}
+	}
+	for _, o := range recovered {
+		m.logger.WithFields(logrus.Fields{
+			"node":     o.node.Name,
+			"nodeUUID": o.node.UUID,
+			"failures": o.failures,
+		}).Info("Нода снова отвечает")
+
+		if err := m.bot.SendMessage(ctx, telegram.FormatNodeRecovered(o.node.Name, o.failures)); err != nil {
+			m.logger.WithError(err).Error("Ошибка отправки сообщения о восстановлении ноды")
+		}
+	}
+}
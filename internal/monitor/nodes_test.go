@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+func mustSelectors(t *testing.T, s string) []config.NodeSelector {
+	t.Helper()
+	sel, err := config.ParseNodeSelectors(s)
+	if err != nil {
+		t.Fatalf("ParseNodeSelectors(%q): %v", s, err)
+	}
+	return sel
+}
+
+func TestNodeSkipped(t *testing.T) {
+	m := &Monitor{ignoredNodes: map[string]struct{}{"ignored": {}}}
+	cfg := &config.Config{
+		NodeInclude: mustSelectors(t, "country:DE,name:eu-*"),
+		NodeExclude: mustSelectors(t, "name:*-test"),
+	}
+	cases := []struct {
+		node api.Node
+		want bool
+	}{
+		{api.Node{UUID: "a", Name: "Frankfurt", CountryCode: "DE"}, false},
+		{api.Node{UUID: "b", Name: "EU-Amsterdam", CountryCode: "NL"}, false},
+		{api.Node{UUID: "c", Name: "Tokyo", CountryCode: "JP"}, true},
+		{api.Node{UUID: "d", Name: "Frankfurt-test", CountryCode: "DE"}, true},
+		{api.Node{UUID: "IGNORED", Name: "Berlin", CountryCode: "DE"}, true},
+	}
+	for _, tc := range cases {
+		if got := m.nodeSkipped(cfg, tc.node); got != tc.want {
+			t.Errorf("nodeSkipped(%+v) = %v, want %v", tc.node, got, tc.want)
+		}
+	}
+
+	// Без NODE_INCLUDE опрашивается всё, кроме исключённого.
+	cfg.NodeInclude = nil
+	if m.nodeSkipped(cfg, api.Node{UUID: "c", Name: "Tokyo", CountryCode: "JP"}) {
+		t.Error("node without include rules should not be skipped")
+	}
+}
+
+func TestPlanNodes_Intervals(t *testing.T) {
+	intervals, err := config.ParseNodeIntervals("country:JP=600")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{CheckInterval: 60, NodeIntervals: intervals}
+	de := api.Node{UUID: "de", Name: "Frankfurt", CountryCode: "DE"}
+	jp := api.Node{UUID: "jp", Name: "Tokyo", CountryCode: "JP"}
+	nodes := []api.Node{de, jp}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	m := &Monitor{}
+	// Первая проверка: прошлых результатов нет — опрашиваются все.
+	due, reused := m.planNodes(cfg, nodes, now)
+	if len(due) != 2 || len(reused) != 0 {
+		t.Fatalf("first check: due = %d, reused = %d", len(due), len(reused))
+	}
+	m.rememberNodes(nodes, []nodeResult{
+		{nodeUUID: "de", fetchedAt: now},
+		{nodeUUID: "jp", fetchedAt: now},
+	})
+
+	// Через 5 минут JP берётся из кэша, DE опрашивается как обычно.
+	due, reused = m.planNodes(cfg, nodes, now.Add(5*time.Minute))
+	if len(due) != 1 || due[0].UUID != "de" || len(reused) != 1 || reused[0].nodeUUID != "jp" || !reused[0].cached {
+		t.Errorf("after 5m: due = %+v, reused = %+v", due, reused)
+	}
+
+	// Чуть меньше интервала — уже пора (запас на дрожание тикера).
+	due, _ = m.planNodes(cfg, nodes, now.Add(599*time.Second))
+	if len(due) != 2 {
+		t.Errorf("at 599s: due = %d, want 2", len(due))
+	}
+
+	// Нода пропала из опроса — её результат забыт.
+	m.rememberNodes([]api.Node{de}, nil)
+	if _, ok := m.nodeResults["jp"]; ok {
+		t.Error("result of removed node should be forgotten")
+	}
+}
+
+func TestStaleNodes_MaxAge(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	de := api.Node{UUID: "de", Name: "Frankfurt"}
+	jp := api.Node{UUID: "jp", Name: "Tokyo"}
+	m := &Monitor{}
+	m.rememberNodes([]api.Node{de, jp}, []nodeResult{
+		{nodeUUID: "de", fetchedAt: now.Add(-2 * time.Minute)},
+		{nodeUUID: "jp", fetchedAt: now.Add(-10 * time.Minute)},
+	})
+
+	// DE ещё в пределах NODE_STALE_MAX_AGE, JP уже слишком старый.
+	stale := m.staleNodes(&config.Config{NodeStaleMaxAge: 300}, []api.Node{de, jp}, now)
+	if len(stale) != 1 || stale[0].nodeUUID != "de" || !stale[0].stale || !stale[0].cached {
+		t.Errorf("stale = %+v, want only de marked stale", stale)
+	}
+
+	// 0 — переиспользование выключено.
+	if got := m.staleNodes(&config.Config{NodeStaleMaxAge: 0}, []api.Node{de}, now); len(got) != 0 {
+		t.Errorf("NODE_STALE_MAX_AGE=0: stale = %+v, want none", got)
+	}
+}
+
+func TestTrackNodeFailures(t *testing.T) {
+	cfg := &config.Config{NodeFailureAlertAfter: 3}
+	jp := api.Node{UUID: "jp", Name: "Tokyo"}
+	m := &Monitor{}
+
+	// Алерт ровно на третьем сбое подряд, не раньше и не повторно.
+	for i := 1; i <= 4; i++ {
+		down, _ := m.trackNodeFailures(cfg, []api.Node{jp}, []api.Node{jp})
+		if want := i == 3; (len(down) == 1) != want {
+			t.Errorf("failure %d: down = %+v, want alert = %v", i, down, want)
+		}
+	}
+
+	// Нода ответила — сообщение о восстановлении, счётчик сброшен.
+	_, recovered := m.trackNodeFailures(cfg, []api.Node{jp}, nil)
+	if len(recovered) != 1 || recovered[0].failures != 4 {
+		t.Errorf("recovered = %+v, want jp after 4 failures", recovered)
+	}
+	if _, ok := m.nodeFailures["jp"]; ok {
+		t.Error("failure counter should be reset after recovery")
+	}
+
+	// Сбой короче порога восстанавливается молча.
+	m.trackNodeFailures(cfg, []api.Node{jp}, []api.Node{jp})
+	if _, recovered = m.trackNodeFailures(cfg, []api.Node{jp}, nil); len(recovered) != 0 {
+		t.Errorf("recovered = %+v, want none below threshold", recovered)
+	}
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/remnawave/limiter/internal/notify"
+)
+
+func (m *Monitor) SetNotifier(n *notify.Notifier) {
+	m.notifier = n
+}
+
+// notifyViolation сообщает подписчику о нарушении по итогу действия
+// (значение HistoryEntry.Action). Неудачные действия не сообщаются.
+func (m *Monitor) notifyViolation(ctx context.Context, d *detection, action string, durationMin int) {
+	if m.notifier == nil {
+		return
+	}
+	data := notify.Data{Devices: d.deviceCount, Limit: d.limit.limit}
+
+	event := notify.EventViolation
+	switch action {
+	case historyActionAlert, historyActionWarned:
+	case historyActionDropped:
+		data.Dropped = true
+	case historyActionDisabled:
+		event = notify.EventDisabled
+		data.Permanent = durationMin == 0
+		if !data.Permanent {
+			data.Until = m.notifier.FormatUntil(durationMin)
+		}
+	default:
+		return
+	}
+	m.notifier.Notify(ctx, event, d.user, data)
+}
+
+func (m *Monitor) notifySoft(ctx context.Context, d *detection) {
+	if m.notifier == nil {
+		return
+	}
+	m.notifier.Notify(ctx, notify.EventSoft, d.user, notify.Data{Devices: d.deviceCount, Limit: d.limit.limit})
+}
+
+func (m *Monitor) notifyRestored(ctx context.Context, userID int64) {
+	if m.notifier == nil || !m.cfg.Load().UserNotify {
+		return
+	}
+	user, err := m.getUser(ctx, userID)
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Не удалось получить пользователя для уведомления о восстановлении")
+		return
+	}
+	m.notifier.Notify(ctx, notify.EventRestored, user, notify.Data{Limit: resolveLimit(m.cfg.Load(), user).limit})
+}
@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/panelhook"
+)
+
+// HandlePanelEvent реагирует на webhook панели Remnawave: кэш пользователя
+// (вместе с HWID-устройствами) сбрасывается при любом событии, чтобы следующая
+// проверка увидела новый лимит, статус, сквады или устройства. Удаление
+// пользователя и его включение в панели отменяют ожидающее восстановление
+// по таймеру.
+func (m *Monitor) HandlePanelEvent(ctx context.Context, ev panelhook.Event) error {
+	fields := logrus.Fields{
+		"event":    ev.Name,
+		"userID":   ev.UserID,
+		"username": ev.Username,
+	}
+
+	if err := m.cache.DeleteUser(ctx, ev.UserID); err != nil {
+		return fmt.Errorf("сброс кэша пользователя: %w", err)
+	}
+
+	switch ev.Name {
+	case panelhook.EventUserDeleted, panelhook.EventUserEnabled:
+		cancelled, err := m.cache.CancelRestoreTimer(ctx, ev.UserID)
+		if err != nil {
+			return err
+		}
+		if err := m.cache.ResetRestoreAttempts(ctx, ev.UserID); err != nil {
+			m.logger.WithError(err).WithFields(fields).Debug("Ошибка сброса счётчика попыток восстановления")
+		}
+		if cancelled {
+			if ev.Name == panelhook.EventUserDeleted {
+				m.logger.WithFields(fields).Info("Пользователь удалён в панели, таймер восстановления снят")
+			} else {
+				m.logger.WithFields(fields).Info("Пользователь включён в панели, восстановление по таймеру отменено")
+			}
+		}
+	}
+
+	m.logger.WithFields(fields).Debug("Webhook панели: кэш пользователя сброшен")
+	return nil
+}
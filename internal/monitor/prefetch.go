@@ -0,0 +1,83 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+// prefetchTTL — записи живут две загрузки.
+func prefetchTTL(cfg *config.Config) time.Duration {
+	return time.Duration(max(cfg.UserCacheTTL, 2*cfg.UserPrefetchInterval)) * time.Second
+}
+
+// userPrefetchLoop загружает пользователей сразу и затем каждые USER_PREFETCH_INTERVAL секунд.
+func (m *Monitor) userPrefetchLoop(ctx context.Context) {
+	m.prefetchUsers(ctx)
+	for {
+		interval := time.Duration(m.cfg.Load().UserPrefetchInterval) * time.Second
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		m.prefetchUsers(ctx)
+	}
+}
+
+func (m *Monitor) prefetchUsers(ctx context.Context) {
+	cfg := m.cfg.Load()
+	started := time.Now()
+	ttl := prefetchTTL(cfg)
+
+	loaded, err := m.fetchAllUsers(ctx, cfg.UserPrefetchPageSize, func(page []api.UserData) error {
+		batch := make([]*api.CachedUser, len(page))
+		for i := range page {
+			batch[i] = cachedUserFromData(page[i].ID, &page[i])
+		}
+		return m.cache.SetUsers(ctx, batch, ttl)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		userPrefetchTotal.WithLabelValues("failed").Inc()
+		m.logger.WithError(err).WithField("loaded", loaded).Warn("Ошибка фоновой загрузки пользователей, промахи кэша добираются по одному")
+		return
+	}
+
+	userPrefetchTotal.WithLabelValues("ok").Inc()
+	userPrefetchUsers.Set(float64(loaded))
+	m.logger.WithFields(logrus.Fields{
+		"users": loaded,
+		"took":  time.Since(started).Truncate(time.Millisecond).String(),
+	}).Debug("Пользователи загружены в кэш")
+}
+
+// fetchAllUsers отдаёт в visit страницы /api/users и возвращает их общий размер.
+func (m *Monitor) fetchAllUsers(ctx context.Context, pageSize int, visit func([]api.UserData) error) (int, error) {
+	loaded := 0
+	for {
+		page, total, err := m.api.GetUsers(ctx, loaded, pageSize)
+		if err != nil {
+			return loaded, err
+		}
+		if len(page) == 0 {
+			return loaded, nil
+		}
+		if err := visit(page); err != nil {
+			return loaded, fmt.Errorf("кэширование пользователей: %w", err)
+		}
+		loaded += len(page)
+		if loaded >= total {
+			return loaded, nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+// usersServer отдаёт total пользователей страницами, как /api/users панели.
+func usersServer(t *testing.T, total int, requests *[]int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		*requests = append(*requests, start)
+
+		users := "["
+		for id := start + 1; id <= min(start+size, total); id++ {
+			if id > start+1 {
+				users += ","
+			}
+			users += fmt.Sprintf(`{"id":%d,"username":"u%d"}`, id, id)
+		}
+		users += "]"
+		fmt.Fprintf(w, `{"response":{"total":%d,"users":%s}}`, total, users)
+	}))
+}
+
+func TestFetchAllUsers_Pages(t *testing.T) {
+	var requests []int
+	srv := usersServer(t, 5, &requests)
+	defer srv.Close()
+
+	m := &Monitor{api: api.NewClient(srv.URL, "token"), logger: quietLogger()}
+
+	var ids []int64
+	loaded, err := m.fetchAllUsers(context.Background(), 2, func(page []api.UserData) error {
+		for _, u := range page {
+			ids = append(ids, u.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("fetchAllUsers: %v", err)
+	}
+	if loaded != 5 || len(ids) != 5 || ids[4] != 5 {
+		t.Errorf("loaded = %d, ids = %v; want 5 users 1..5", loaded, ids)
+	}
+	// 3 страницы: 0, 2, 4 — после последней total достигнут, лишнего запроса нет.
+	if fmt.Sprint(requests) != "[0 2 4]" {
+		t.Errorf("requests = %v, want [0 2 4]", requests)
+	}
+}
+
+func TestFetchAllUsers_VisitError(t *testing.T) {
+	var requests []int
+	srv := usersServer(t, 5, &requests)
+	defer srv.Close()
+
+	m := &Monitor{api: api.NewClient(srv.URL, "token"), logger: quietLogger()}
+
+	loaded, err := m.fetchAllUsers(context.Background(), 2, func([]api.UserData) error {
+		return fmt.Errorf("redis down")
+	})
+	if err == nil || loaded != 0 || len(requests) != 1 {
+		t.Errorf("loaded = %d, err = %v, requests = %v; want stop after first page", loaded, err, requests)
+	}
+}
+
+func TestPrefetchTTL(t *testing.T) {
+	cfg := &config.Config{UserCacheTTL: 600, UserPrefetchInterval: 300}
+	if got := prefetchTTL(cfg); got != 10*time.Minute {
+		t.Errorf("prefetchTTL = %v, want 10m", got)
+	}
+	cfg.UserPrefetchInterval = 900
+	if got := prefetchTTL(cfg); got != 30*time.Minute {
+		t.Errorf("prefetchTTL = %v, want 30m", got)
+	}
+}
@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/geoip"
+	"github.com/remnawave/limiter/internal/telegram"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+// hostingIPCount — число IP из ASN класса hosting (ASN_HOSTING).
+func hostingIPCount(d *detection) int {
+	n := 0
+	for _, ip := range d.ips {
+		if ip.ASNClass == geoip.ClassHosting {
+			n++
+		}
+	}
+	return n
+}
+
+// checkReseller сообщает о вероятной перепродаже подписки через VPN/прокси:
+// одновременно активно VPN_RESELLER_MIN_IPS и больше IP из хостинг-ASN.
+func (m *Monitor) checkReseller(ctx context.Context, d *detection) {
+	cfg := m.cfg.Load()
+	count := hostingIPCount(d)
+	if count < cfg.VPNResellerMinIPs {
+		return
+	}
+	if !m.alertAllowed(ctx, cache.AlertVPNReseller, d.user.UserID) {
+		return
+	}
+
+	resellerAlertsTotal.Inc()
+	m.logger.WithFields(logrus.Fields{
+		"userID":    d.user.UserID,
+		"username":  d.user.Username,
+		"hostingIP": count,
+	}).Warn("VPN-реселлер: много одновременных IP из хостинг-ASN")
+
+	text := telegram.FormatResellerAlert(d.user, d.ips, count, m.location)
+	m.raiseAlert(ctx, d, cache.ReasonVPNReseller, text, func(v *webhook.ViolationPayload) {
+		v.HostingIPCount = count
+	})
+}
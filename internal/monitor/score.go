@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/geoip"
+)
+
+// Веса сигналов SCORING в баллах; в сумме 100.
+const (
+	weightGroups      = 35
+	weightPersistence = 20
+	weightCountries   = 15
+	weightNodes       = 10
+	weightASN         = 10
+	weightHistory     = 10
+)
+
+type scoreInput struct {
+	excessFor time.Duration
+	window    time.Duration
+	prior     int
+}
+
+// computeScore оценивает уверенность в нарушении от 0 до 100.
+func computeScore(d *detection, in scoreInput) *cache.Score {
+	s := &cache.Score{At: time.Now()}
+	excess := d.deviceCount - d.limit.limit
+	if excess <= 0 {
+		return s
+	}
+
+	nodes := make(map[string]struct{})
+	countries := make(map[string]struct{})
+	asns := make(map[uint32]struct{})
+	hosting := 0
+	for _, ip := range d.ips {
+		node := ip.NodeUUID
+		if node == "" {
+			node = ip.NodeName
+		}
+		nodes[node] = struct{}{}
+		if ip.Country != "" {
+			countries[ip.Country] = struct{}{}
+		}
+		if ip.ASN != 0 {
+			asns[ip.ASN] = struct{}{}
+		}
+		if ip.ASNClass == geoip.ClassHosting {
+			hosting++
+		}
+	}
+
+	asnPart := spread(len(asns), 3)
+	if len(d.ips) > 0 {
+		asnPart = math.Max(asnPart, float64(hosting)/float64(len(d.ips)))
+	}
+	persistence := 0.0
+	if in.window > 0 {
+		persistence = math.Min(float64(in.excessFor)/float64(in.window), 1)
+	}
+
+	s.Groups = points(weightGroups, float64(excess)/float64(max(d.limit.limit, 1)))
+	s.Persistence = points(weightPersistence, persistence)
+	s.Nodes = points(weightNodes, spread(len(nodes), 2))
+	s.Countries = points(weightCountries, spread(len(countries), 2))
+	s.ASN = points(weightASN, asnPart)
+	s.History = points(weightHistory, float64(in.prior)/3)
+	s.Value = min(s.Groups+s.Persistence+s.Nodes+s.Countries+s.ASN+s.History, 100)
+	return s
+}
+
+// spread нормирует число различных значений: одно — 0, 1+full — 1.
+func spread(distinct, full int) float64 {
+	if distinct <= 1 {
+		return 0
+	}
+	return math.Min(float64(distinct-1)/float64(full), 1)
+}
+
+func points(weight int, part float64) int {
+	return int(math.Round(float64(weight) * math.Min(math.Max(part, 0), 1)))
+}
+
+// checkScore выбирает действие по порогам SCORE_SOFT, SCORE_ALERT и SCORE_DISABLE.
+func (m *Monitor) checkScore(ctx context.Context, d *detection, st *checkStats) {
+	cfg := m.cfg.Load()
+	userID := d.user.UserID
+	if d.deviceCount <= d.limit.limit {
+		return
+	}
+
+	// Ключ переживает пару пропущенных проверок, но не перерыв в превышении.
+	ttl := max(3*time.Duration(cfg.CheckInterval)*time.Second, time.Minute)
+	in := scoreInput{window: time.Duration(cfg.ViolationThresholdWindow) * time.Second}
+	if since, err := m.cache.TouchExcess(ctx, userID, ttl); err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка отметки превышения для оценки")
+	} else {
+		in.excessFor = time.Since(since)
+	}
+	history, err := m.cache.GetHistory(ctx, userID, time.Now().Add(-historyRetention(cfg)))
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка чтения истории для оценки")
+	}
+	in.prior = countViolations(history)
+
+	d.score = computeScore(d, in)
+	if err := m.cache.SetScore(ctx, userID, d.score, historyRetention(cfg)); err != nil {
+		m.logger.WithError(err).WithField("userID", userID).Warn("Ошибка сохранения оценки")
+	}
+	m.logger.WithFields(logrus.Fields{
+		"userID":   userID,
+		"username": d.user.Username,
+		"devices":  d.deviceCount,
+		"limit":    d.limit.limit,
+		"score":    d.score.Value,
+	}).Debug("Оценка нарушения")
+
+	switch {
+	case d.score.Value >= cfg.ScoreDisable:
+		scoreDecisionsTotal.WithLabelValues("disable").Inc()
+		m.handleHardViolation(ctx, d, st, d.limit.actionMode)
+	case d.score.Value >= cfg.ScoreAlert:
+		scoreDecisionsTotal.WithLabelValues("alert").Inc()
+		m.handleHardViolation(ctx, d, st, "manual")
+	case d.score.Value >= cfg.ScoreSoft:
+		scoreDecisionsTotal.WithLabelValues("soft").Inc()
+		m.handleSoftWarning(ctx, d, st)
+	}
+}
@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/geoip"
+)
+
+func scoreDetection(limit int, ips ...api.ActiveIP) *detection {
+	return &detection{ips: ips, deviceCount: len(ips), limit: limitDecision{limit: limit}}
+}
+
+func TestComputeScore_NoExcess(t *testing.T) {
+	d := scoreDetection(2,
+		api.ActiveIP{IP: "1.1.1.1", NodeUUID: "a", Country: "RU"},
+		api.ActiveIP{IP: "2.2.2.2", NodeUUID: "b", Country: "US"},
+	)
+	if s := computeScore(d, scoreInput{excessFor: time.Hour, window: time.Hour, prior: 5}); s.Value != 0 {
+		t.Errorf("без превышения Value = %d, want 0", s.Value)
+	}
+}
+
+func TestComputeScore_Signals(t *testing.T) {
+	// Семья: одна нода, одна страна, один провайдер, превышение только что.
+	family := scoreDetection(2,
+		api.ActiveIP{IP: "1.1.1.1", NodeUUID: "a", Country: "RU", ASN: 100},
+		api.ActiveIP{IP: "1.1.1.2", NodeUUID: "a", Country: "RU", ASN: 100},
+		api.ActiveIP{IP: "1.1.1.3", NodeUUID: "a", Country: "RU", ASN: 100},
+	)
+	s := computeScore(family, scoreInput{window: time.Hour})
+	if s.Groups != 18 || s.Value != 18 {
+		t.Errorf("семья: %+v, want groups=18 value=18", s)
+	}
+
+	// Шаринг: вдвое больше лимита, три ноды и страны, давно и не впервые.
+	shared := scoreDetection(2,
+		api.ActiveIP{IP: "1.1.1.1", NodeUUID: "a", Country: "RU", ASN: 100},
+		api.ActiveIP{IP: "2.2.2.2", NodeUUID: "b", Country: "US", ASN: 200},
+		api.ActiveIP{IP: "3.3.3.3", NodeUUID: "c", Country: "DE", ASN: 300},
+		api.ActiveIP{IP: "4.4.4.4", NodeUUID: "c", Country: "DE", ASN: 400, ASNClass: geoip.ClassHosting},
+	)
+	s = computeScore(shared, scoreInput{excessFor: 30 * time.Minute, window: time.Hour, prior: 1})
+	want := map[string][2]int{
+		"groups":      {s.Groups, 35},
+		"persistence": {s.Persistence, 10},
+		"nodes":       {s.Nodes, 10},
+		"countries":   {s.Countries, 15},
+		"asn":         {s.ASN, 10},
+		"history":     {s.History, 3},
+	}
+	for name, v := range want {
+		if v[0] != v[1] {
+			t.Errorf("%s = %d, want %d", name, v[0], v[1])
+		}
+	}
+	if s.Value != 83 {
+		t.Errorf("Value = %d, want 83", s.Value)
+	}
+
+	// Все сигналы на максимуме — не больше 100.
+	s = computeScore(shared, scoreInput{excessFor: 2 * time.Hour, window: time.Hour, prior: 10})
+	if s.Value != 100 {
+		t.Errorf("максимум: Value = %d, want 100", s.Value)
+	}
+}
@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/telegram"
+)
+
+const shadowDayFormat = "2006-01-02"
+
+// shadowDiffKinds сравнивает решения живого и shadow-конфига по одному
+// пользователю. shadow_disable — shadow отключил бы, а живой конфиг нет.
+func shadowDiffKinds(live, shadow *detection) []string {
+	liveHit, shadowHit := live.exceeded(), shadow.exceeded()
+
+	var kinds []string
+	switch {
+	case shadowHit && !liveHit:
+		kinds = append(kinds, cache.ShadowOnly)
+	case liveHit && !shadowHit:
+		kinds = append(kinds, cache.LiveOnly)
+	}
+	liveDisables := liveHit && live.limit.actionMode == "auto"
+	if shadowHit && shadow.limit.actionMode == "auto" && !liveDisables {
+		kinds = append(kinds, cache.ShadowDisable)
+	}
+	return kinds
+}
+
+// observeShadow прогоняет пользователя через shadow-конфиг и записывает
+// расхождения с живым решением. seen — все IP пользователя до фильтра
+// COUNTING_MODE: у shadow режим подсчёта может быть свой. Никаких действий
+// не выполняется.
+func (m *Monitor) observeShadow(ctx context.Context, shadowCfg *config.Config, live *detection, seen []api.ActiveIP, st *checkStats) {
+	ips := m.countedIPs(shadowCfg, live.user.UserID, seen)
+	shadow := newDetection(shadowCfg, live.user, ips, resolveLimit(shadowCfg, live.user))
+	if shadow.exceeded() {
+		st.shadowFlagged.Add(1)
+	}
+
+	day := time.Now().In(m.location).Format(shadowDayFormat)
+	for _, kind := range shadowDiffKinds(live, shadow) {
+		d := shadow
+		switch kind {
+		case cache.ShadowOnly:
+			st.shadowOnly.Add(1)
+		case cache.LiveOnly:
+			st.shadowLiveOnly.Add(1)
+			d = live
+		}
+
+		added, err := m.cache.AddShadowDiff(ctx, day, kind, d.user.UserID, d.user.Username)
+		if err != nil {
+			m.logger.WithError(err).WithField("userID", d.user.UserID).Warn("Ошибка записи расхождения shadow-режима")
+			continue
+		}
+		if added {
+			m.logger.WithFields(logrus.Fields{
+				"userID":        d.user.UserID,
+				"username":      d.user.Username,
+				"kind":          kind,
+				"liveDevices":   live.deviceCount,
+				"liveLimit":     live.limit.banThreshold(),
+				"shadowDevices": shadow.deviceCount,
+				"shadowLimit":   shadow.limit.banThreshold(),
+			}).Info("Shadow: решение отличается от живого конфига")
+		}
+	}
+}
+
+// sendShadowReport присылает в чат расхождения за прошедшие сутки.
+func (m *Monitor) sendShadowReport(ctx context.Context) {
+	cfg := m.cfg.Load()
+	day := time.Now().In(m.location).AddDate(0, 0, -1).Format(shadowDayFormat)
+
+	diff, err := m.cache.GetShadowDiff(ctx, day)
+	if err != nil {
+		m.logger.WithError(err).Error("Ошибка получения расхождений shadow-режима")
+		return
+	}
+
+	text := telegram.FormatShadowReport(day, cfg.ShadowOverrides, diff)
+	if err := m.bot.SendMessage(ctx, text); err != nil {
+		m.logger.WithError(err).Error("Ошибка отправки отчёта shadow-режима")
+	}
+}
@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+func TestNewDetection_GroupingFromConfig(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, HWIDDeviceLimit: 2}
+	ips := []api.ActiveIP{
+		{IP: "10.0.0.1", ASN: 100},
+		{IP: "10.0.0.2", ASN: 100},
+		{IP: "10.0.1.1", ASN: 200},
+	}
+	lim := limitDecision{limit: 2, actionMode: "manual"}
+
+	live := newDetection(&config.Config{}, user, ips, lim)
+	if live.deviceCount != 3 || !live.exceeded() {
+		t.Errorf("by IP: devices=%d exceeded=%v, want 3 true", live.deviceCount, live.exceeded())
+	}
+
+	subnet := newDetection(&config.Config{SubnetGrouping: true, SubnetPrefixV4: 24, SubnetPrefixV6: 64}, user, ips, lim)
+	if subnet.deviceCount != 2 || subnet.exceeded() {
+		t.Errorf("by subnet: devices=%d exceeded=%v, want 2 false", subnet.deviceCount, subnet.exceeded())
+	}
+}
+
+func TestShadowDiffKinds(t *testing.T) {
+	det := func(devices int, mode string) *detection {
+		return &detection{deviceCount: devices, limit: limitDecision{limit: 2, actionMode: mode}}
+	}
+
+	cases := []struct {
+		name         string
+		live, shadow *detection
+		want         []string
+	}{
+		{"both clean", det(1, "manual"), det(1, "auto"), nil},
+		{"both flagged, same mode", det(3, "manual"), det(3, "manual"), nil},
+		{"only shadow flags", det(2, "manual"), det(3, "manual"), []string{cache.ShadowOnly}},
+		{"only live flags", det(3, "manual"), det(2, "manual"), []string{cache.LiveOnly}},
+		{"shadow would disable", det(3, "manual"), det(3, "auto"), []string{cache.ShadowDisable}},
+		{"shadow flags and disables", det(1, "manual"), det(3, "auto"), []string{cache.ShadowOnly, cache.ShadowDisable}},
+		{"live already disables", det(3, "auto"), det(3, "auto"), nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := shadowDiffKinds(c.live, c.shadow); !slices.Equal(got, c.want) {
+				t.Errorf("shadowDiffKinds = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,7 @@ package monitor
 
 import "net"
 
-func subnetPrefix(ipStr string, v4 int) string {
+func subnetPrefix(ipStr string, v4, v6 int) string {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
 		return ipStr
@@ -12,7 +12,8 @@ func subnetPrefix(ipStr string, v4 int) string {
 		network := ip4.Mask(mask)
 		return fmtCIDR(network, v4)
 	}
-	return ipStr
+	mask := net.CIDRMask(v6, 128)
+	return fmtCIDR(ip.Mask(mask), v6)
 }
 
 func fmtCIDR(network net.IP, prefix int) string {
@@ -7,21 +7,24 @@ func TestSubnetPrefix(t *testing.T) {
 		name string
 		ip   string
 		v4   int
+		v6   int
 		want string
 	}{
-		{"v4_default_24", "176.59.35.168", 24, "176.59.35.0/24"},
-		{"v4_16", "176.59.35.168", 16, "176.59.0.0/16"},
-		{"v4_20", "176.59.45.158", 20, "176.59.32.0/20"},
-		{"v4_32_exact", "1.2.3.4", 32, "1.2.3.4/32"},
-		{"v6_returned_as_is", "2001:db8:abcd:1234::1", 24, "2001:db8:abcd:1234::1"},
-		{"v6_loopback_as_is", "::1", 24, "::1"},
-		{"invalid", "not-an-ip", 24, "not-an-ip"},
+		{"v4_default_24", "176.59.35.168", 24, 64, "176.59.35.0/24"},
+		{"v4_16", "176.59.35.168", 16, 64, "176.59.0.0/16"},
+		{"v4_20", "176.59.45.158", 20, 64, "176.59.32.0/20"},
+		{"v4_32_exact", "1.2.3.4", 32, 64, "1.2.3.4/32"},
+		{"v4_mapped_v6_uses_v4", "::ffff:176.59.35.168", 24, 64, "176.59.35.0/24"},
+		{"v6_64", "2001:db8:abcd:1234:a1b2:c3d4:e5f6:1", 24, 64, "2001:db8:abcd:1234::/64"},
+		{"v6_56", "2001:db8:abcd:12ff::1", 24, 56, "2001:db8:abcd:1200::/56"},
+		{"v6_128_exact", "::1", 24, 128, "::1/128"},
+		{"invalid", "not-an-ip", 24, 64, "not-an-ip"},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := subnetPrefix(tc.ip, tc.v4)
+			got := subnetPrefix(tc.ip, tc.v4, tc.v6)
 			if got != tc.want {
-				t.Errorf("subnetPrefix(%q, %d) = %q, want %q", tc.ip, tc.v4, got, tc.want)
+				t.Errorf("subnetPrefix(%q, %d, %d) = %q, want %q", tc.ip, tc.v4, tc.v6, got, tc.want)
 			}
 		})
 	}
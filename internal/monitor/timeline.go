@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+// ipTimeline — сколько проверок подряд IP пользователя был жив (COUNTING_MODE=concurrent).
+type ipTimeline struct {
+	mu    sync.Mutex
+	users map[int64]map[string]*ipPresence
+}
+
+type ipPresence struct {
+	lastSeen time.Time
+	streak   int
+	// fresh — IP пришёл с ноды, опрошенной в этой проверке.
+	fresh bool
+}
+
+func newIPTimeline() *ipTimeline {
+	return &ipTimeline{users: make(map[int64]map[string]*ipPresence)}
+}
+
+// observe обновляет историю по результату проверки. Пользователи и IP, которых
+// нет в aggregated, забываются.
+func (t *ipTimeline) observe(aggregated map[int64][]api.ActiveIP) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	users := make(map[int64]map[string]*ipPresence, len(aggregated))
+	for userID, ips := range aggregated {
+		prev := t.users[userID]
+		cur := make(map[string]*ipPresence, len(ips))
+		for _, ip := range ips {
+			if p, ok := cur[ip.IP]; ok {
+				if ip.LastSeen.After(p.lastSeen) {
+					p.lastSeen = ip.LastSeen
+				}
+				p.fresh = p.fresh || !ip.Cached
+				continue
+			}
+			cur[ip.IP] = &ipPresence{lastSeen: ip.LastSeen, fresh: !ip.Cached}
+		}
+		for addr, p := range cur {
+			old, ok := prev[addr]
+			switch {
+			case !ok:
+				p.streak = 1
+			case p.lastSeen.IsZero() || p.lastSeen.After(old.lastSeen):
+				p.streak = old.streak + 1
+			case !p.fresh:
+				p.streak = old.streak
+			default:
+				p.streak = 0
+			}
+		}
+		users[userID] = cur
+	}
+	t.users = users
+}
+
+// reset забывает историю — режим concurrent выключен.
+func (t *ipTimeline) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.users) > 0 {
+		t.users = make(map[int64]map[string]*ipPresence)
+	}
+}
+
+// concurrent оставляет IP, живые не меньше minChecks проверок подряд.
+func (t *ipTimeline) concurrent(userID int64, ips []api.ActiveIP, minChecks int) []api.ActiveIP {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	presence := t.users[userID]
+	out := make([]api.ActiveIP, 0, len(ips))
+	for _, ip := range ips {
+		if p, ok := presence[ip.IP]; ok && p.streak >= minChecks {
+			out = append(out, ip)
+		}
+	}
+	return out
+}
+
+// countingConcurrent — нужен ли timeline живому или shadow-конфигу.
+func countingConcurrent(cfg *config.Config) bool {
+	return cfg.CountingMode == config.CountingConcurrent ||
+		(cfg.Shadow != nil && cfg.Shadow.CountingMode == config.CountingConcurrent)
+}
+
+// countedIPs — IP, которые идут в подсчёт устройств по режиму из cfg.
+func (m *Monitor) countedIPs(cfg *config.Config, userID int64, ips []api.ActiveIP) []api.ActiveIP {
+	if cfg.CountingMode != config.CountingConcurrent {
+		return ips
+	}
+	return m.timeline.concurrent(userID, ips, cfg.ConcurrentMinChecks)
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/remnawave/limiter/internal/api"
+)
+
+func concurrentAddrs(t *ipTimeline, userID int64, ips []api.ActiveIP, minChecks int) []string {
+	var out []string
+	for _, ip := range t.concurrent(userID, ips, minChecks) {
+		out = append(out, ip.IP)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestIPTimeline_WiFiToLTE(t *testing.T) {
+	tl := newIPTimeline()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	wifi, lte := "10.0.0.1", "176.59.35.168"
+
+	// Проверка 1: пользователь на Wi-Fi.
+	first := []api.ActiveIP{{IP: wifi, LastSeen: base}}
+	tl.observe(map[int64][]api.ActiveIP{1: first})
+	if got := concurrentAddrs(tl, 1, first, 2); got != nil {
+		t.Errorf("check 1: concurrent = %v, want none", got)
+	}
+
+	// Проверка 2: ушёл на LTE — Wi-Fi ещё в окне, но LastSeen застыл.
+	second := []api.ActiveIP{
+		{IP: wifi, LastSeen: base},
+		{IP: lte, LastSeen: base.Add(time.Minute)},
+	}
+	tl.observe(map[int64][]api.ActiveIP{1: second})
+	if got := concurrentAddrs(tl, 1, second, 2); got != nil {
+		t.Errorf("check 2: concurrent = %v, want none", got)
+	}
+
+	// Проверка 3: жив только LTE — одно устройство, а не два.
+	third := []api.ActiveIP{
+		{IP: wifi, LastSeen: base},
+		{IP: lte, LastSeen: base.Add(2 * time.Minute)},
+	}
+	tl.observe(map[int64][]api.ActiveIP{1: third})
+	if got, want := concurrentAddrs(tl, 1, third, 2), []string{lte}; !reflect.DeepEqual(got, want) {
+		t.Errorf("check 3: concurrent = %v, want %v", got, want)
+	}
+}
+
+func TestIPTimeline_SharedAccount(t *testing.T) {
+	tl := newIPTimeline()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var ips, unique []api.ActiveIP
+	for i := 0; i < 3; i++ {
+		// Два IP живы одновременно; повтор одного IP с другой ноды схлопывается
+		// по самому свежему LastSeen.
+		at := base.Add(time.Duration(i) * time.Minute)
+		ips = []api.ActiveIP{
+			{IP: "1.1.1.1", LastSeen: at, NodeName: "de"},
+			{IP: "1.1.1.1", LastSeen: at.Add(-time.Hour), NodeName: "nl"},
+			{IP: "2.2.2.2", LastSeen: at},
+		}
+		tl.observe(map[int64][]api.ActiveIP{7: ips})
+		unique = []api.ActiveIP{ips[0], ips[2]}
+	}
+	if got, want := concurrentAddrs(tl, 7, unique, 3), []string{"1.1.1.1", "2.2.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("concurrent = %v, want %v", got, want)
+	}
+	if got := concurrentAddrs(tl, 7, unique, 4); got != nil {
+		t.Errorf("minChecks=4: concurrent = %v, want none", got)
+	}
+
+	// Пользователь пропал из проверки — история забыта.
+	tl.observe(map[int64][]api.ActiveIP{})
+	tl.observe(map[int64][]api.ActiveIP{7: ips})
+	if got := concurrentAddrs(tl, 7, unique, 2); got != nil {
+		t.Errorf("after gap: concurrent = %v, want none", got)
+	}
+}
+
+func TestIPTimeline_CachedNodeKeepsStreak(t *testing.T) {
+	tl := newIPTimeline()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ip := []api.ActiveIP{{IP: "1.1.1.1", LastSeen: base}}
+
+	tl.observe(map[int64][]api.ActiveIP{1: ip})
+	tl.observe(map[int64][]api.ActiveIP{1: {{IP: "1.1.1.1", LastSeen: base.Add(time.Minute)}}})
+
+	// Нода опрашивается реже (NODE_INTERVALS): тот же LastSeen из прошлого
+	// результата не обнуляет streak.
+	cached := []api.ActiveIP{{IP: "1.1.1.1", LastSeen: base.Add(time.Minute), Cached: true}}
+	tl.observe(map[int64][]api.ActiveIP{1: cached})
+	if got := concurrentAddrs(tl, 1, cached, 2); len(got) != 1 {
+		t.Errorf("cached: concurrent = %v, want [1.1.1.1]", got)
+	}
+
+	// Свежий опрос без движения LastSeen — IP брошен.
+	fresh := []api.ActiveIP{{IP: "1.1.1.1", LastSeen: base.Add(time.Minute)}}
+	tl.observe(map[int64][]api.ActiveIP{1: fresh})
+	if got := concurrentAddrs(tl, 1, fresh, 1); got != nil {
+		t.Errorf("fresh: concurrent = %v, want none", got)
+	}
+}
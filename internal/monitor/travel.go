@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"context"
+	"math"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/geoip"
+	"github.com/remnawave/limiter/internal/telegram"
+	"github.com/remnawave/limiter/internal/webhook"
+)
+
+const earthRadiusKm = 6371.0
+
+// SetLocationResolver подключает базу GeoLite2-City/Country.
+func (m *Monitor) SetLocationResolver(r geoip.LocationResolver) {
+	m.locator = r
+}
+
+// travelMatch — самая далёкая пара IP из разных стран.
+type travelMatch struct {
+	from, to   api.ActiveIP
+	distanceKm int
+}
+
+// findTravel ищет пару IP из разных стран не ближе minKm. IP без страны или
+// координат пропускаются.
+func findTravel(ips []api.ActiveIP, minKm int) *travelMatch {
+	var best *travelMatch
+	for i := range ips {
+		for j := i + 1; j < len(ips); j++ {
+			a, b := ips[i], ips[j]
+			if !a.HasCoords || !b.HasCoords || a.Country == "" || b.Country == "" || a.Country == b.Country {
+				continue
+			}
+			km := int(math.Round(haversineKm(a, b)))
+			if km < minKm {
+				continue
+			}
+			if best == nil || km > best.distanceKm {
+				best = &travelMatch{from: a, to: b, distanceKm: km}
+			}
+		}
+	}
+	return best
+}
+
+func haversineKm(a, b api.ActiveIP) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(min(h, 1)))
+}
+
+// checkTravel сообщает о невозможном перемещении (см. raiseAlert).
+func (m *Monitor) checkTravel(ctx context.Context, d *detection) {
+	if m.locator == nil || len(d.ips) < 2 {
+		return
+	}
+	cfg := m.cfg.Load()
+
+	match := findTravel(d.ips, cfg.ImpossibleTravelKm)
+	if match == nil {
+		return
+	}
+
+	if !m.alertAllowed(ctx, cache.AlertTravel, d.user.UserID) {
+		return
+	}
+
+	travelViolationsTotal.Inc()
+	m.logger.WithFields(logrus.Fields{
+		"userID":     d.user.UserID,
+		"username":   d.user.Username,
+		"from":       match.from.IP + " " + match.from.Country,
+		"to":         match.to.IP + " " + match.to.Country,
+		"distanceKm": match.distanceKm,
+	}).Warn("Невозможное перемещение: IP пользователя активны в далёких странах")
+
+	text := telegram.FormatTravelAlert(d.user, d.ips, telegram.TravelInfo{
+		FromIP:      match.from.IP,
+		FromCountry: match.from.Country,
+		ToIP:        match.to.IP,
+		ToCountry:   match.to.Country,
+		DistanceKm:  match.distanceKm,
+	}, m.location)
+	m.raiseAlert(ctx, d, cache.ReasonImpossibleTravel, text, func(v *webhook.ViolationPayload) {
+		v.Travel = &webhook.TravelPayload{
+			FromIP:      match.from.IP,
+			FromCountry: match.from.Country,
+			ToIP:        match.to.IP,
+			ToCountry:   match.to.Country,
+			DistanceKm:  match.distanceKm,
+			MinKm:       cfg.ImpossibleTravelKm,
+		}
+	})
+}
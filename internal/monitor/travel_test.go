@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/geoip"
+)
+
+func cityIP(ip, country string, lat, lon float64) api.ActiveIP {
+	return api.ActiveIP{IP: ip, Country: country, Latitude: lat, Longitude: lon, HasCoords: true}
+}
+
+func TestHaversineKm(t *testing.T) {
+	// Москва — Нью-Йорк ≈ 7510 км.
+	got := haversineKm(
+		api.ActiveIP{Latitude: 55.7558, Longitude: 37.6173},
+		api.ActiveIP{Latitude: 40.7128, Longitude: -74.0060},
+	)
+	if got < 7450 || got > 7570 {
+		t.Errorf("haversineKm = %.0f, want ≈7510", got)
+	}
+}
+
+func TestFindTravel(t *testing.T) {
+	moscow := cityIP("1.1.1.1", "RU", 55.7558, 37.6173)
+	moscow2 := cityIP("1.1.1.2", "RU", 55.75, 37.62)
+	newYork := cityIP("2.2.2.2", "US", 40.7128, -74.0060)
+	// Нарва и Ивангород — разные страны через реку.
+	narva := cityIP("3.3.3.3", "EE", 59.3772, 28.1903)
+	ivangorod := cityIP("4.4.4.4", "RU", 59.3667, 28.2167)
+
+	if m := findTravel([]api.ActiveIP{moscow, moscow2}, 1000); m != nil {
+		t.Errorf("одна страна: match = %+v, want nil", m)
+	}
+	if m := findTravel([]api.ActiveIP{narva, ivangorod}, 1000); m != nil {
+		t.Errorf("соседние страны ближе порога: match = %+v, want nil", m)
+	}
+
+	m := findTravel([]api.ActiveIP{moscow, narva, newYork}, 1000)
+	if m == nil {
+		t.Fatal("match = nil, want RU/EE → US")
+	}
+	if m.to.IP != "2.2.2.2" || m.from.IP != "1.1.1.1" {
+		t.Errorf("выбрана пара %s → %s, want самая далёкая 1.1.1.1 → 2.2.2.2", m.from.IP, m.to.IP)
+	}
+	if m.distanceKm < 7000 {
+		t.Errorf("distanceKm = %d, want > 7000", m.distanceKm)
+	}
+}
+
+// Без координат (GeoLite2-Country или IP без location в City) расстояние не
+// проверить — разные страны сами по себе не нарушение. Страна ноды не
+// подставляется: координат у неё всё равно нет.
+func TestFindTravel_NoCoordsSkipped(t *testing.T) {
+	berlin := cityIP("1.1.1.1", "DE", 52.52, 13.405)
+	countryOnly := api.ActiveIP{IP: "3.3.3.3", Country: "BR"}
+	nodeOnly := api.ActiveIP{IP: "4.4.4.4", NodeCountry: "US"}
+	if m := findTravel([]api.ActiveIP{berlin, countryOnly, nodeOnly}, 1000); m != nil {
+		t.Errorf("match = %+v, want nil", m)
+	}
+}
+
+type fakeLocator map[string]geoip.Location
+
+func (f fakeLocator) Locate(ip string) (geoip.Location, bool) {
+	loc, ok := f[ip]
+	return loc, ok
+}
+
+// Координаты берутся из uniqueIPs — checkTravel не обращается к базе повторно.
+func TestUniqueIPs_KeepsCoordinatesForTravel(t *testing.T) {
+	m := &Monitor{locator: fakeLocator{
+		"1.1.1.1": {Country: "DE", Latitude: 52.52, Longitude: 13.405, HasCoords: true},
+		"2.2.2.2": {Country: "US", Latitude: 40.7128, Longitude: -74.0060, HasCoords: true},
+	}}
+	ips := m.uniqueIPs([]api.ActiveIP{{IP: "1.1.1.1"}, {IP: "2.2.2.2"}})
+	if m := findTravel(ips, 1000); m == nil || m.distanceKm < 6000 {
+		t.Errorf("match = %+v, want DE ↔ US", m)
+	}
+}
@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/telegram"
+)
+
+// UserCard собирает ответ на /user: данные берутся из панели напрямую (а не из
+// кэша), IP — из последней проверки.
+func (m *Monitor) UserCard(ctx context.Context, query string) (*telegram.UserCard, error) {
+	user, err := m.lookupUser(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := m.cfg.Load()
+	lim := resolveLimit(cfg, user)
+
+	m.snapshotMu.RLock()
+	rawIPs := m.snapshot[user.UserID]
+	snapshotAt := m.snapshotAt
+	m.snapshotMu.RUnlock()
+
+	state, err := m.cache.GetUserState(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := m.cache.GetViolationCount(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := m.History(ctx, user.UserID)
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", user.UserID).Warn("Ошибка чтения истории нарушений")
+	}
+	score, err := m.cache.GetScore(ctx, user.UserID)
+	if err != nil {
+		m.logger.WithError(err).WithField("userID", user.UserID).Warn("Ошибка чтения оценки")
+	}
+
+	text := telegram.FormatUserStatus(&telegram.UserStatus{
+		User:          user,
+		IPs:           m.uniqueIPs(rawIPs),
+		SnapshotAt:    snapshotAt,
+		Limit:         lim.limit,
+		BanThreshold:  lim.banThreshold(),
+		LimitSource:   lim.source,
+		State:         state,
+		Violations24h: violations,
+		History:       history,
+		Score:         score,
+	}, m.location)
+
+	return &telegram.UserCard{
+		Text:            text,
+		UserID:          user.UserID,
+		DisableDuration: cfg.AutoDisableDuration,
+		IgnoreDuration:  cfg.IgnoreDuration,
+	}, nil
+}
+
+// lookupUser ищет пользователя по числовому ID, а если запрос не число —
+// по username (ведущий @ отбрасывается).
+func (m *Monitor) lookupUser(ctx context.Context, query string) (*api.CachedUser, error) {
+	var (
+		data *api.UserData
+		err  error
+	)
+	if id, convErr := strconv.ParseInt(query, 10, 64); convErr == nil {
+		data, err = m.api.GetUserByID(ctx, id)
+	} else {
+		data, err = m.api.GetUserByUsername(ctx, strings.TrimPrefix(query, "@"))
+	}
+	if errors.Is(err, api.ErrNotFound) {
+		return nil, telegram.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cachedUserFromData(data.ID, data), nil
+}
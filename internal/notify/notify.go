@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+const (
+	EventSoft      = "soft"
+	EventViolation = "violation"
+	EventDisabled  = "disabled"
+	EventRestored  = "restored"
+
+	replyStart = "start"
+	replyStop  = "stop"
+)
+
+var templateNames = []string{EventSoft, EventViolation, EventDisabled, EventRestored, replyStart, replyStop}
+
+type Sender interface {
+	SendDirect(ctx context.Context, chatID int64, text string) error
+}
+
+type Store interface {
+	SetNotifyOptOut(ctx context.Context, telegramID int64, optOut bool) error
+	IsNotifyOptedOut(ctx context.Context, telegramID int64) (bool, error)
+	TryNotifySlot(ctx context.Context, userID int64, interval time.Duration) (bool, error)
+}
+
+// Data — поля, доступные в шаблонах.
+type Data struct {
+	Username  string
+	Devices   int
+	Limit     int
+	Until     string
+	Permanent bool
+	Dropped   bool
+}
+
+// Notifier шлёт подписчикам личные уведомления по их TelegramID из панели.
+type Notifier struct {
+	sender    Sender
+	store     Store
+	cfg       *config.Provider
+	logger    *logrus.Logger
+	location  *time.Location
+	templates map[string]map[string]*template.Template
+}
+
+func New(sender Sender, store Store, cfg *config.Provider, loc *time.Location, logger *logrus.Logger) (*Notifier, error) {
+	templates, err := loadTemplates(cfg.Load().UserNotifyTemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Notifier{
+		sender:    sender,
+		store:     store,
+		cfg:       cfg,
+		logger:    logger,
+		location:  loc,
+		templates: templates,
+	}, nil
+}
+
+func loadTemplates(dir string) (map[string]map[string]*template.Template, error) {
+	out := make(map[string]map[string]*template.Template, len(defaultTemplates))
+	for lang, builtin := range defaultTemplates {
+		out[lang] = make(map[string]*template.Template, len(templateNames))
+		for _, name := range templateNames {
+			text := builtin[name]
+			if dir != "" {
+				override, err := readOverride(dir, name, lang)
+				if err != nil {
+					return nil, err
+				}
+				if override != "" {
+					text = override
+				}
+			}
+			t, err := template.New(name).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("шаблон уведомления %s (%s): %w", name, lang, err)
+			}
+			out[lang][name] = t
+		}
+	}
+	return out, nil
+}
+
+// readOverride ищет сначала <name>.<lang>.tmpl, затем <name>.tmpl.
+func readOverride(dir, name, lang string) (string, error) {
+	for _, file := range []string{name + "." + lang + ".tmpl", name + ".tmpl"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("USER_NOTIFY_TEMPLATES_DIR: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", nil
+}
+
+func (n *Notifier) render(name string, data *Data) (string, error) {
+	lang := n.cfg.Load().UserNotifyLanguage
+	t, ok := n.templates[lang][name]
+	if !ok {
+		t = n.templates["ru"][name]
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("шаблон уведомления %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// Notify отправляет уведомление подписчику, если USER_NOTIFY включён, у него
+// есть TelegramID, он не отписался и не получал уведомлений за
+// USER_NOTIFY_INTERVAL. Отключение и восстановление доступа шлются без
+// ограничения частоты: их не должно вытеснять недавнее предупреждение.
+func (n *Notifier) Notify(ctx context.Context, event string, user *api.CachedUser, data Data) {
+	cfg := n.cfg.Load()
+	if !cfg.UserNotify || user.TelegramID == 0 {
+		return
+	}
+	log := n.logger.WithFields(logrus.Fields{
+		"userID": user.UserID,
+		"event":  event,
+	})
+
+	optedOut, err := n.store.IsNotifyOptedOut(ctx, user.TelegramID)
+	if err != nil {
+		log.WithError(err).Warn("Ошибка проверки отписки от уведомлений")
+		return
+	}
+	if optedOut {
+		return
+	}
+
+	if event != EventDisabled && event != EventRestored {
+		interval := time.Duration(cfg.UserNotifyInterval) * time.Minute
+		ok, err := n.store.TryNotifySlot(ctx, user.UserID, interval)
+		if err != nil {
+			log.WithError(err).Warn("Ошибка проверки частоты уведомлений")
+			return
+		}
+		if !ok {
+			log.Debug("Уведомление пользователю пропущено: недавно уже отправлялось")
+			return
+		}
+	}
+
+	data.Username = html.EscapeString(user.Username)
+	text, err := n.render(event, &data)
+	if err != nil {
+		log.WithError(err).Error("Ошибка подготовки уведомления пользователю")
+		return
+	}
+	if err := n.sender.SendDirect(ctx, user.TelegramID, text); err != nil {
+		log.WithError(err).Warn("Не удалось отправить уведомление пользователю")
+		return
+	}
+	log.Debug("Уведомление пользователю отправлено")
+}
+
+// FormatUntil — момент возврата доступа в часовом поясе TIMEZONE.
+func (n *Notifier) FormatUntil(durationMin int) string {
+	return time.Now().Add(time.Duration(durationMin) * time.Minute).In(n.location).Format("02.01.2006 15:04")
+}
+
+// HandleSubscription — обработчик /start и /stop от подписчика.
+func (n *Notifier) HandleSubscription(ctx context.Context, telegramID int64, optOut bool) (string, error) {
+	// USER_NOTIFY меняется на лету, поэтому обработчик зарегистрирован всегда.
+	if !n.cfg.Load().UserNotify {
+		return "", nil
+	}
+	if err := n.store.SetNotifyOptOut(ctx, telegramID, optOut); err != nil {
+		return "", err
+	}
+	n.logger.WithFields(logrus.Fields{
+		"telegramID": telegramID,
+		"optOut":     optOut,
+	}).Info("Подписчик изменил настройку уведомлений")
+
+	name := replyStart
+	if optOut {
+		name = replyStop
+	}
+	return n.render(name, &Data{})
+}
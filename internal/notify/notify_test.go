@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+type sent struct {
+	chatID int64
+	text   string
+}
+
+type fakeSender struct{ sent []sent }
+
+func (f *fakeSender) SendDirect(_ context.Context, chatID int64, text string) error {
+	f.sent = append(f.sent, sent{chatID, text})
+	return nil
+}
+
+type fakeStore struct {
+	optOut map[int64]bool
+	slots  map[int64]bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{optOut: map[int64]bool{}, slots: map[int64]bool{}}
+}
+
+func (f *fakeStore) SetNotifyOptOut(_ context.Context, id int64, optOut bool) error {
+	f.optOut[id] = optOut
+	return nil
+}
+
+func (f *fakeStore) IsNotifyOptedOut(_ context.Context, id int64) (bool, error) {
+	return f.optOut[id], nil
+}
+
+func (f *fakeStore) TryNotifySlot(_ context.Context, userID int64, _ time.Duration) (bool, error) {
+	if f.slots[userID] {
+		return false, nil
+	}
+	f.slots[userID] = true
+	return true, nil
+}
+
+func newTestNotifier(t *testing.T, cfg *config.Config) (*Notifier, *fakeSender, *fakeStore) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	sender, store := &fakeSender{}, newFakeStore()
+	n, err := New(sender, store, config.NewProvider(cfg), time.UTC, logger)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return n, sender, store
+}
+
+func TestNotify_SendsLocalizedAndEscaped(t *testing.T) {
+	n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyInterval: 60, UserNotifyLanguage: "en"})
+	user := &api.CachedUser{UserID: 1, Username: "<bob>", TelegramID: 777}
+
+	n.Notify(context.Background(), EventDisabled, user, Data{Devices: 4, Limit: 2, Until: "02.01.2026 10:00"})
+
+	if len(sender.sent) != 1 || sender.sent[0].chatID != 777 {
+		t.Fatalf("sent = %+v, want one message to 777", sender.sent)
+	}
+	text := sender.sent[0].text
+	for _, want := range []string{"Subscription suspended", "&lt;bob&gt;", "4 devices", "limit of 2", "02.01.2026 10:00"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestNotify_Skips(t *testing.T) {
+	ctx := context.Background()
+	withTG := &api.CachedUser{UserID: 1, Username: "bob", TelegramID: 777}
+
+	t.Run("disabled", func(t *testing.T) {
+		n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: false, UserNotifyInterval: 60, UserNotifyLanguage: "ru"})
+		n.Notify(ctx, EventSoft, withTG, Data{})
+		if len(sender.sent) != 0 {
+			t.Errorf("sent %d messages with USER_NOTIFY=false", len(sender.sent))
+		}
+	})
+
+	t.Run("no telegram id", func(t *testing.T) {
+		n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyInterval: 60, UserNotifyLanguage: "ru"})
+		n.Notify(ctx, EventSoft, &api.CachedUser{UserID: 2}, Data{})
+		if len(sender.sent) != 0 {
+			t.Errorf("sent %d messages without TelegramID", len(sender.sent))
+		}
+	})
+
+	t.Run("opted out", func(t *testing.T) {
+		n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyInterval: 60, UserNotifyLanguage: "ru"})
+		if _, err := n.HandleSubscription(ctx, 777, true); err != nil {
+			t.Fatalf("HandleSubscription error: %v", err)
+		}
+		n.Notify(ctx, EventRestored, withTG, Data{})
+		if len(sender.sent) != 0 {
+			t.Errorf("sent %d messages after /stop", len(sender.sent))
+		}
+	})
+
+	t.Run("rate limited, restore still sent", func(t *testing.T) {
+		n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyInterval: 60, UserNotifyLanguage: "ru"})
+		n.Notify(ctx, EventSoft, withTG, Data{})
+		n.Notify(ctx, EventViolation, withTG, Data{})
+		n.Notify(ctx, EventRestored, withTG, Data{})
+		if len(sender.sent) != 2 || !strings.Contains(sender.sent[1].text, "снова активна") {
+			t.Errorf("sent = %+v, want soft + restored", sender.sent)
+		}
+	})
+
+	t.Run("rate limited, disable still sent", func(t *testing.T) {
+		n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyInterval: 60, UserNotifyLanguage: "en"})
+		n.Notify(ctx, EventSoft, withTG, Data{})
+		n.Notify(ctx, EventDisabled, withTG, Data{Until: "02.01.2026 10:00"})
+		if len(sender.sent) != 2 || !strings.Contains(sender.sent[1].text, "02.01.2026 10:00") {
+			t.Errorf("sent = %+v, want soft + disabled", sender.sent)
+		}
+	})
+}
+
+func TestHandleSubscription_Replies(t *testing.T) {
+	n, _, store := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyLanguage: "ru"})
+
+	reply, err := n.HandleSubscription(context.Background(), 5, true)
+	if err != nil || !strings.Contains(reply, "/start") || !store.optOut[5] {
+		t.Errorf("stop: reply=%q err=%v optOut=%v", reply, err, store.optOut[5])
+	}
+	reply, err = n.HandleSubscription(context.Background(), 5, false)
+	if err != nil || !strings.Contains(reply, "/stop") || store.optOut[5] {
+		t.Errorf("start: reply=%q err=%v optOut=%v", reply, err, store.optOut[5])
+	}
+}
+
+func TestHandleSubscription_Disabled(t *testing.T) {
+	n, _, store := newTestNotifier(t, &config.Config{UserNotifyLanguage: "ru"})
+
+	// USER_NOTIFY=false: бот молчит и opt-out не пишется.
+	reply, err := n.HandleSubscription(context.Background(), 5, true)
+	if err != nil || reply != "" || store.optOut[5] {
+		t.Errorf("reply=%q err=%v optOut=%v, want silent no-op", reply, err, store.optOut[5])
+	}
+}
+
+func TestLoadTemplates_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	// общий шаблон для всех языков и отдельный — только для en
+	if err := os.WriteFile(filepath.Join(dir, "soft.tmpl"), []byte("custom {{.Devices}}/{{.Limit}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "restored.en.tmpl"), []byte("welcome back"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, sender, _ := newTestNotifier(t, &config.Config{UserNotify: true, UserNotifyInterval: 60, UserNotifyLanguage: "ru", UserNotifyTemplatesDir: dir})
+	user := &api.CachedUser{UserID: 1, TelegramID: 9}
+	n.Notify(context.Background(), EventSoft, user, Data{Devices: 3, Limit: 2})
+	n.Notify(context.Background(), EventRestored, user, Data{})
+
+	if len(sender.sent) != 2 || sender.sent[0].text != "custom 3/2" {
+		t.Fatalf("sent = %+v", sender.sent)
+	}
+	if sender.sent[1].text == "welcome back" {
+		t.Error("en override must not apply to ru")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "violation.tmpl"), []byte("{{.Broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTemplates(dir); err == nil {
+		t.Error("expected parse error for broken template")
+	}
+}
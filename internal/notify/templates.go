@@ -0,0 +1,44 @@
+package notify
+
+// Встроенные шаблоны. Переопределяются файлами <событие>.tmpl или
+// <событие>.<язык>.tmpl в USER_NOTIFY_TEMPLATES_DIR.
+var defaultTemplates = map[string]map[string]string{
+	"ru": {
+		EventSoft: `⚠️ <b>Много устройств на подписке</b>
+
+Сейчас к подписке <code>{{.Username}}</code> подключено {{.Devices}} устр. при лимите {{.Limit}}.
+Пока это в пределах допуска, но при дальнейшем превышении доступ может быть ограничен.`,
+		EventViolation: `⚠️ <b>Превышен лимит устройств</b>
+
+К подписке <code>{{.Username}}</code> подключено {{.Devices}} устр. при лимите {{.Limit}}.
+{{if .Dropped}}Текущие подключения сброшены. {{end}}Отключите лишние устройства, иначе доступ будет ограничен.`,
+		EventDisabled: `🔒 <b>Подписка приостановлена</b>
+
+К подписке <code>{{.Username}}</code> подключено {{.Devices}} устр. при лимите {{.Limit}}.
+{{if .Permanent}}Доступ отключён до решения администратора.{{else}}Доступ вернётся автоматически {{.Until}}.{{end}}`,
+		EventRestored: `✅ <b>Подписка снова активна</b>
+
+Доступ к подписке <code>{{.Username}}</code> восстановлен. Пожалуйста, используйте не больше {{.Limit}} устр.`,
+		replyStart: `🔔 Уведомления о подписке включены. Отправьте /stop, чтобы отписаться.`,
+		replyStop:  `🔕 Уведомления о подписке отключены. Отправьте /start, чтобы включить снова.`,
+	},
+	"en": {
+		EventSoft: `⚠️ <b>Many devices on your subscription</b>
+
+{{.Devices}} devices are connected to <code>{{.Username}}</code> with a limit of {{.Limit}}.
+This is still within tolerance, but further excess may restrict access.`,
+		EventViolation: `⚠️ <b>Device limit exceeded</b>
+
+{{.Devices}} devices are connected to <code>{{.Username}}</code> with a limit of {{.Limit}}.
+{{if .Dropped}}Current connections were dropped. {{end}}Please disconnect extra devices, otherwise access will be restricted.`,
+		EventDisabled: `🔒 <b>Subscription suspended</b>
+
+{{.Devices}} devices are connected to <code>{{.Username}}</code> with a limit of {{.Limit}}.
+{{if .Permanent}}Access is disabled until an administrator reviews it.{{else}}Access will return automatically at {{.Until}}.{{end}}`,
+		EventRestored: `✅ <b>Subscription active again</b>
+
+Access to <code>{{.Username}}</code> is restored. Please use no more than {{.Limit}} devices.`,
+		replyStart: `🔔 Subscription notifications are on. Send /stop to unsubscribe.`,
+		replyStop:  `🔕 Subscription notifications are off. Send /start to turn them back on.`,
+	},
+}
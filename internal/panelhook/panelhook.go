@@ -0,0 +1,156 @@
+// Package panelhook принимает webhook'и панели Remnawave о событиях
+// пользователей, чтобы limiter не ждал следующего опроса.
+package panelhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	Path = "/webhooks/remnawave"
+
+	signatureHeader = "X-Remnawave-Signature"
+
+	maxBody = 1 << 20
+
+	handleTimeout = 15 * time.Second
+)
+
+// События панели, на которые limiter реагирует особо; остальные события
+// пользователя только сбрасывают кэш.
+const (
+	EventUserDeleted = "user.deleted"
+	EventUserEnabled = "user.enabled"
+)
+
+var received = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "limiter_panel_webhooks_total",
+	Help: "Входящие webhook панели: ok, ignored, invalid_signature, bad_request, failed.",
+}, []string{"result"})
+
+// Event — событие панели, относящееся к пользователю.
+type Event struct {
+	Name     string
+	UserID   int64
+	Username string
+}
+
+type Handler func(ctx context.Context, ev Event) error
+
+type Receiver struct {
+	secret  string
+	handler Handler
+	logger  *logrus.Logger
+}
+
+func New(secret string, handler Handler, logger *logrus.Logger) *Receiver {
+	return &Receiver{secret: secret, handler: handler, logger: logger}
+}
+
+func (r *Receiver) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+Path, r.handle)
+}
+
+type userData struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// envelope — тело webhook Remnawave. У событий user.* в data сам
+// пользователь, у user_hwid_devices.* — объект с полем user.
+type envelope struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+var errNoUser = errors.New("в событии нет ID пользователя")
+
+func parseEvent(body []byte) (Event, error) {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return Event{}, err
+	}
+	var data struct {
+		userData
+		User *userData `json:"user"`
+	}
+	if len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, &data); err != nil {
+			return Event{}, err
+		}
+	}
+	u := data.userData
+	if data.User != nil {
+		u = *data.User
+	}
+	if u.ID <= 0 {
+		return Event{Name: env.Event}, errNoUser
+	}
+	return Event{Name: env.Event, UserID: u.ID, Username: u.Username}, nil
+}
+
+func (r *Receiver) verify(body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(r.secret))
+	mac.Write(body)
+	want := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(strings.ToLower(strings.TrimSpace(signature))))
+}
+
+func (r *Receiver) handle(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBody))
+	if err != nil {
+		received.WithLabelValues("bad_request").Inc()
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if !r.verify(body, req.Header.Get(signatureHeader)) {
+		received.WithLabelValues("invalid_signature").Inc()
+		r.logger.WithField("remote", req.RemoteAddr).Warn("Webhook панели: неверная подпись, запрос отклонён")
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	ev, err := parseEvent(body)
+	if errors.Is(err, errNoUser) {
+		// События не о пользователях (ноды, системные) limiter не интересуют.
+		received.WithLabelValues("ignored").Inc()
+		r.logger.WithField("event", ev.Name).Debug("Webhook панели: событие без пользователя пропущено")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		received.WithLabelValues("bad_request").Inc()
+		r.logger.WithError(err).Warn("Webhook панели: не удалось разобрать тело")
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Панель не ждёт долгой обработки, а обрыв соединения не должен
+	// прерывать её на полпути.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), handleTimeout)
+	defer cancel()
+	if err := r.handler(ctx, ev); err != nil {
+		received.WithLabelValues("failed").Inc()
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"event":  ev.Name,
+			"userID": ev.UserID,
+		}).Error("Webhook панели: ошибка обработки")
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	received.WithLabelValues("ok").Inc()
+	w.WriteHeader(http.StatusNoContent)
+}
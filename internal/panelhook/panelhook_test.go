@@ -0,0 +1,122 @@
+package panelhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+const testSecret = "panel-secret"
+
+func sign(body string) string {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestMux(handler Handler) *http.ServeMux {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	mux := http.NewServeMux()
+	New(testSecret, handler, logger).Register(mux)
+	return mux
+}
+
+func post(mux http.Handler, body, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set(signatureHeader, signature)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReceiver_UserEvent(t *testing.T) {
+	var got []Event
+	mux := newTestMux(func(_ context.Context, ev Event) error {
+		got = append(got, ev)
+		return nil
+	})
+
+	body := `{"scope":"user","event":"user.enabled","timestamp":"2026-01-01T00:00:00Z","data":{"id":42,"username":"alice","status":"ACTIVE"}}`
+	if rec := post(mux, body, sign(body)); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(got) != 1 || got[0] != (Event{Name: EventUserEnabled, UserID: 42, Username: "alice"}) {
+		t.Errorf("events = %+v", got)
+	}
+}
+
+func TestReceiver_HWIDEventCarriesNestedUser(t *testing.T) {
+	var got Event
+	mux := newTestMux(func(_ context.Context, ev Event) error {
+		got = ev
+		return nil
+	})
+
+	body := `{"scope":"user_hwid_devices","event":"user_hwid_devices.added","data":{"user":{"id":7,"username":"bob"},"hwidUserDevice":{"hwid":"abc"}}}`
+	if rec := post(mux, body, strings.ToUpper(sign(body))); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got.UserID != 7 || got.Name != "user_hwid_devices.added" {
+		t.Errorf("event = %+v", got)
+	}
+}
+
+func TestReceiver_RejectsBadSignature(t *testing.T) {
+	called := false
+	mux := newTestMux(func(context.Context, Event) error {
+		called = true
+		return nil
+	})
+
+	body := `{"event":"user.deleted","data":{"id":42}}`
+	for _, signature := range []string{"", "deadbeef", sign(body + " ")} {
+		if rec := post(mux, body, signature); rec.Code != http.StatusUnauthorized {
+			t.Errorf("signature %q: status = %d, want 401", signature, rec.Code)
+		}
+	}
+	if called {
+		t.Error("handler must not be called for an unsigned request")
+	}
+}
+
+func TestReceiver_IgnoresEventsWithoutUser(t *testing.T) {
+	called := false
+	mux := newTestMux(func(context.Context, Event) error {
+		called = true
+		return nil
+	})
+
+	body := `{"scope":"node","event":"node.connection_lost","data":{"uuid":"n-1","name":"DE-1"}}`
+	if rec := post(mux, body, sign(body)); rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+	if called {
+		t.Error("node events must not reach the handler")
+	}
+}
+
+func TestReceiver_Errors(t *testing.T) {
+	mux := newTestMux(func(context.Context, Event) error { return errors.New("redis down") })
+
+	bad := `{"event":`
+	if rec := post(mux, bad, sign(bad)); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status = %d, want 400", rec.Code)
+	}
+
+	body := `{"event":"user.modified","data":{"id":1}}`
+	if rec := post(mux, body, sign(body)); rec.Code != http.StatusInternalServerError {
+		t.Errorf("handler error: status = %d, want 500", rec.Code)
+	}
+}
@@ -12,10 +12,15 @@ import (
 	"github.com/remnawave/limiter/internal/telegram"
 )
 
+// ChangeHandler вызывается после применения настройки с отображаемыми
+// значениями до и после изменения.
+type ChangeHandler func(ctx context.Context, key, oldValue, newValue string)
+
 type Manager struct {
 	provider *config.Provider
 	cache    *cache.Cache
 	envPath  string
+	onChange ChangeHandler
 
 	mu        sync.Mutex
 	overrides map[string]string
@@ -36,6 +41,25 @@ func NewManager(provider *config.Provider, c *cache.Cache, envPath string, overr
 	}
 }
 
+func (m *Manager) SetChangeHandler(handler ChangeHandler) {
+	m.onChange = handler
+}
+
+// store публикует новую конфигурацию и сообщает об изменившихся настройках.
+func (m *Manager) store(ctx context.Context, newCfg *config.Config) {
+	old := m.provider.Load()
+	m.provider.Store(newCfg)
+	if m.onChange == nil {
+		return
+	}
+	for _, f := range config.Registry() {
+		before, after := config.Display(old, f.Key), config.Display(newCfg, f.Key)
+		if before != after {
+			m.onChange(ctx, f.Key, before, after)
+		}
+	}
+}
+
 func mapKind(k config.Kind) telegram.SettingKind {
 	switch k {
 	case config.KindFloat:
@@ -112,7 +136,7 @@ func (m *Manager) Apply(ctx context.Context, key, raw string) (string, error) {
 				return "", fmt.Errorf("удаление из Redis: %w", err)
 			}
 			delete(m.overrides, key)
-			m.provider.Store(newCfg)
+			m.store(ctx, newCfg)
 			return config.Display(newCfg, key), nil
 		}
 	}
@@ -122,7 +146,7 @@ func (m *Manager) Apply(ctx context.Context, key, raw string) (string, error) {
 	}
 
 	m.overrides[key] = raw
-	m.provider.Store(newCfg)
+	m.store(ctx, newCfg)
 	return config.Display(newCfg, key), nil
 }
 
@@ -162,7 +186,7 @@ func (m *Manager) Reset(ctx context.Context, key string) (string, error) {
 	}
 
 	delete(m.overrides, key)
-	m.provider.Store(newCfg)
+	m.store(ctx, newCfg)
 	return config.Display(newCfg, key), nil
 }
 
@@ -180,7 +204,7 @@ func (m *Manager) ResetAll(ctx context.Context) error {
 	}
 
 	m.overrides = make(map[string]string)
-	m.provider.Store(newCfg)
+	m.store(ctx, newCfg)
 	return nil
 }
 
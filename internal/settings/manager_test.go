@@ -173,3 +173,29 @@ func TestManager_ResetAndResetAll(t *testing.T) {
 		t.Errorf("overrides not empty after ResetAll: %v", ov)
 	}
 }
+
+func TestManager_ChangeHandlerReportsChangedKeys(t *testing.T) {
+	mgr, _, _ := setupManager(t)
+	ctx := context.Background()
+
+	type change struct{ key, old, new string }
+	var got []change
+	mgr.SetChangeHandler(func(_ context.Context, key, oldValue, newValue string) {
+		got = append(got, change{key, oldValue, newValue})
+	})
+
+	if _, err := mgr.Apply(ctx, "COOLDOWN", "600"); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if len(got) != 1 || got[0] != (change{"COOLDOWN", "300", "600"}) {
+		t.Fatalf("changes after Apply = %+v", got)
+	}
+
+	// сброс к .env — тоже изменение
+	if _, err := mgr.Reset(ctx, "COOLDOWN"); err != nil {
+		t.Fatalf("Reset error: %v", err)
+	}
+	if len(got) != 2 || got[1] != (change{"COOLDOWN", "600", "300"}) {
+		t.Errorf("changes after Reset = %+v", got)
+	}
+}
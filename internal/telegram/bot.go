@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	tu "github.com/mymmrac/telego/telegoutil"
 	"github.com/sirupsen/logrus"
 
+	"github.com/remnawave/limiter/internal/audit"
 	"github.com/remnawave/limiter/internal/i18n"
 )
 
@@ -29,6 +31,22 @@ type ActionHandler func(ctx context.Context, action string, userID int64) error
 
 type StatsHandler func(ctx context.Context) (string, error)
 
+// UserCard — ответ на /user: готовый текст и параметры клавиатуры действий.
+type UserCard struct {
+	Text            string
+	UserID          int64
+	DisableDuration int
+	IgnoreDuration  int
+}
+
+type UserHandler func(ctx context.Context, query string) (*UserCard, error)
+
+var ErrUserNotFound = errors.New("пользователь не найден")
+
+// SubscriptionHandler обрабатывает /start (optOut=false) и /stop (optOut=true)
+// от подписчика в личке и возвращает текст ответа.
+type SubscriptionHandler func(ctx context.Context, telegramID int64, optOut bool) (string, error)
+
 func buildProxyHTTPClient(proxyURL string) (*http.Client, error) {
 	u, err := url.Parse(proxyURL)
 	if err != nil {
@@ -76,6 +94,8 @@ type Bot struct {
 	logger   *logrus.Logger
 	onAction ActionHandler
 	onStats  StatsHandler
+	onUser   UserHandler
+	onSub    SubscriptionHandler
 
 	sendMu sync.Mutex
 
@@ -135,6 +155,14 @@ func (b *Bot) SetStatsHandler(handler StatsHandler) {
 	b.onStats = handler
 }
 
+func (b *Bot) SetUserHandler(handler UserHandler) {
+	b.onUser = handler
+}
+
+func (b *Bot) SetSubscriptionHandler(handler SubscriptionHandler) {
+	b.onSub = handler
+}
+
 func (b *Bot) sendMsg(ctx context.Context, text string, keyboard *telego.InlineKeyboardMarkup) error {
 	msg := tu.Message(tu.ID(b.chatID), text).
 		WithParseMode(telego.ModeHTML).
@@ -160,7 +188,30 @@ func (b *Bot) sendMsg(ctx context.Context, text string, keyboard *telego.InlineK
 	return nil
 }
 
+// SendDirect отправляет сообщение в личный чат (например, подписчику), мимо
+// админского чата и топика.
+func (b *Bot) SendDirect(ctx context.Context, chatID int64, text string) error {
+	msg := tu.Message(tu.ID(chatID), text).
+		WithParseMode(telego.ModeHTML).
+		WithLinkPreviewOptions(&telego.LinkPreviewOptions{IsDisabled: true})
+
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if _, err := b.api.SendMessage(sendCtx, msg); err != nil {
+		return fmt.Errorf("не удалось отправить сообщение: %w", err)
+	}
+	return nil
+}
+
 func (b *Bot) SendManualAlert(ctx context.Context, text string, userID int64, disableDuration int, ignoreDuration int) error {
+	return b.sendMsg(ctx, text, manualKeyboard(userID, disableDuration, ignoreDuration))
+}
+
+func manualKeyboard(userID int64, disableDuration int, ignoreDuration int) *telego.InlineKeyboardMarkup {
 	rows := [][]telego.InlineKeyboardButton{
 		{
 			tu.InlineKeyboardButton(i18n.T("button.drop")).WithCallbackData(fmt.Sprintf("drop:%d", userID)),
@@ -186,8 +237,7 @@ func (b *Bot) SendManualAlert(ctx context.Context, text string, userID int64, di
 		})
 	}
 
-	keyboard := &telego.InlineKeyboardMarkup{InlineKeyboard: rows}
-	return b.sendMsg(ctx, text, keyboard)
+	return &telego.InlineKeyboardMarkup{InlineKeyboard: rows}
 }
 
 func (b *Bot) SendAutoAlert(ctx context.Context, text string, userID int64) error {
@@ -216,6 +266,7 @@ func botCommands() []telego.BotCommand {
 	return []telego.BotCommand{
 		{Command: "settings", Description: i18n.T("command.settings")},
 		{Command: "stats", Description: i18n.T("command.stats")},
+		{Command: "user", Description: i18n.T("command.user")},
 	}
 }
 
@@ -267,24 +318,56 @@ func (b *Bot) StartPolling(ctx context.Context) {
 }
 
 func (b *Bot) handleMessage(ctx context.Context, msg *telego.Message) {
-	if !b.adminIDs[msg.From.ID] {
+	text := strings.TrimSpace(msg.Text)
+	command, args, _ := strings.Cut(text, " ")
+
+	if msg.Chat.Type == telego.ChatTypePrivate && b.handleSubscriberCommand(ctx, msg, command) {
 		return
 	}
 
-	text := strings.TrimSpace(msg.Text)
+	if !b.adminIDs[msg.From.ID] {
+		return
+	}
 
-	switch strings.SplitN(text, "@", 2)[0] {
+	switch strings.SplitN(command, "@", 2)[0] {
 	case "/settings":
 		b.handleSettingsCommand(ctx, msg)
 		return
 	case "/stats":
 		b.handleStatsCommand(ctx, msg)
 		return
+	case "/user":
+		b.handleUserCommand(ctx, msg, strings.TrimSpace(args))
+		return
 	}
 
 	b.handlePendingInput(ctx, msg)
 }
 
+func (b *Bot) handleSubscriberCommand(ctx context.Context, msg *telego.Message, command string) bool {
+	if b.onSub == nil {
+		return false
+	}
+	var optOut bool
+	switch strings.SplitN(command, "@", 2)[0] {
+	case "/start":
+	case "/stop":
+		optOut = true
+	default:
+		return false
+	}
+
+	reply, err := b.onSub(ctx, msg.From.ID, optOut)
+	if err != nil {
+		b.logger.WithError(err).WithField("from", msg.From.ID).Error("Telegram бот: ошибка изменения подписки на уведомления")
+		return true
+	}
+	if reply != "" {
+		b.replyText(ctx, msg.Chat.ID, reply)
+	}
+	return true
+}
+
 func (b *Bot) handleStatsCommand(ctx context.Context, msg *telego.Message) {
 	if b.onStats == nil {
 		return
@@ -298,6 +381,44 @@ func (b *Bot) handleStatsCommand(ctx context.Context, msg *telego.Message) {
 	b.replyText(ctx, msg.Chat.ID, text)
 }
 
+func (b *Bot) handleUserCommand(ctx context.Context, msg *telego.Message, query string) {
+	if b.onUser == nil {
+		return
+	}
+	if query == "" {
+		b.replyText(ctx, msg.Chat.ID, i18n.T("user.usage"))
+		return
+	}
+	card, err := b.onUser(ctx, query)
+	if errors.Is(err, ErrUserNotFound) {
+		b.replyText(ctx, msg.Chat.ID, i18n.T("user.not_found"))
+		return
+	}
+	if err != nil {
+		b.logger.WithError(err).WithField("query", query).Error("Telegram бот: ошибка получения данных пользователя")
+		b.replyText(ctx, msg.Chat.ID, i18n.T("user.error"))
+		return
+	}
+	b.replyMarkup(ctx, msg.Chat.ID, card.Text, manualKeyboard(card.UserID, card.DisableDuration, card.IgnoreDuration))
+}
+
+// adminActor описывает администратора Telegram для аудита: @username, если
+// он есть, иначе имя.
+func adminActor(u telego.User) audit.Actor {
+	name := u.FirstName
+	if u.LastName != "" {
+		name += " " + u.LastName
+	}
+	if u.Username != "" {
+		name = "@" + u.Username
+	}
+	return audit.Actor{
+		Source: audit.SourceTelegram,
+		ID:     strconv.FormatInt(u.ID, 10),
+		Name:   name,
+	}
+}
+
 func (b *Bot) answerCallback(ctx context.Context, callbackID, text string) {
 	if err := b.api.AnswerCallbackQuery(ctx, &telego.AnswerCallbackQueryParams{
 		CallbackQueryID: callbackID,
@@ -339,16 +460,12 @@ func (b *Bot) handleCallback(ctx context.Context, callback *telego.CallbackQuery
 		return
 	}
 
-	adminName := callback.From.FirstName
-	if callback.From.LastName != "" {
-		adminName += " " + callback.From.LastName
-	}
-	if callback.From.Username != "" {
-		adminName = "@" + callback.From.Username
-	}
+	actor := adminActor(callback.From)
+	adminName := actor.Name
 
 	if b.onAction != nil {
-		if err := b.onAction(ctx, action, userID); err != nil {
+		actionCtx := audit.WithActor(ctx, actor)
+		if err := b.onAction(actionCtx, action, userID); err != nil {
 			b.logger.WithError(err).WithFields(logrus.Fields{
 				"action": action,
 				"userID": userID,
@@ -360,12 +477,6 @@ func (b *Bot) handleCallback(ctx context.Context, callback *telego.CallbackQuery
 		}
 	}
 
-	b.logger.WithFields(logrus.Fields{
-		"action": action,
-		"userID": userID,
-		"admin":  callerID,
-	}).Info("Telegram бот: действие выполнено администратором")
-
 	msg, _ := callback.Message.(*telego.Message)
 	if msg != nil {
 		originalText := msg.Text
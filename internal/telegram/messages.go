@@ -3,27 +3,47 @@ package telegram
 import (
 	"fmt"
 	"net/url"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/remnawave/limiter/internal/api"
 	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/geoip"
 	"github.com/remnawave/limiter/internal/i18n"
 )
 
 const asnOrgMaxLen = 40
 
-func FormatManualAlert(user *api.CachedUser, ips []api.ActiveIP, limit int, violationCount int64, loc *time.Location, subnetGroups int, subnetEnabled bool, asnGroups int, asnEnabled bool) string {
+// Grouping — как считались устройства: Mode (ip, subnet, asn, country, hybrid),
+// Count — групп устройств с учётом классов ASN, ModeCount — групп по самому
+// режиму (их подписывает строка лимита), Groups — разбивка IP по группам.
+type Grouping struct {
+	Mode      string
+	Count     int
+	ModeCount int
+	Groups    []DeviceGroup
+}
+
+type DeviceGroup struct {
+	Key string
+	IPs []string
+}
+
+func FormatManualAlert(user *api.CachedUser, ips []api.ActiveIP, limit int, violationCount int64, loc *time.Location, grouping Grouping, score *cache.Score) string {
 	var b strings.Builder
 
 	b.WriteString(i18n.T("alert.manual.title") + "\n\n")
 	b.WriteString(fmt.Sprintf("%s: <code>%s</code>\n", i18n.T("alert.user"), escapeHTML(user.Username)))
-	b.WriteString(formatGroupingLine(limit, subnetGroups, len(ips), countUniqueASN(ips), subnetEnabled, asnGroups, asnEnabled))
+	b.WriteString(formatGroupingLine(limit, len(ips), countUniqueASN(ips), grouping))
+	b.WriteString(formatScoreLine(score))
 	b.WriteString(fmt.Sprintf("%s: %d\n", i18n.T("alert.violations_24h"), violationCount))
 	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
 
 	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.ips_header")))
 	writeIPList(&b, ips)
+	writeGroupList(&b, grouping, len(ips))
 
 	if user.SubscriptionURL != "" {
 		b.WriteString(fmt.Sprintf("\n<a href=\"%s\">%s</a>", escapeHTML(user.SubscriptionURL), i18n.T("alert.profile")))
@@ -32,44 +52,200 @@ func FormatManualAlert(user *api.CachedUser, ips []api.ActiveIP, limit int, viol
 	return b.String()
 }
 
-func FormatAutoAlert(user *api.CachedUser, ips []api.ActiveIP, limit, durationMinutes int, violationCount int64, loc *time.Location, subnetGroups int, subnetEnabled bool, asnGroups int, asnEnabled bool) string {
+func FormatAutoAlert(user *api.CachedUser, ips []api.ActiveIP, limit, durationMinutes int, violationCount int64, loc *time.Location, grouping Grouping, score *cache.Score) string {
+	return formatAutoAlert(i18n.T("alert.auto.title"), nil, true, durationMinutes, user, ips, limit, violationCount, loc, grouping, score)
+}
+
+// EscalationInfo — выбранная ступень лестницы наказаний (Step считается с 1).
+type EscalationInfo struct {
+	Step        int
+	Total       int
+	Action      string
+	DurationMin int
+}
+
+func FormatEscalationAlert(user *api.CachedUser, ips []api.ActiveIP, limit int, esc EscalationInfo, violationCount int64, loc *time.Location, grouping Grouping, score *cache.Score) string {
+	title := i18n.T("alert.auto.title")
+	switch esc.Action {
+	case "warn":
+		title = i18n.T("alert.escalation.warn_title")
+	case "drop":
+		title = i18n.T("alert.escalation.drop_title")
+	}
+	return formatAutoAlert(title, &esc, esc.Action == "disable", esc.DurationMin, user, ips, limit, violationCount, loc, grouping, score)
+}
+
+func formatAutoAlert(title string, esc *EscalationInfo, disabled bool, durationMinutes int, user *api.CachedUser, ips []api.ActiveIP, limit int, violationCount int64, loc *time.Location, grouping Grouping, score *cache.Score) string {
 	var b strings.Builder
 
-	b.WriteString(i18n.T("alert.auto.title") + "\n\n")
+	b.WriteString(title + "\n\n")
 	b.WriteString(fmt.Sprintf("%s: <code>%s</code>\n", i18n.T("alert.user"), escapeHTML(user.Username)))
-	b.WriteString(formatGroupingLine(limit, subnetGroups, len(ips), countUniqueASN(ips), subnetEnabled, asnGroups, asnEnabled))
+	b.WriteString(formatGroupingLine(limit, len(ips), countUniqueASN(ips), grouping))
+	b.WriteString(formatScoreLine(score))
 	b.WriteString(fmt.Sprintf("%s: %d\n", i18n.T("alert.violations_24h"), violationCount))
 
-	if durationMinutes == 0 {
-		b.WriteString(fmt.Sprintf("%s: %s\n", i18n.T("alert.disabled_for"), i18n.T("alert.permanent")))
-	} else {
-		b.WriteString(fmt.Sprintf("%s: %d %s\n", i18n.T("alert.disabled_for"), durationMinutes, i18n.T("duration.min")))
+	if esc != nil {
+		b.WriteString(fmt.Sprintf("%s: %d/%d — %s\n", i18n.T("alert.escalation.step"), esc.Step, esc.Total, i18n.T("escalation."+esc.Action)))
+	}
+
+	if disabled {
+		if durationMinutes == 0 {
+			b.WriteString(fmt.Sprintf("%s: %s\n", i18n.T("alert.disabled_for"), i18n.T("alert.permanent")))
+		} else {
+			b.WriteString(fmt.Sprintf("%s: %d %s\n", i18n.T("alert.disabled_for"), durationMinutes, i18n.T("duration.min")))
+		}
 	}
 
 	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
 
 	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.ips_header")))
 	writeIPList(&b, ips)
+	writeGroupList(&b, grouping, len(ips))
 
 	return b.String()
 }
 
-func FormatSoftAlert(user *api.CachedUser, ips []api.ActiveIP, limit, banThreshold int, loc *time.Location, subnetGroups int, subnetEnabled bool, asnGroups int, asnEnabled bool) string {
+func FormatSoftAlert(user *api.CachedUser, ips []api.ActiveIP, limit, banThreshold int, loc *time.Location, grouping Grouping, score *cache.Score) string {
 	var b strings.Builder
 
 	b.WriteString(i18n.T("alert.soft.title") + "\n\n")
 	b.WriteString(fmt.Sprintf("%s: <code>%s</code>\n", i18n.T("alert.user"), escapeHTML(user.Username)))
-	b.WriteString(formatGroupingLine(limit, subnetGroups, len(ips), countUniqueASN(ips), subnetEnabled, asnGroups, asnEnabled))
+	b.WriteString(formatGroupingLine(limit, len(ips), countUniqueASN(ips), grouping))
+	b.WriteString(formatScoreLine(score))
 	b.WriteString(fmt.Sprintf("%s: %d\n", i18n.T("alert.ban_threshold"), banThreshold))
 	b.WriteString(i18n.T("alert.soft.note") + "\n")
 	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
 
 	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.ips_header")))
 	writeIPList(&b, ips)
+	writeGroupList(&b, grouping, len(ips))
 
 	return b.String()
 }
 
+// TravelInfo — пара IP для алерта о невозможном перемещении.
+type TravelInfo struct {
+	FromIP      string
+	FromCountry string
+	ToIP        string
+	ToCountry   string
+	DistanceKm  int
+}
+
+func FormatTravelAlert(user *api.CachedUser, ips []api.ActiveIP, travel TravelInfo, loc *time.Location) string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T("alert.travel.title") + "\n\n")
+	b.WriteString(fmt.Sprintf("%s: <code>%s</code>\n", i18n.T("alert.user"), escapeHTML(user.Username)))
+	b.WriteString(fmt.Sprintf("%s: %s (<code>%s</code>) → %s (<code>%s</code>), %d km\n", i18n.T("alert.travel.route"),
+		escapeHTML(travel.FromCountry), escapeHTML(travel.FromIP), escapeHTML(travel.ToCountry), escapeHTML(travel.ToIP), travel.DistanceKm))
+	b.WriteString(i18n.T("alert.travel.note") + "\n")
+	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
+
+	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.ips_header")))
+	writeIPList(&b, ips)
+
+	if user.SubscriptionURL != "" {
+		b.WriteString(fmt.Sprintf("\n<a href=\"%s\">%s</a>", escapeHTML(user.SubscriptionURL), i18n.T("alert.profile")))
+	}
+
+	return b.String()
+}
+
+// FormatResellerAlert — алерт VPN_RESELLER_ALERT: hostingCount IP пользователя
+// принадлежат хостинг-ASN (ASN_HOSTING).
+func FormatResellerAlert(user *api.CachedUser, ips []api.ActiveIP, hostingCount int, loc *time.Location) string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T("alert.reseller.title") + "\n\n")
+	b.WriteString(fmt.Sprintf("%s: <code>%s</code>\n", i18n.T("alert.user"), escapeHTML(user.Username)))
+	b.WriteString(fmt.Sprintf("%s: %d / %d\n", i18n.T("alert.reseller.hosting"), hostingCount, len(ips)))
+	b.WriteString(i18n.T("alert.reseller.note") + "\n")
+	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
+
+	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.ips_header")))
+	writeIPList(&b, ips)
+
+	if user.SubscriptionURL != "" {
+		b.WriteString(fmt.Sprintf("\n<a href=\"%s\">%s</a>", escapeHTML(user.SubscriptionURL), i18n.T("alert.profile")))
+	}
+
+	return b.String()
+}
+
+// FormatHWIDAlert — алерт HWID_CHECK: IP-групп (g.Count) больше, чем
+// устройств, зарегистрированных у пользователя в панели по HWID.
+func FormatHWIDAlert(user *api.CachedUser, ips []api.ActiveIP, devices []api.HWIDDevice, g Grouping, loc *time.Location) string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T("alert.hwid.title") + "\n\n")
+	b.WriteString(fmt.Sprintf("%s: <code>%s</code>\n", i18n.T("alert.user"), escapeHTML(user.Username)))
+	b.WriteString(fmt.Sprintf("%s: %d / %d\n", i18n.T("alert.hwid.groups"), g.Count, len(devices)))
+	b.WriteString(i18n.T("alert.hwid.note") + "\n")
+	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
+
+	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.hwid.devices")))
+	for _, dev := range devices {
+		b.WriteString("  • " + escapeHTML(hwidDeviceLabel(dev)) + "\n")
+	}
+
+	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.ips_header")))
+	writeIPList(&b, ips)
+	writeGroupList(&b, g, len(ips))
+
+	if user.SubscriptionURL != "" {
+		b.WriteString(fmt.Sprintf("\n<a href=\"%s\">%s</a>", escapeHTML(user.SubscriptionURL), i18n.T("alert.profile")))
+	}
+
+	return b.String()
+}
+
+// hwidDeviceLabel — «модель (платформа версия)»; без модели — User-Agent клиента.
+func hwidDeviceLabel(dev api.HWIDDevice) string {
+	platform := strings.TrimSpace(dev.Platform + " " + dev.OSVersion)
+	name := dev.DeviceModel
+	if name == "" {
+		name = dev.UserAgent
+	}
+	switch {
+	case name != "" && platform != "":
+		return name + " (" + platform + ")"
+	case name != "":
+		return name
+	case platform != "":
+		return platform
+	}
+	return "?"
+}
+
+// FormatNodeDownAlert — нода не ответила failures опросов подряд
+// (NODE_FAILURE_ALERT_AFTER). staleUntil — до какого момента в проверку идёт
+// её прошлый результат; нулевое — результата нет.
+func FormatNodeDownAlert(name, uuid string, failures int, lastOK, staleUntil time.Time, loc *time.Location) string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T("node.down.title") + "\n\n")
+	b.WriteString(fmt.Sprintf("%s: <b>%s</b>\n", i18n.T("node.down.node"), escapeHTML(name)))
+	b.WriteString(fmt.Sprintf("UUID: <code>%s</code>\n", escapeHTML(uuid)))
+	b.WriteString(fmt.Sprintf("%s: %d\n", i18n.T("node.down.failures"), failures))
+	if !lastOK.IsZero() {
+		b.WriteString(fmt.Sprintf("%s: %s\n", i18n.T("node.down.last_ok"), lastOK.In(loc).Format("02.01.2006 15:04:05")))
+	}
+	if !staleUntil.IsZero() && time.Now().Before(staleUntil) {
+		b.WriteString(fmt.Sprintf(i18n.T("node.down.stale_until"), staleUntil.In(loc).Format("15:04:05")) + "\n")
+	} else {
+		b.WriteString(i18n.T("node.down.no_data") + "\n")
+	}
+	b.WriteString(fmt.Sprintf("🕐 %s\n", time.Now().In(loc).Format("02.01.2006 15:04:05")))
+
+	return b.String()
+}
+
+// FormatNodeRecovered — нода снова отвечает после алерта FormatNodeDownAlert.
+func FormatNodeRecovered(name string, failures int) string {
+	return fmt.Sprintf(i18n.T("node.recovered"), escapeHTML(name), failures)
+}
+
 func FormatStats(stats *cache.ViolationStats, loc *time.Location) string {
 	return formatStatsMessage(i18n.T("stats.title"), stats, loc)
 }
@@ -78,6 +254,52 @@ func FormatDailyReport(stats *cache.ViolationStats, loc *time.Location) string {
 	return formatStatsMessage(i18n.T("stats.daily_title"), stats, loc)
 }
 
+const shadowUsersShown = 10
+
+// FormatShadowReport — суточный отчёт shadow-режима: кого кандидатный конфиг
+// (overrides) отметил бы иначе, чем живой.
+func FormatShadowReport(day string, overrides map[string]string, diff map[string][]cache.ShadowUser) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("%s — %s\n\n", i18n.T("shadow.title"), day))
+
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b.WriteString(i18n.T("shadow.config") + ":\n")
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf("  <code>%s=%s</code>\n", escapeHTML(k), escapeHTML(overrides[k])))
+	}
+
+	empty := true
+	for _, kind := range cache.ShadowKinds {
+		users := diff[kind]
+		if len(users) == 0 {
+			continue
+		}
+		empty = false
+		b.WriteString(fmt.Sprintf("\n%s: <b>%d</b>\n", i18n.T("shadow."+kind), len(users)))
+		for i, u := range users {
+			if i >= shadowUsersShown {
+				b.WriteString(fmt.Sprintf("  … %s %d\n", i18n.T("alert.and_more"), len(users)-shadowUsersShown))
+				break
+			}
+			name := u.Username
+			if name == "" {
+				name = fmt.Sprintf("%d", u.UserID)
+			}
+			b.WriteString(fmt.Sprintf("  • <code>%s</code> (ID %d)\n", escapeHTML(name), u.UserID))
+		}
+	}
+	if empty {
+		b.WriteString("\n" + i18n.T("shadow.no_diff") + "\n")
+	}
+
+	return b.String()
+}
+
 func formatStatsMessage(title string, stats *cache.ViolationStats, loc *time.Location) string {
 	var b strings.Builder
 
@@ -104,6 +326,130 @@ func formatStatsMessage(title string, stats *cache.ViolationStats, loc *time.Loc
 	return b.String()
 }
 
+// UserStatus — всё, что лимитер знает о пользователе, для команды /user.
+// IPs берутся из последней проверки (SnapshotAt); нулевой SnapshotAt означает,
+// что проверок ещё не было.
+type UserStatus struct {
+	User          *api.CachedUser
+	IPs           []api.ActiveIP
+	SnapshotAt    time.Time
+	Limit         int
+	BanThreshold  int
+	LimitSource   string
+	State         *cache.UserState
+	Violations24h int64
+	History       []cache.HistoryEntry
+	Score         *cache.Score
+}
+
+const userHistoryShown = 5
+
+func FormatUserStatus(s *UserStatus, loc *time.Location) string {
+	var b strings.Builder
+	const tsFormat = "02.01.2006 15:04"
+
+	b.WriteString(i18n.T("user.title") + "\n\n")
+	b.WriteString(fmt.Sprintf("%s: <code>%s</code> (ID %d)\n", i18n.T("alert.user"), escapeHTML(s.User.Username), s.User.UserID))
+	b.WriteString(fmt.Sprintf("%s: <b>%s</b>\n", i18n.T("user.status"), escapeHTML(s.User.Status)))
+	b.WriteString(fmt.Sprintf("%s: %d (%s: %s) | %s: %d\n",
+		i18n.T("alert.limit"), s.Limit, i18n.T("user.limit_source"), escapeHTML(s.LimitSource),
+		i18n.T("alert.ban_threshold"), s.BanThreshold))
+	b.WriteString(fmt.Sprintf("%s: %d\n", i18n.T("alert.violations_24h"), s.Violations24h))
+
+	until := func(t time.Time) string {
+		return i18n.T("user.until") + " " + t.In(loc).Format(tsFormat)
+	}
+	whitelist := i18n.T("user.none")
+	if s.State.Whitelisted {
+		whitelist = i18n.T("user.forever")
+		if !s.State.WhitelistUntil.IsZero() {
+			whitelist = until(s.State.WhitelistUntil)
+		}
+	}
+	cooldown := i18n.T("user.none")
+	if !s.State.CooldownUntil.IsZero() {
+		cooldown = until(s.State.CooldownUntil)
+	}
+	restore := i18n.T("user.none")
+	if !s.State.RestoreAt.IsZero() {
+		restore = s.State.RestoreAt.In(loc).Format(tsFormat)
+	}
+	b.WriteString(fmt.Sprintf("%s: %s\n", i18n.T("user.whitelist"), whitelist))
+	b.WriteString(fmt.Sprintf("%s: %s\n", i18n.T("user.cooldown"), cooldown))
+	b.WriteString(fmt.Sprintf("%s: %s\n", i18n.T("user.restore_at"), restore))
+	if s.Score != nil {
+		b.WriteString(fmt.Sprintf("%s: %d/100 (%s)\n", i18n.T("user.score"), s.Score.Value, s.Score.At.In(loc).Format(tsFormat)))
+	}
+
+	if s.SnapshotAt.IsZero() {
+		b.WriteString(fmt.Sprintf("\n%s: %s\n", i18n.T("user.active_ips"), i18n.T("user.no_snapshot")))
+	} else {
+		b.WriteString(fmt.Sprintf("\n%s (%s %s): %d\n", i18n.T("user.active_ips"),
+			i18n.T("user.snapshot_at"), s.SnapshotAt.In(loc).Format("15:04:05"), len(s.IPs)))
+		if len(s.IPs) == 0 {
+			b.WriteString("  " + i18n.T("user.no_ips") + "\n")
+		}
+		writeIPList(&b, s.IPs)
+	}
+
+	if len(s.History) > 0 {
+		b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("user.history")))
+		for i, h := range s.History {
+			if i >= userHistoryShown {
+				b.WriteString(fmt.Sprintf("  … %s %d\n", i18n.T("alert.and_more"), len(s.History)-userHistoryShown))
+				break
+			}
+			b.WriteString(fmt.Sprintf("  • %s — %s\n", h.At.In(loc).Format(tsFormat), formatHistoryEntry(h)))
+		}
+	}
+
+	if s.User.SubscriptionURL != "" {
+		b.WriteString(fmt.Sprintf("\n<a href=\"%s\">%s</a>", escapeHTML(s.User.SubscriptionURL), i18n.T("alert.profile")))
+	}
+
+	return b.String()
+}
+
+func formatHistoryEntry(h cache.HistoryEntry) string {
+	if h.Reason == cache.ReasonImpossibleTravel {
+		countries := make([]string, 0, len(h.IPs))
+		for _, ip := range h.IPs {
+			if ip.Country != "" && !slices.Contains(countries, ip.Country) {
+				countries = append(countries, ip.Country)
+			}
+		}
+		return fmt.Sprintf("%s: %s → %s", i18n.T("user.history_travel"),
+			escapeHTML(strings.Join(countries, ", ")), escapeHTML(h.Action))
+	}
+	if h.Reason == cache.ReasonVPNReseller {
+		hosting := 0
+		for _, ip := range h.IPs {
+			if ip.ASNClass == geoip.ClassHosting {
+				hosting++
+			}
+		}
+		return fmt.Sprintf("%s: %d/%d IP → %s", i18n.T("user.history_reseller"),
+			hosting, len(h.IPs), escapeHTML(h.Action))
+	}
+	if h.Reason == cache.ReasonHWIDMismatch {
+		return fmt.Sprintf("%s: %d/%d → %s", i18n.T("user.history_hwid"),
+			h.DeviceCount, h.HWIDDevices, escapeHTML(h.Action))
+	}
+	if h.Kind == cache.HistoryViolation {
+		s := fmt.Sprintf("%s: %d/%d %s → %s", i18n.T("user.history_violation"),
+			h.DeviceCount, h.Limit, i18n.T("user.devices"), escapeHTML(h.Action))
+		if h.Score > 0 {
+			s += fmt.Sprintf(" (🎯 %d)", h.Score)
+		}
+		return s
+	}
+	s := escapeHTML(h.Action)
+	if h.Actor != "" {
+		s += " (" + escapeHTML(h.Actor) + ")"
+	}
+	return s
+}
+
 func writeIPList(b *strings.Builder, ips []api.ActiveIP) {
 	const maxIPs = 10
 	for i, ip := range ips {
@@ -112,13 +458,23 @@ func writeIPList(b *strings.Builder, ips []api.ActiveIP) {
 			return
 		}
 		href := "https://ipinfo.io/" + url.PathEscape(ip.IP)
+		label := escapeHTML(ip.IP)
+		if ip.Country != "" {
+			label += " [" + escapeHTML(ip.Country) + "]"
+		}
+		switch ip.ASNClass {
+		case geoip.ClassMobile:
+			label += " 📱"
+		case geoip.ClassHosting:
+			label += " 🏢"
+		}
 		org := strings.TrimSpace(ip.ASNOrg)
 		if org != "" {
 			b.WriteString(fmt.Sprintf("  • <a href=\"%s\">%s</a> - %s (%s)\n",
-				escapeHTML(href), escapeHTML(ip.IP), escapeHTML(truncateASNOrg(org)), escapeHTML(ip.NodeName)))
+				escapeHTML(href), label, escapeHTML(truncateASNOrg(org)), escapeHTML(ip.NodeName)))
 		} else {
 			b.WriteString(fmt.Sprintf("  • <a href=\"%s\">%s</a> (%s)\n",
-				escapeHTML(href), escapeHTML(ip.IP), escapeHTML(ip.NodeName)))
+				escapeHTML(href), label, escapeHTML(ip.NodeName)))
 		}
 	}
 }
@@ -189,7 +545,7 @@ func FormatDuration(minutes int) string {
 	return fmt.Sprintf("%d %s %d %s %d %s", days, i18n.T("duration.day"), remHours, i18n.T("duration.hour"), mins, i18n.T("duration.min"))
 }
 
-func FormatStartupMessage(version, actionMode string, checkInterval, cooldown, tolerance int, toleranceMultiplier float64, defaultDeviceLimit, autoDisableDuration int, autoNotifySoft, webhookEnabled, subnetGrouping bool, subnetPrefixV4 int, asnGrouping, maxmindLoaded bool, violationThreshold, violationThresholdWindow int) string {
+func FormatStartupMessage(version, actionMode string, checkInterval, cooldown, tolerance int, toleranceMultiplier float64, defaultDeviceLimit, autoDisableDuration int, autoNotifySoft, webhookEnabled, subnetGrouping bool, subnetPrefixV4, subnetPrefixV6 int, asnGrouping, countryGrouping, hybridGrouping, maxmindLoaded bool, violationThreshold, violationThresholdWindow int) string {
 	var b strings.Builder
 
 	b.WriteString(i18n.T("startup.title") + "\n\n")
@@ -238,10 +594,22 @@ func FormatStartupMessage(version, actionMode string, checkInterval, cooldown, t
 		subnetStatus = i18n.T("startup.enabled")
 	}
 	b.WriteString(fmt.Sprintf("🌐 %s: %s\n", i18n.T("startup.subnet_grouping"), subnetStatus))
-	if subnetGrouping && !asnGrouping {
-		b.WriteString(fmt.Sprintf("  ↳ /%d\n", subnetPrefixV4))
+
+	hybridStatus := i18n.T("startup.disabled")
+	if hybridGrouping {
+		hybridStatus = i18n.T("startup.enabled")
+	}
+	b.WriteString(fmt.Sprintf("🧩 %s: %s\n", i18n.T("startup.hybrid_grouping"), hybridStatus))
+	if !countryGrouping && (hybridGrouping || subnetGrouping && !asnGrouping) {
+		b.WriteString(fmt.Sprintf("  ↳ IPv4 /%d, IPv6 /%d\n", subnetPrefixV4, subnetPrefixV6))
 	}
 
+	countryStatus := i18n.T("startup.disabled")
+	if countryGrouping {
+		countryStatus = i18n.T("startup.enabled")
+	}
+	b.WriteString(fmt.Sprintf("🗺 %s: %s\n", i18n.T("startup.country_grouping"), countryStatus))
+
 	maxmindStatus := i18n.T("startup.maxmind_unavailable")
 	if maxmindLoaded {
 		maxmindStatus = i18n.T("startup.maxmind_loaded")
@@ -258,32 +626,91 @@ func FormatStartupMessage(version, actionMode string, checkInterval, cooldown, t
 	return b.String()
 }
 
-func formatGroupingLine(limit, subnetGroups, ipCount, asnCount int, subnetEnabled bool, asnGroups int, asnEnabled bool) string {
+func formatGroupingLine(limit, ipCount, asnCount int, g Grouping) string {
+	var groupsKey string
+	switch g.Mode {
+	case "country":
+		groupsKey = "alert.countries"
+	case "hybrid":
+		groupsKey = "alert.hybrid_groups"
+	case "asn":
+		groupsKey = "alert.asn_groups"
+	case "subnet":
+		if g.ModeCount > 0 && g.ModeCount < ipCount {
+			groupsKey = "alert.subnets"
+		}
+	}
+
 	var base string
-	switch {
-	case asnEnabled:
-		base = fmt.Sprintf("%s: %d | %s: %d | %s: %d IP",
-			i18n.T("alert.limit"), limit,
-			i18n.T("alert.asn_groups"), asnGroups,
-			i18n.T("alert.detected_ips"), ipCount)
-	case subnetEnabled && subnetGroups > 0 && subnetGroups < ipCount:
+	shown := ipCount
+	if groupsKey != "" {
+		shown = g.ModeCount
 		base = fmt.Sprintf("%s: %d | %s: %d | %s: %d IP",
 			i18n.T("alert.limit"), limit,
-			i18n.T("alert.subnets"), subnetGroups,
+			i18n.T(groupsKey), g.ModeCount,
 			i18n.T("alert.detected_ips"), ipCount)
-	default:
+	} else {
 		base = fmt.Sprintf("%s: %d | %s: %d IP",
 			i18n.T("alert.limit"), limit,
 			i18n.T("alert.detected_ips"), ipCount)
 	}
+	// Классы ASN изменили подсчёт — с лимитом сравнивается это число.
+	if g.Count > 0 && g.Count != shown {
+		base += fmt.Sprintf(" | %s: %d", i18n.T("alert.device_groups"), g.Count)
+	}
 
-	if !asnEnabled && asnCount > 0 {
+	if g.Mode != "asn" && g.Mode != "hybrid" && asnCount > 0 {
 		base += fmt.Sprintf(" (%d %s)", asnCount, i18n.T("alert.asn_count"))
 	}
 
 	return base + "\n"
 }
 
+// formatScoreLine — оценка SCORING и ненулевые сигналы; пусто, если оценки нет.
+func formatScoreLine(s *cache.Score) string {
+	if s == nil {
+		return ""
+	}
+	var parts []string
+	for _, sig := range []struct {
+		key    string
+		points int
+	}{
+		{"score.groups", s.Groups},
+		{"score.persistence", s.Persistence},
+		{"score.nodes", s.Nodes},
+		{"score.countries", s.Countries},
+		{"score.asn", s.ASN},
+		{"score.history", s.History},
+	} {
+		if sig.points > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", i18n.T(sig.key), sig.points))
+		}
+	}
+	line := fmt.Sprintf("%s: %d/100", i18n.T("alert.score"), s.Value)
+	if len(parts) > 0 {
+		line += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return line + "\n"
+}
+
+// writeGroupList выводит, какие IP попали в одно «устройство». Только если
+// группировка что-то объединила — иначе разбивка повторяет список IP.
+func writeGroupList(b *strings.Builder, g Grouping, ipCount int) {
+	if len(g.Groups) == 0 || len(g.Groups) >= ipCount {
+		return
+	}
+	const maxGroups = 10
+	b.WriteString(fmt.Sprintf("\n%s:\n", i18n.T("alert.groups_header")))
+	for i, dg := range g.Groups {
+		if i >= maxGroups {
+			b.WriteString(fmt.Sprintf("  … %s %d\n", i18n.T("alert.and_more"), len(g.Groups)-maxGroups))
+			return
+		}
+		b.WriteString(fmt.Sprintf("  • <code>%s</code>: %s\n", escapeHTML(dg.Key), escapeHTML(strings.Join(dg.IPs, ", "))))
+	}
+}
+
 func escapeHTML(s string) string {
 	s = strings.ReplaceAll(s, "&", "&amp;")
 	s = strings.ReplaceAll(s, "<", "&lt;")
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/remnawave/limiter/internal/api"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/geoip"
 	"github.com/remnawave/limiter/internal/i18n"
 )
 
@@ -31,7 +33,7 @@ func TestFormatManualAlert(t *testing.T) {
 	}
 	limit := 3
 
-	result := FormatManualAlert(user, ips, limit, 5, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, limit, 5, loc, Grouping{}, nil)
 
 	checks := []struct {
 		name string
@@ -69,7 +71,7 @@ func TestFormatManualAlert_IPWithoutASN_OmitsASNPart(t *testing.T) {
 	user := &api.CachedUser{UserID: 1, Username: "u"}
 	ips := []api.ActiveIP{{IP: "10.0.0.1", NodeName: "Local", NodeUUID: "n"}}
 
-	result := FormatManualAlert(user, ips, 1, 1, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, 1, 1, loc, Grouping{}, nil)
 
 	if !strings.Contains(result, "10.0.0.1</a> (Local)") {
 		t.Errorf("expected IP line without ASN part, got:\n%s", result)
@@ -84,7 +86,7 @@ func TestFormatManualAlert_IPWithASN_IncludesOrgDashFormat(t *testing.T) {
 	user := &api.CachedUser{UserID: 1, Username: "u"}
 	ips := []api.ActiveIP{{IP: "1.2.3.4", NodeName: "Chicago-1", NodeUUID: "n", ASN: 13335, ASNOrg: "Cloudflare, Inc."}}
 
-	result := FormatManualAlert(user, ips, 1, 1, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, 1, 1, loc, Grouping{}, nil)
 
 	wantSubstr := "1.2.3.4</a> - Cloudflare, Inc. (Chicago-1)"
 	if !strings.Contains(result, wantSubstr) {
@@ -104,7 +106,7 @@ func TestFormatAutoAlert(t *testing.T) {
 		{IP: "6.6.6.6", NodeName: "Node-UK", NodeUUID: "n6"},
 	}
 
-	result := FormatAutoAlert(user, ips, 2, 30, 3, loc, 0, false, 0, false)
+	result := FormatAutoAlert(user, ips, 2, 30, 3, loc, Grouping{}, nil)
 
 	checks := []struct{ name, want string }{
 		{"contains auto title", "автоматически отключена"},
@@ -127,7 +129,7 @@ func TestFormatAutoAlert_Permanent(t *testing.T) {
 	user := &api.CachedUser{UserID: 9, Username: "permuser"}
 	ips := []api.ActiveIP{{IP: "7.7.7.7", NodeName: "Node-JP", NodeUUID: "n7"}}
 
-	result := FormatAutoAlert(user, ips, 1, 0, 1, loc, 0, false, 0, false)
+	result := FormatAutoAlert(user, ips, 1, 0, 1, loc, Grouping{}, nil)
 
 	if !strings.Contains(result, "Перманентно") {
 		t.Errorf("expected 'Перманентно' for duration=0, got:\n%s", result)
@@ -142,7 +144,7 @@ func TestFormatManualAlert_English(t *testing.T) {
 	user := &api.CachedUser{UserID: 7, Username: "enuser"}
 	ips := []api.ActiveIP{{IP: "8.8.8.8", NodeName: "Node-US", NodeUUID: "n8"}}
 
-	result := FormatManualAlert(user, ips, 2, 1, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, 2, 1, loc, Grouping{}, nil)
 
 	checks := []struct{ name, want string }{
 		{"en title", "Device limit exceeded"},
@@ -159,6 +161,38 @@ func TestFormatManualAlert_English(t *testing.T) {
 	}
 }
 
+// Гибридный режим: заголовок с группами и разбивка IP по группам.
+func TestFormatManualAlert_HybridGroups(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "u"}
+	ips := []api.ActiveIP{
+		{IP: "1.1.1.1", NodeName: "A", ASN: 13335},
+		{IP: "1.1.1.2", NodeName: "A", ASN: 13335},
+		{IP: "10.0.0.5", NodeName: "A"},
+	}
+	grouping := Grouping{Mode: "hybrid", Count: 2, ModeCount: 2, Groups: []DeviceGroup{
+		{Key: "asn:13335", IPs: []string{"1.1.1.1", "1.1.1.2"}},
+		{Key: "subnet:10.0.0.0/24", IPs: []string{"10.0.0.5"}},
+	}}
+
+	result := FormatManualAlert(user, ips, 1, 1, time.UTC, grouping, nil)
+	for _, want := range []string{
+		"Групп (ASN/подсеть): 2 | Обнаружено: 3 IP\n",
+		"Группы устройств",
+		"<code>asn:13335</code>: 1.1.1.1, 1.1.1.2",
+		"<code>subnet:10.0.0.0/24</code>: 10.0.0.5",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q, got:\n%s", want, result)
+		}
+	}
+
+	// Ничего не объединено — разбивка не нужна.
+	grouping = Grouping{Mode: "hybrid", Count: 3, ModeCount: 3, Groups: []DeviceGroup{{Key: "a"}, {Key: "b"}, {Key: "c"}}}
+	if result := FormatManualAlert(user, ips, 1, 1, time.UTC, grouping, nil); strings.Contains(result, "Группы устройств") {
+		t.Errorf("unexpected group list:\n%s", result)
+	}
+}
+
 func TestFormatManualAlert_SubnetGroupingHeader(t *testing.T) {
 	loc := time.UTC
 	user := &api.CachedUser{UserID: 1, Username: "u"}
@@ -168,7 +202,7 @@ func TestFormatManualAlert_SubnetGroupingHeader(t *testing.T) {
 		{IP: "2.2.2.2", NodeName: "A", NodeUUID: "n", ASN: 24940},
 	}
 
-	result := FormatManualAlert(user, ips, 3, 1, loc, 2, true, 0, false)
+	result := FormatManualAlert(user, ips, 3, 1, loc, Grouping{Mode: "subnet", Count: 2, ModeCount: 2}, nil)
 
 	if !strings.Contains(result, "Подсетей: 2") {
 		t.Errorf("expected 'Подсетей: 2' in header, got:\n%s", result)
@@ -178,6 +212,30 @@ func TestFormatManualAlert_SubnetGroupingHeader(t *testing.T) {
 	}
 }
 
+// Мобильный ASN схлопнул подсети — подсети подписаны своим числом, а
+// устройства, которые сравниваются с лимитом, показаны отдельно.
+func TestFormatManualAlert_ASNClassesChangeCount(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "u"}
+	ips := []api.ActiveIP{
+		{IP: "1.1.1.1", NodeName: "A", NodeUUID: "n"},
+		{IP: "2.2.2.2", NodeName: "A", NodeUUID: "n"},
+		{IP: "3.3.3.3", NodeName: "A", NodeUUID: "n"},
+	}
+
+	result := FormatManualAlert(user, ips, 1, 1, time.UTC, Grouping{Mode: "subnet", Count: 1, ModeCount: 3}, nil)
+	if strings.Contains(result, "Подсетей") {
+		t.Errorf("subnets equal IPs, header should omit them, got:\n%s", result)
+	}
+	if !strings.Contains(result, "3 IP | Устройств: 1") {
+		t.Errorf("expected device count after IPs, got:\n%s", result)
+	}
+
+	result = FormatManualAlert(user, ips, 1, 1, time.UTC, Grouping{Mode: "subnet", Count: 2, ModeCount: 2}, nil)
+	if strings.Contains(result, "Устройств") {
+		t.Errorf("device count equals subnets, should be omitted, got:\n%s", result)
+	}
+}
+
 func TestFormatManualAlert_NoASN_OmitsCount(t *testing.T) {
 	loc := time.UTC
 	user := &api.CachedUser{UserID: 1, Username: "u"}
@@ -186,7 +244,7 @@ func TestFormatManualAlert_NoASN_OmitsCount(t *testing.T) {
 		{IP: "2.2.2.2", NodeName: "A", NodeUUID: "n"},
 	}
 
-	result := FormatManualAlert(user, ips, 1, 1, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, 1, 1, loc, Grouping{}, nil)
 
 	if strings.Contains(result, "ASN)") {
 		t.Errorf("ASN count suffix must be omitted when no IP has ASN, got:\n%s", result)
@@ -201,7 +259,7 @@ func TestFormatAutoAlert_ASNCount(t *testing.T) {
 		{IP: "6.6.6.6", NodeName: "Node-UK", NodeUUID: "n6", ASN: 24940},
 	}
 
-	result := FormatAutoAlert(user, ips, 2, 30, 3, loc, 0, false, 0, false)
+	result := FormatAutoAlert(user, ips, 2, 30, 3, loc, Grouping{}, nil)
 
 	if !strings.Contains(result, "2 IP (2 ASN)") {
 		t.Errorf("expected '2 IP (2 ASN)' in auto alert header, got:\n%s", result)
@@ -217,7 +275,7 @@ func TestFormatManualAlert_ASNGroupingHeader(t *testing.T) {
 		{IP: "2.2.2.2", NodeName: "A", NodeUUID: "n", ASN: 24940},
 	}
 
-	result := FormatManualAlert(user, ips, 9, 1, loc, 0, false, 2, true)
+	result := FormatManualAlert(user, ips, 9, 1, loc, Grouping{Mode: "asn", Count: 2, ModeCount: 2}, nil)
 
 	if !strings.Contains(result, "ASN-групп: 2") {
 		t.Errorf("expected 'ASN-групп: 2' in header, got:\n%s", result)
@@ -230,12 +288,25 @@ func TestFormatManualAlert_ASNGroupingHeader(t *testing.T) {
 	}
 }
 
+func TestFormatManualAlert_CountryGroupingHeader(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "u"}
+	ips := []api.ActiveIP{
+		{IP: "1.1.1.1", NodeName: "A", Country: "DE"},
+		{IP: "2.2.2.2", NodeName: "A", Country: "BR"},
+	}
+
+	result := FormatManualAlert(user, ips, 1, 1, time.UTC, Grouping{Mode: "country", Count: 2, ModeCount: 2}, nil)
+	if !strings.Contains(result, "Стран: 2 | Обнаружено: 2 IP") {
+		t.Errorf("expected country header, got:\n%s", result)
+	}
+}
+
 func TestFormatManualAlert_SubnetGroupingDisabled_NoSubnetLine(t *testing.T) {
 	loc := time.UTC
 	user := &api.CachedUser{UserID: 1, Username: "u"}
 	ips := []api.ActiveIP{{IP: "1.1.1.1", NodeName: "A", NodeUUID: "n"}}
 
-	result := FormatManualAlert(user, ips, 1, 1, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, 1, 1, loc, Grouping{}, nil)
 
 	if strings.Contains(result, "Подсетей") {
 		t.Errorf("subnetEnabled=false must not show 'Подсетей' in header, got:\n%s", result)
@@ -270,7 +341,7 @@ func TestFormatManualAlert_LongASNOrgIsTruncated(t *testing.T) {
 	longOrg := "Amazon Data Services Ireland Limited / AWS EMEA SARL"
 	ips := []api.ActiveIP{{IP: "52.0.0.1", NodeName: "N", NodeUUID: "n", ASN: 16509, ASNOrg: longOrg}}
 
-	result := FormatManualAlert(user, ips, 1, 1, loc, 0, false, 0, false)
+	result := FormatManualAlert(user, ips, 1, 1, loc, Grouping{}, nil)
 
 	if strings.Contains(result, longOrg) {
 		t.Errorf("full long org must not appear; expected truncated form, got:\n%s", result)
@@ -422,7 +493,7 @@ func TestFormatAlert_EscapesSubscriptionURL(t *testing.T) {
 		Username:        "user",
 		SubscriptionURL: `https://e.com/s?a=1"><b>x</b>`,
 	}
-	out := FormatManualAlert(user, nil, 3, 1, time.UTC, 0, false, 0, false)
+	out := FormatManualAlert(user, nil, 3, 1, time.UTC, Grouping{}, nil)
 
 	if strings.Contains(out, `"><b>x</b>`) {
 		t.Errorf("subscription URL broke out of the href attribute: %s", out)
@@ -442,3 +513,254 @@ func TestTruncateAnswer(t *testing.T) {
 		t.Errorf("truncateAnswer returned %d runes, want <= %d", len(runes), callbackAnswerMaxLen)
 	}
 }
+
+func TestFormatUserStatus_NoSnapshot(t *testing.T) {
+	s := &UserStatus{
+		User:         &api.CachedUser{UserID: 42, Username: "bob<", Status: "ACTIVE"},
+		Limit:        3,
+		BanThreshold: 4,
+		LimitSource:  "squad:vip",
+		State:        &cache.UserState{Whitelisted: true},
+	}
+	result := FormatUserStatus(s, time.UTC)
+
+	for _, want := range []string{"bob&lt;", "ID 42", "squad:vip", "навсегда", "ещё не было ни одной проверки"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatUserStatus_WithIPsAndHistory(t *testing.T) {
+	at := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	s := &UserStatus{
+		User:       &api.CachedUser{UserID: 7, Username: "alice", Status: "DISABLED"},
+		IPs:        []api.ActiveIP{{IP: "1.1.1.1", NodeName: "Node-DE"}},
+		SnapshotAt: at,
+		Limit:      2,
+		State:      &cache.UserState{CooldownUntil: at.Add(time.Hour), RestoreAt: at.Add(2 * time.Hour)},
+		History: []cache.HistoryEntry{
+			{At: at, Kind: cache.HistoryViolation, Action: "disabled", DeviceCount: 3, Limit: 2},
+		},
+	}
+	result := FormatUserStatus(s, time.UTC)
+
+	for _, want := range []string{"1.1.1.1", "(Node-DE)", "10:00:00", "02.01.2026 11:00", "02.01.2026 12:00", "disabled"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatEscalationAlert(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "bob"}
+	ips := []api.ActiveIP{{IP: "1.1.1.1", NodeName: "Node-DE"}}
+
+	drop := FormatEscalationAlert(user, ips, 1, EscalationInfo{Step: 2, Total: 5, Action: "drop"}, 2, time.UTC, Grouping{}, nil)
+	for _, want := range []string{"подключения сброшены", "Ступень эскалации: 2/5 — сброс подключений"} {
+		if !strings.Contains(drop, want) {
+			t.Errorf("expected drop alert to contain %q, got:\n%s", want, drop)
+		}
+	}
+	if strings.Contains(drop, "Отключена на") {
+		t.Errorf("drop alert must not mention disable duration, got:\n%s", drop)
+	}
+
+	disable := FormatEscalationAlert(user, ips, 1, EscalationInfo{Step: 3, Total: 5, Action: "disable", DurationMin: 30}, 3, time.UTC, Grouping{}, nil)
+	for _, want := range []string{"автоматически отключена", "3/5 — отключение", "Отключена на: 30"} {
+		if !strings.Contains(disable, want) {
+			t.Errorf("expected disable alert to contain %q, got:\n%s", want, disable)
+		}
+	}
+}
+
+func TestFormatShadowReport(t *testing.T) {
+	diff := map[string][]cache.ShadowUser{
+		cache.ShadowOnly:    {{UserID: 1, Username: "bob"}, {UserID: 2}},
+		cache.ShadowDisable: {{UserID: 1, Username: "bob"}},
+	}
+	result := FormatShadowReport("2026-01-02", map[string]string{"ACTION_MODE": "auto", "ASN_GROUPING": "true"}, diff)
+
+	for _, want := range []string{"2026-01-02", "ACTION_MODE=auto", "ASN_GROUPING=true", "Отмечены только кандидатом: <b>2</b>", "<code>bob</code> (ID 1)", "<code>2</code> (ID 2)", "Были бы отключены"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "только текущим") {
+		t.Errorf("empty kinds must be omitted, got:\n%s", result)
+	}
+
+	if empty := FormatShadowReport("2026-01-02", nil, map[string][]cache.ShadowUser{}); !strings.Contains(empty, "совпадают") {
+		t.Errorf("expected no-diff note, got:\n%s", empty)
+	}
+}
+
+func TestFormatTravelAlert(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "traveler"}
+	ips := []api.ActiveIP{
+		{IP: "1.1.1.1", NodeName: "Node-DE", Country: "RU"},
+		{IP: "2.2.2.2", NodeName: "Node-US", Country: "US"},
+	}
+
+	result := FormatTravelAlert(user, ips, TravelInfo{
+		FromIP: "1.1.1.1", FromCountry: "RU", ToIP: "2.2.2.2", ToCountry: "US", DistanceKm: 7510,
+	}, time.UTC)
+	for _, want := range []string{
+		"Невозможное перемещение",
+		"<code>traveler</code>",
+		"RU (<code>1.1.1.1</code>) → US (<code>2.2.2.2</code>), 7510 km",
+		"1.1.1.1 [RU]</a> (Node-DE)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatHistoryEntry_ImpossibleTravel(t *testing.T) {
+	got := formatHistoryEntry(cache.HistoryEntry{
+		Kind:   cache.HistoryAlert,
+		Action: "alert",
+		Reason: cache.ReasonImpossibleTravel,
+		IPs:    []cache.HistoryIP{{IP: "1.1.1.1", Country: "RU"}, {IP: "1.1.1.2", Country: "RU"}, {IP: "2.2.2.2", Country: "US"}},
+	})
+	if want := "перемещение: RU, US → alert"; got != want {
+		t.Errorf("formatHistoryEntry = %q, want %q", got, want)
+	}
+}
+
+func TestFormatResellerAlert(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "reseller"}
+	ips := []api.ActiveIP{
+		{IP: "1.1.1.1", NodeName: "Node-DE", ASNClass: geoip.ClassHosting},
+		{IP: "2.2.2.2", NodeName: "Node-DE", ASNClass: geoip.ClassHosting},
+		{IP: "3.3.3.3", NodeName: "Node-US", ASNClass: geoip.ClassMobile},
+	}
+
+	result := FormatResellerAlert(user, ips, 2, time.UTC)
+	for _, want := range []string{
+		"VPN-реселлер",
+		"<code>reseller</code>",
+		"2 / 3",
+		"1.1.1.1 🏢</a> (Node-DE)",
+		"3.3.3.3 📱</a> (Node-US)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestFormatHWIDAlert(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "hwid"}
+	ips := []api.ActiveIP{
+		{IP: "1.1.1.1", NodeName: "Node-DE"},
+		{IP: "1.1.1.2", NodeName: "Node-DE"},
+		{IP: "2.2.2.2", NodeName: "Node-US"},
+	}
+	devices := []api.HWIDDevice{
+		{HWID: "a1", Platform: "iOS", OSVersion: "17.4", DeviceModel: "iPhone15,2"},
+		{HWID: "b2", UserAgent: "v2rayNG/1.8"},
+	}
+	g := Grouping{Mode: "subnet", Count: 2, ModeCount: 2, Groups: []DeviceGroup{
+		{Key: "subnet:1.1.1.0/24", IPs: []string{"1.1.1.1", "1.1.1.2"}},
+		{Key: "ip:2.2.2.2", IPs: []string{"2.2.2.2"}},
+	}}
+
+	result := FormatHWIDAlert(user, ips, devices, g, time.UTC)
+	for _, want := range []string{
+		"HWID",
+		"<code>hwid</code>",
+		"2 / 2",
+		"• iPhone15,2 (iOS 17.4)",
+		"• v2rayNG/1.8",
+		"<code>subnet:1.1.1.0/24</code>: 1.1.1.1, 1.1.1.2",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestHWIDDeviceLabel(t *testing.T) {
+	cases := []struct {
+		dev  api.HWIDDevice
+		want string
+	}{
+		{api.HWIDDevice{DeviceModel: "Pixel 8", Platform: "Android", OSVersion: "14"}, "Pixel 8 (Android 14)"},
+		{api.HWIDDevice{Platform: "Windows"}, "Windows"},
+		{api.HWIDDevice{UserAgent: "Happ/1.0"}, "Happ/1.0"},
+		{api.HWIDDevice{HWID: "x"}, "?"},
+	}
+	for _, tc := range cases {
+		if got := hwidDeviceLabel(tc.dev); got != tc.want {
+			t.Errorf("hwidDeviceLabel(%+v) = %q, want %q", tc.dev, got, tc.want)
+		}
+	}
+}
+
+func TestFormatManualAlert_Score(t *testing.T) {
+	user := &api.CachedUser{UserID: 1, Username: "u"}
+	ips := []api.ActiveIP{{IP: "1.1.1.1", NodeName: "A"}, {IP: "2.2.2.2", NodeName: "B"}}
+	score := &cache.Score{Value: 65, Groups: 35, Persistence: 20, Nodes: 10}
+
+	result := FormatManualAlert(user, ips, 1, 1, time.UTC, Grouping{}, score)
+	if want := "Оценка: 65/100 (устройства 35, длительность 20, ноды 10)\n"; !strings.Contains(result, want) {
+		t.Errorf("expected %q, got:\n%s", want, result)
+	}
+
+	// Без SCORING строки нет.
+	if result := FormatManualAlert(user, ips, 1, 1, time.UTC, Grouping{}, nil); strings.Contains(result, "Оценка") {
+		t.Errorf("unexpected score line:\n%s", result)
+	}
+
+	entry := formatHistoryEntry(cache.HistoryEntry{Kind: cache.HistoryViolation, Action: "alert", DeviceCount: 2, Limit: 1, Score: 65})
+	if !strings.HasSuffix(entry, "→ alert (🎯 65)") {
+		t.Errorf("history entry = %q", entry)
+	}
+}
+
+func TestFormatNodeDownAlert(t *testing.T) {
+	lastOK := time.Now().Add(-2 * time.Minute)
+
+	// Прошлый результат ещё в ходу — указано, до какого времени.
+	result := FormatNodeDownAlert("JP <Tokyo>", "jp-1", 3, lastOK, lastOK.Add(5*time.Minute), time.UTC)
+	for _, want := range []string{
+		"<b>JP &lt;Tokyo&gt;</b>",
+		"<code>jp-1</code>",
+		": 3\n",
+		lastOK.UTC().Format("02.01.2006 15:04:05"),
+		lastOK.Add(5 * time.Minute).UTC().Format("15:04:05"),
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+
+	// Результата нет — предупреждение, что нода не проверяется.
+	result = FormatNodeDownAlert("JP", "jp-1", 3, time.Time{}, time.Time{}, time.UTC)
+	if !strings.Contains(result, "NODE_STALE_MAX_AGE") {
+		t.Errorf("expected no-data note, got:\n%s", result)
+	}
+}
+
+func TestFormatNodeRecovered(t *testing.T) {
+	result := FormatNodeRecovered("JP", 4)
+	if strings.Contains(result, "%!") || !strings.Contains(result, "<b>JP</b>") || !strings.Contains(result, "4") {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
+
+func TestFormatStartupMessage_Grouping(t *testing.T) {
+	hybrid := FormatStartupMessage("1.0", "manual", 60, 300, 0, 0, 3, 0, false, false, true, 24, 64, true, false, true, true, 1, 600)
+	for _, want := range []string{"Гибридная группировка: включён", "↳ IPv4 /24, IPv6 /64", "Группировка по странам: выключен"} {
+		if !strings.Contains(hybrid, want) {
+			t.Errorf("expected %q in:\n%s", want, hybrid)
+		}
+	}
+
+	country := FormatStartupMessage("1.0", "manual", 60, 300, 0, 0, 3, 0, false, false, true, 24, 64, false, true, false, true, 1, 600)
+	if !strings.Contains(country, "Группировка по странам: включён") || strings.Contains(country, "↳ IPv4") {
+		t.Errorf("country mode: unexpected message:\n%s", country)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
 
+	"github.com/remnawave/limiter/internal/audit"
 	"github.com/remnawave/limiter/internal/i18n"
 )
 
@@ -161,7 +162,7 @@ func (b *Bot) handlePendingInput(ctx context.Context, msg *telego.Message) bool
 	b.deleteMessage(ctx, msg.Chat.ID, msg.MessageID)
 
 	raw := strings.TrimSpace(msg.Text)
-	display, err := b.settings.Apply(ctx, pend.key, raw)
+	display, err := b.settings.Apply(audit.WithActor(ctx, adminActor(*msg.From)), pend.key, raw)
 	if err != nil {
 		b.replyText(ctx, pend.chatID, fmt.Sprintf("%s: %s", i18n.T("settings.apply_error"), err.Error()))
 		return true
@@ -177,12 +178,21 @@ func (b *Bot) handlePendingInput(ctx context.Context, msg *telego.Message) bool
 }
 
 func (b *Bot) replyText(ctx context.Context, chatID int64, text string) {
-	msg := tu.Message(tu.ID(chatID), text).WithParseMode(telego.ModeHTML)
+	b.replyMarkup(ctx, chatID, text, nil)
+}
+
+func (b *Bot) replyMarkup(ctx context.Context, chatID int64, text string, keyboard *telego.InlineKeyboardMarkup) {
+	msg := tu.Message(tu.ID(chatID), text).
+		WithParseMode(telego.ModeHTML).
+		WithLinkPreviewOptions(&telego.LinkPreviewOptions{IsDisabled: true})
 	if b.threadID != 0 && chatID == b.chatID {
 		msg = msg.WithMessageThreadID(int(b.threadID))
 	}
+	if keyboard != nil {
+		msg = msg.WithReplyMarkup(keyboard)
+	}
 	if _, err := b.api.SendMessage(ctx, msg); err != nil {
-		b.logger.WithError(err).Error("Telegram бот: ошибка отправки ответа настроек")
+		b.logger.WithError(err).Error("Telegram бот: ошибка отправки ответа")
 	}
 }
 
@@ -197,6 +207,7 @@ func (b *Bot) handleSettingsCallback(ctx context.Context, callback *telego.Callb
 	}
 
 	b.clearPending(callback.From.ID)
+	ctx = audit.WithActor(ctx, adminActor(callback.From))
 
 	var chatID int64
 	var messageID int
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+type endpoint struct {
+	config.WebhookEndpoint
+	client *Client
+}
+
+// Dispatcher рассылает событие во все endpoint'ы, чьи фильтры по событию и
+// пользователю ему соответствуют. При наличии outbox события сначала
+// записываются в Redis и доставляются воркером (см. Run), иначе — напрямую.
+type Dispatcher struct {
+	endpoints   []endpoint
+	outbox      Outbox
+	maxAttempts int
+	logger      *logrus.Logger
+}
+
+func NewDispatcher(endpoints []config.WebhookEndpoint, outbox Outbox, maxAttempts int, logger *logrus.Logger) *Dispatcher {
+	d := &Dispatcher{
+		endpoints:   make([]endpoint, len(endpoints)),
+		outbox:      outbox,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+	for i, e := range endpoints {
+		d.endpoints[i] = endpoint{
+			WebhookEndpoint: e,
+			client:          newEndpointClient(e, logger),
+		}
+	}
+	return d
+}
+
+func (d *Dispatcher) Send(ctx context.Context, payload *Payload) {
+	matched := d.match(payload)
+	if len(matched) == 0 {
+		return
+	}
+
+	var direct []endpoint
+	if d.outbox != nil {
+		for _, e := range matched {
+			data, contentType, err := render(e.Format, payload)
+			if err != nil {
+				d.logger.WithError(err).WithField("endpoint", e.Name).Error("Ошибка сериализации webhook payload")
+				continue
+			}
+			job := &cache.WebhookJob{Endpoint: e.Name, Event: payload.Event, ContentType: contentType, Body: data}
+			if err := d.outbox.EnqueueWebhook(ctx, job); err != nil {
+				d.logger.WithError(err).WithFields(logrus.Fields{
+					"endpoint": e.Name,
+					"event":    payload.Event,
+				}).Warn("Outbox недоступен, webhook отправляется напрямую")
+				direct = append(direct, e)
+			}
+		}
+	} else {
+		direct = matched
+	}
+
+	var wg sync.WaitGroup
+	for _, e := range direct {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			c.Send(ctx, payload)
+		}(e.client)
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) match(payload *Payload) []endpoint {
+	user := payload.User
+	var squads []string
+	if user != nil {
+		squads = make([]string, 0, 2*len(user.Squads))
+		for _, sq := range user.Squads {
+			squads = append(squads, sq.UUID, sq.Name)
+		}
+	}
+
+	var out []endpoint
+	for _, e := range d.endpoints {
+		if !e.WantsEvent(payload.Event) {
+			continue
+		}
+		// События без пользователя (config_changed) фильтр пользователей не отсекает.
+		if user != nil && !e.WantsUser(user.UserID, user.Tag, squads) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func (d *Dispatcher) endpoint(name string) *Client {
+	for _, e := range d.endpoints {
+		if e.Name == name {
+			return e.client
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/i18n"
+)
+
+const (
+	contentTypeJSON        = "application/json"
+	contentTypeCloudEvents = "application/cloudevents+json; charset=utf-8"
+
+	cloudEventsSource     = "/remnawave/limiter"
+	cloudEventsTypePrefix = "io.remnawave.limiter."
+
+	// chatMaxIPs — сколько IP перечислять в Slack/Discord; у Discord поле
+	// embed ограничено 1024 символами.
+	chatMaxIPs = 10
+)
+
+// render сериализует payload в формате endpoint'а и возвращает Content-Type.
+func render(format string, p *Payload) ([]byte, string, error) {
+	var v any
+	contentType := contentTypeJSON
+	switch format {
+	case config.WebhookFormatCloudEvents:
+		v, contentType = newCloudEvent(p), contentTypeCloudEvents
+	case config.WebhookFormatSlack:
+		v = slackMessage(summarize(p))
+	case config.WebhookFormatDiscord:
+		v = discordMessage(summarize(p))
+	default:
+		v = p
+	}
+	data, err := json.Marshal(v)
+	return data, contentType, err
+}
+
+// cloudEvent — CloudEvents 1.0 в structured mode, исходный payload в data.
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            *Payload  `json:"data"`
+}
+
+func newCloudEvent(p *Payload) cloudEvent {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	ce := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          cloudEventsSource,
+		Type:            cloudEventsTypePrefix + p.Event,
+		Time:            p.Timestamp,
+		DataContentType: contentTypeJSON,
+		Data:            p,
+	}
+	if p.User != nil {
+		ce.Subject = "users/" + strconv.FormatInt(p.User.UserID, 10)
+	}
+	return ce
+}
+
+type chatField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+// chatSummary — человекочитаемое представление события для чат-форматов.
+type chatSummary struct {
+	Title     string
+	Color     int
+	Fields    []chatField
+	Timestamp time.Time
+}
+
+const (
+	colorRed    = 0xE74C3C
+	colorOrange = 0xF39C12
+	colorGreen  = 0x2ECC71
+	colorGrey   = 0x95A5A6
+)
+
+var eventColors = map[string]int{
+	config.EventViolationDetected:     colorRed,
+	config.EventSoftViolationDetected: colorOrange,
+	config.EventUserDisabled:          colorRed,
+	config.EventRestoreFailed:         colorRed,
+	config.EventConnectionsDropped:    colorOrange,
+	config.EventUserEnabled:           colorGreen,
+	config.EventUserRestoredByTimer:   colorGreen,
+}
+
+func summarize(p *Payload) chatSummary {
+	s := chatSummary{
+		Title:     i18n.T("webhook.event." + p.Event),
+		Color:     colorGrey,
+		Timestamp: p.Timestamp,
+	}
+	if c, ok := eventColors[p.Event]; ok {
+		s.Color = c
+	}
+	add := func(name, value string, inline bool) {
+		if value != "" {
+			s.Fields = append(s.Fields, chatField{Name: i18n.T(name), Value: value, Inline: inline})
+		}
+	}
+
+	if u := p.User; u != nil {
+		user := strconv.FormatInt(u.UserID, 10)
+		if u.Username != "" {
+			user = fmt.Sprintf("%s (%d)", u.Username, u.UserID)
+		}
+		add("webhook.field.user", user, true)
+	}
+
+	if v := p.Violation; v != nil && v.Travel != nil {
+		s.Title = i18n.T("webhook.event.impossible_travel")
+		add("webhook.field.travel", formatChatTravel(v.Travel), false)
+		add("webhook.field.ips", formatChatIPs(v.IPs), false)
+	} else if v != nil && v.Reason == cache.ReasonVPNReseller {
+		s.Title = i18n.T("webhook.event.vpn_reseller")
+		add("webhook.field.hosting_ips", fmt.Sprintf("%d / %d", v.HostingIPCount, v.IPCount), true)
+		add("webhook.field.ips", formatChatIPs(v.IPs), false)
+	} else if v != nil && v.Reason == cache.ReasonHWIDMismatch {
+		s.Title = i18n.T("webhook.event.hwid_mismatch")
+		add("webhook.field.hwid_devices", fmt.Sprintf("%d / %d", v.DeviceGroupCount, v.HWIDDeviceCount), true)
+		add("webhook.field.ips", formatChatIPs(v.IPs), false)
+		if len(v.Groups) > 0 && len(v.Groups) < v.IPCount {
+			add("webhook.field.groups", formatChatGroups(v.Groups), false)
+		}
+	} else if v != nil {
+		add("webhook.field.devices", fmt.Sprintf("%d / %d (%s: %d)", v.DeviceGroupCount, v.DeviceLimit, i18n.T("webhook.field.effective_limit"), v.EffectiveLimit), true)
+		add("webhook.field.violations_24h", strconv.FormatInt(v.ViolationCount24h, 10), true)
+		if v.Score != nil {
+			add("webhook.field.score", fmt.Sprintf("%d/100", v.Score.Value), true)
+		}
+		add("webhook.field.ips", formatChatIPs(v.IPs), false)
+		if len(v.Groups) > 0 && len(v.Groups) < v.IPCount {
+			add("webhook.field.groups", formatChatGroups(v.Groups), false)
+		}
+	}
+	// Ступени warn/drop ничего не отключают — длительности нет.
+	if a := p.Action; a != nil && p.ActionMode == "auto" && (a.Escalation == nil || a.Escalation.Action == config.EscalationDisable) {
+		add("webhook.field.duration", formatChatDuration(a.AutoDisableDurationMin, a.AutoDisableDurationMin == 0), true)
+	}
+
+	if a := p.Actor; a != nil {
+		actor := a.Source
+		if a.Name != "" {
+			actor = a.Name
+		}
+		if a.ID != "" {
+			actor += " (" + a.ID + ")"
+		}
+		add("webhook.field.actor", actor, true)
+	}
+	add("webhook.field.reason", p.Reason, true)
+	if d := p.Details; d != nil {
+		if d.DurationMin > 0 || d.Permanent {
+			add("webhook.field.duration", formatChatDuration(d.DurationMin, d.Permanent), true)
+		}
+		if d.Setting != "" {
+			add("webhook.field.setting", fmt.Sprintf("%s: %s → %s", d.Setting, d.OldValue, d.NewValue), false)
+		}
+		if d.Attempts > 0 {
+			add("webhook.field.attempts", strconv.Itoa(d.Attempts), true)
+		}
+		add("webhook.field.error", d.Error, false)
+	}
+	return s
+}
+
+func formatChatIPs(ips []IPPayload) string {
+	var b strings.Builder
+	for i, ip := range ips {
+		if i == chatMaxIPs {
+			fmt.Fprintf(&b, "… +%d", len(ips)-chatMaxIPs)
+			break
+		}
+		b.WriteString(ip.IP)
+		if ip.Country != "" {
+			b.WriteString(" [" + ip.Country + "]")
+		}
+		if ip.NodeName != "" {
+			b.WriteString(" — " + ip.NodeName)
+		}
+		if ip.ASNOrg != "" {
+			b.WriteString(" (" + ip.ASNOrg + ")")
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func formatChatGroups(groups []GroupPayload) string {
+	var b strings.Builder
+	for i, g := range groups {
+		if i == chatMaxIPs {
+			fmt.Fprintf(&b, "… +%d", len(groups)-chatMaxIPs)
+			break
+		}
+		b.WriteString(g.Key + ": " + strings.Join(g.IPs, ", ") + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func formatChatTravel(t *TravelPayload) string {
+	return fmt.Sprintf("%s (%s) → %s (%s), %d km", t.FromCountry, t.FromIP, t.ToCountry, t.ToIP, t.DistanceKm)
+}
+
+func formatChatDuration(durationMin int, permanent bool) string {
+	if permanent {
+		return i18n.T("alert.permanent")
+	}
+	switch {
+	case durationMin%(24*60) == 0:
+		return strconv.Itoa(durationMin/(24*60)) + "d"
+	case durationMin%60 == 0:
+		return strconv.Itoa(durationMin/60) + "h"
+	}
+	return strconv.Itoa(durationMin) + "m"
+}
+
+// slackMessage — сообщение для Slack incoming webhook: цветное вложение с
+// полями события.
+func slackMessage(s chatSummary) map[string]any {
+	fields := make([]map[string]any, len(s.Fields))
+	for i, f := range s.Fields {
+		fields[i] = map[string]any{"title": f.Name, "value": f.Value, "short": f.Inline}
+	}
+	return map[string]any{
+		"text": s.Title,
+		"attachments": []map[string]any{{
+			"color":  fmt.Sprintf("#%06X", s.Color),
+			"fields": fields,
+			"ts":     s.Timestamp.Unix(),
+		}},
+	}
+}
+
+// discordMessage — сообщение для Discord webhook с одним embed.
+func discordMessage(s chatSummary) map[string]any {
+	fields := make([]map[string]any, len(s.Fields))
+	for i, f := range s.Fields {
+		fields[i] = map[string]any{"name": f.Name, "value": f.Value, "inline": f.Inline}
+	}
+	return map[string]any{
+		"embeds": []map[string]any{{
+			"title":     s.Title,
+			"color":     s.Color,
+			"fields":    fields,
+			"timestamp": s.Timestamp.UTC().Format(time.RFC3339),
+		}},
+	}
+}
@@ -0,0 +1,259 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/remnawave/limiter/internal/audit"
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+	"github.com/remnawave/limiter/internal/i18n"
+)
+
+func decode(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON: %v: %s", err, data)
+	}
+	return v
+}
+
+func TestRender_DefaultIsPlainPayload(t *testing.T) {
+	for _, format := range []string{"", config.WebhookFormatJSON} {
+		data, contentType, err := render(format, testPayload())
+		if err != nil || contentType != contentTypeJSON {
+			t.Fatalf("render(%q) = %q, %v", format, contentType, err)
+		}
+		if v := decode(t, data); v["event"] != config.EventViolationDetected {
+			t.Errorf("render(%q) event = %v", format, v["event"])
+		}
+	}
+}
+
+func TestRender_CloudEvents(t *testing.T) {
+	data, contentType, err := render(config.WebhookFormatCloudEvents, testPayload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(contentType, "application/cloudevents+json") {
+		t.Errorf("content type = %q", contentType)
+	}
+	ce := decode(t, data)
+	if ce["specversion"] != "1.0" || ce["type"] != "io.remnawave.limiter.violation_detected" || ce["subject"] != "users/42" {
+		t.Errorf("envelope = %v", ce)
+	}
+	if id, _ := ce["id"].(string); len(id) != 32 {
+		t.Errorf("id = %v", ce["id"])
+	}
+	if ce["data"].(map[string]any)["user"].(map[string]any)["username"] != "testuser" {
+		t.Errorf("data = %v", ce["data"])
+	}
+
+	// у разных событий разные id
+	other, _, _ := render(config.WebhookFormatCloudEvents, testPayload())
+	if decode(t, other)["id"] == ce["id"] {
+		t.Error("CloudEvents id must be unique per event")
+	}
+}
+
+func TestRender_Slack(t *testing.T) {
+	i18n.SetLanguage("en")
+	defer i18n.SetLanguage("ru")
+
+	data, contentType, err := render(config.WebhookFormatSlack, testPayload())
+	if err != nil || contentType != contentTypeJSON {
+		t.Fatalf("render = %q, %v", contentType, err)
+	}
+	msg := decode(t, data)
+	if msg["text"] != i18n.T("webhook.event.violation_detected") {
+		t.Errorf("text = %v", msg["text"])
+	}
+	att := msg["attachments"].([]any)[0].(map[string]any)
+	if att["color"] != "#E74C3C" {
+		t.Errorf("color = %v", att["color"])
+	}
+	fields := att["fields"].([]any)
+	first := fields[0].(map[string]any)
+	if first["title"] != "User" || first["value"] != "testuser (42)" {
+		t.Errorf("first field = %v", first)
+	}
+	// в auto-режиме показывается срок отключения
+	last := fields[len(fields)-1].(map[string]any)
+	if last["title"] != "Duration" || last["value"] != "10m" {
+		t.Errorf("last field = %v", last)
+	}
+}
+
+func TestRender_DiscordLifecycle(t *testing.T) {
+	i18n.SetLanguage("en")
+	defer i18n.SetLanguage("ru")
+
+	p := &Payload{
+		Event:     config.EventConfigChanged,
+		Actor:     &audit.Actor{Source: audit.SourceTelegram, ID: "111", Name: "@admin"},
+		Details:   &LifecyclePayload{Setting: "COOLDOWN", OldValue: "300", NewValue: "600"},
+		Timestamp: testPayload().Timestamp,
+	}
+	data, _, err := render(config.WebhookFormatDiscord, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embed := decode(t, data)["embeds"].([]any)[0].(map[string]any)
+	if embed["title"] != i18n.T("webhook.event.config_changed") || embed["color"] != float64(colorGrey) {
+		t.Errorf("embed = %v", embed)
+	}
+	var values []string
+	for _, f := range embed["fields"].([]any) {
+		values = append(values, f.(map[string]any)["value"].(string))
+	}
+	got := strings.Join(values, "|")
+	if got != "@admin (111)|COOLDOWN: 300 → 600" {
+		t.Errorf("fields = %q", got)
+	}
+}
+
+func TestFormatChatIPs_Truncates(t *testing.T) {
+	ips := make([]IPPayload, chatMaxIPs+3)
+	for i := range ips {
+		ips[i] = IPPayload{IP: fmt.Sprintf("10.0.0.%d", i), NodeName: "DE-1"}
+	}
+	got := formatChatIPs(ips)
+	if lines := strings.Split(got, "\n"); len(lines) != chatMaxIPs+1 || lines[chatMaxIPs] != "… +3" {
+		t.Errorf("formatChatIPs = %q", got)
+	}
+}
+
+func TestFormatChatDuration(t *testing.T) {
+	tests := []struct {
+		min  int
+		want string
+	}{
+		{30, "30m"},
+		{120, "2h"},
+		{2880, "2d"},
+		{90, "90m"},
+	}
+	for _, tt := range tests {
+		if got := formatChatDuration(tt.min, false); got != tt.want {
+			t.Errorf("formatChatDuration(%d) = %q, want %q", tt.min, got, tt.want)
+		}
+	}
+}
+
+// Невозможное перемещение — отдельный заголовок и поле со странами вместо
+// счётчика устройств.
+func TestSummarize_ImpossibleTravel(t *testing.T) {
+	p := testPayload()
+	p.Violation.Reason = cache.ReasonImpossibleTravel
+	p.Violation.Travel = &TravelPayload{FromIP: "1.1.1.1", FromCountry: "RU", ToIP: "2.2.2.2", ToCountry: "US", DistanceKm: 7510}
+
+	s := summarize(p)
+	if s.Title != i18n.T("webhook.event.impossible_travel") {
+		t.Errorf("title = %q", s.Title)
+	}
+	var travel string
+	for _, f := range s.Fields {
+		if f.Name == i18n.T("webhook.field.devices") {
+			t.Errorf("unexpected devices field: %v", f)
+		}
+		if f.Name == i18n.T("webhook.field.travel") {
+			travel = f.Value
+		}
+	}
+	if want := "RU (1.1.1.1) → US (2.2.2.2), 7510 km"; travel != want {
+		t.Errorf("travel = %q, want %q", travel, want)
+	}
+}
+
+// VPN-реселлер — свой заголовок и число IP из хостинг-ASN.
+func TestSummarize_VPNReseller(t *testing.T) {
+	p := testPayload()
+	p.Violation.Reason = cache.ReasonVPNReseller
+	p.Violation.HostingIPCount = 2
+	p.Violation.IPCount = 3
+
+	s := summarize(p)
+	if s.Title != i18n.T("webhook.event.vpn_reseller") {
+		t.Errorf("title = %q", s.Title)
+	}
+	var hosting string
+	for _, f := range s.Fields {
+		if f.Name == i18n.T("webhook.field.hosting_ips") {
+			hosting = f.Value
+		}
+	}
+	if hosting != "2 / 3" {
+		t.Errorf("hosting = %q, want %q", hosting, "2 / 3")
+	}
+}
+
+func TestSummarize_HWIDMismatch(t *testing.T) {
+	p := testPayload()
+	p.Violation.Reason = cache.ReasonHWIDMismatch
+	p.Violation.DeviceGroupCount = 3
+	p.Violation.HWIDDeviceCount = 1
+
+	s := summarize(p)
+	if s.Title != i18n.T("webhook.event.hwid_mismatch") {
+		t.Errorf("title = %q", s.Title)
+	}
+	var hwid string
+	for _, f := range s.Fields {
+		if f.Name == i18n.T("webhook.field.hwid_devices") {
+			hwid = f.Value
+		}
+	}
+	if hwid != "3 / 1" {
+		t.Errorf("hwid = %q, want %q", hwid, "3 / 1")
+	}
+}
+
+// Разбивка по группам — только если группировка объединила IP.
+func TestSummarize_Groups(t *testing.T) {
+	p := testPayload()
+	p.Violation.IPCount = 3
+	p.Violation.Groups = []GroupPayload{
+		{Key: "asn:13335", IPs: []string{"1.1.1.1", "1.1.1.2"}},
+		{Key: "subnet:10.0.0.0/24", IPs: []string{"10.0.0.5"}},
+	}
+
+	var groups string
+	for _, f := range summarize(p).Fields {
+		if f.Name == i18n.T("webhook.field.groups") {
+			groups = f.Value
+		}
+	}
+	if want := "asn:13335: 1.1.1.1, 1.1.1.2\nsubnet:10.0.0.0/24: 10.0.0.5"; groups != want {
+		t.Errorf("groups = %q, want %q", groups, want)
+	}
+
+	p.Violation.IPCount = 2
+	for _, f := range summarize(p).Fields {
+		if f.Name == i18n.T("webhook.field.groups") {
+			t.Errorf("unexpected groups field: %v", f)
+		}
+	}
+}
+
+// Ступень warn ничего не отключает — длительности в сообщении нет.
+func TestSummarize_EscalationWarnHasNoDuration(t *testing.T) {
+	p := testPayload()
+	p.Action = &ActionPayload{Escalation: &EscalationPayload{Step: 1, Steps: 3, Action: config.EscalationWarn}}
+	for _, f := range summarize(p).Fields {
+		if f.Name == i18n.T("webhook.field.duration") {
+			t.Errorf("unexpected duration field: %v", f)
+		}
+	}
+
+	p.Action = &ActionPayload{AutoDisableDurationMin: 30, Escalation: &EscalationPayload{Step: 3, Steps: 3, Action: config.EscalationDisable, DurationMin: 30}}
+	var found bool
+	for _, f := range summarize(p).Fields {
+		found = found || f.Name == i18n.T("webhook.field.duration")
+	}
+	if !found {
+		t.Error("disable step should carry duration")
+	}
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/cache"
+)
+
+const (
+	outboxPollInterval = 2 * time.Second
+	outboxBatch        = 50
+	// outboxLease — на сколько откладывается взятое в работу задание. Должен
+	// быть больше таймаута HTTP-клиента, иначе задание уйдёт повторно.
+	outboxLease = time.Minute
+
+	retryBaseDelay = 10 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+var (
+	outboxPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_webhook_outbox_pending",
+		Help: "Webhook-события в outbox, ожидающие доставки.",
+	})
+	outboxDead = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "limiter_webhook_dead_letters",
+		Help: "Webhook-события в dead-letter.",
+	})
+)
+
+var errUnknownEndpoint = errors.New("endpoint отсутствует в конфигурации")
+
+type Outbox interface {
+	EnqueueWebhook(ctx context.Context, job *cache.WebhookJob) error
+	ClaimDueWebhooks(ctx context.Context, lease time.Duration, limit int) ([]cache.WebhookJob, error)
+	CompleteWebhook(ctx context.Context, id string) error
+	RetryWebhook(ctx context.Context, job *cache.WebhookJob, at time.Time) error
+	DeadLetterWebhook(ctx context.Context, job *cache.WebhookJob) error
+	WebhookOutboxLen(ctx context.Context) (pending, dead int64, err error)
+}
+
+// Run доставляет события из outbox до отмены ctx. Задание, прерванное
+// остановкой, остаётся в Redis и доставляется после перезапуска.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.outbox == nil {
+		return
+	}
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.processOutbox(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) processOutbox(ctx context.Context) {
+	jobs, err := d.outbox.ClaimDueWebhooks(ctx, outboxLease, outboxBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.WithError(err).Error("Ошибка чтения webhook outbox")
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(job *cache.WebhookJob) {
+			defer wg.Done()
+			d.deliver(ctx, job)
+		}(&jobs[i])
+	}
+	wg.Wait()
+
+	if pending, dead, err := d.outbox.WebhookOutboxLen(ctx); err == nil {
+		outboxPending.Set(float64(pending))
+		outboxDead.Set(float64(dead))
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job *cache.WebhookJob) {
+	log := d.logger.WithFields(logrus.Fields{
+		"endpoint": job.Endpoint,
+		"event":    job.Event,
+		"job":      job.ID,
+	})
+
+	client := d.endpoint(job.Endpoint)
+	if client == nil {
+		job.LastError = errUnknownEndpoint.Error()
+		d.deadLetter(ctx, job, log)
+		return
+	}
+
+	contentType := job.ContentType
+	if contentType == "" {
+		contentType = contentTypeJSON
+	}
+	retry, err := client.deliver(ctx, job.Body, contentType)
+	if err == nil {
+		deliveries.WithLabelValues(job.Endpoint, job.Event, "delivered").Inc()
+		if err := d.outbox.CompleteWebhook(ctx, job.ID); err != nil {
+			log.WithError(err).Warn("Webhook доставлен, но не удалён из outbox — возможна повторная доставка")
+		}
+		return
+	}
+	// Остановка сервиса: попытку не засчитываем, задание вернётся по истечении lease.
+	if ctx.Err() != nil {
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	if !retry {
+		deliveries.WithLabelValues(job.Endpoint, job.Event, "rejected").Inc()
+		d.deadLetter(ctx, job, log.WithError(err))
+		return
+	}
+	if job.Attempts >= d.maxAttempts {
+		deliveries.WithLabelValues(job.Endpoint, job.Event, "failed").Inc()
+		d.deadLetter(ctx, job, log.WithError(err))
+		return
+	}
+
+	delay := retryDelay(job.Attempts)
+	if err := d.outbox.RetryWebhook(ctx, job, time.Now().Add(delay)); err != nil {
+		log.WithError(err).Error("Не удалось перепланировать webhook")
+		return
+	}
+	deliveries.WithLabelValues(job.Endpoint, job.Event, "retried").Inc()
+	log.WithError(err).WithFields(logrus.Fields{
+		"attempt": job.Attempts,
+		"of":      d.maxAttempts,
+		"retryIn": delay,
+	}).Warn("Ошибка отправки webhook, повтор")
+}
+
+func (d *Dispatcher) deadLetter(ctx context.Context, job *cache.WebhookJob, log *logrus.Entry) {
+	if err := d.outbox.DeadLetterWebhook(ctx, job); err != nil {
+		log.WithError(err).Error("Не удалось перенести webhook в dead-letter")
+		return
+	}
+	log.WithField("attempts", job.Attempts).Error("Webhook не доставлен и перенесён в dead-letter")
+}
+
+// retryDelay — экспоненциальная задержка перед попыткой attempt+1:
+// 10с, 20с, 40с, … но не больше часа.
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
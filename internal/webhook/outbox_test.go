@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/remnawave/limiter/internal/cache"
+	"github.com/remnawave/limiter/internal/config"
+)
+
+type fakeOutbox struct {
+	mu         sync.Mutex
+	seq        int
+	jobs       map[string]*cache.WebhookJob
+	due        map[string]time.Time
+	dead       map[string]*cache.WebhookJob
+	enqueueErr error
+}
+
+func newFakeOutbox() *fakeOutbox {
+	return &fakeOutbox{
+		jobs: map[string]*cache.WebhookJob{},
+		due:  map[string]time.Time{},
+		dead: map[string]*cache.WebhookJob{},
+	}
+}
+
+func (f *fakeOutbox) EnqueueWebhook(_ context.Context, job *cache.WebhookJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.enqueueErr != nil {
+		return f.enqueueErr
+	}
+	f.seq++
+	job.ID = strconv.Itoa(f.seq)
+	cp := *job
+	f.jobs[job.ID] = &cp
+	f.due[job.ID] = time.Now()
+	return nil
+}
+
+func (f *fakeOutbox) ClaimDueWebhooks(_ context.Context, lease time.Duration, limit int) ([]cache.WebhookJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []cache.WebhookJob
+	now := time.Now()
+	for id, at := range f.due {
+		if len(out) == limit || at.After(now) {
+			continue
+		}
+		f.due[id] = now.Add(lease)
+		out = append(out, *f.jobs[id])
+	}
+	return out, nil
+}
+
+func (f *fakeOutbox) CompleteWebhook(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.jobs, id)
+	delete(f.due, id)
+	return nil
+}
+
+func (f *fakeOutbox) RetryWebhook(_ context.Context, job *cache.WebhookJob, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := *job
+	f.jobs[job.ID] = &cp
+	f.due[job.ID] = at
+	return nil
+}
+
+func (f *fakeOutbox) DeadLetterWebhook(_ context.Context, job *cache.WebhookJob) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := *job
+	f.dead[job.ID] = &cp
+	delete(f.jobs, job.ID)
+	delete(f.due, job.ID)
+	return nil
+}
+
+func (f *fakeOutbox) WebhookOutboxLen(context.Context) (int64, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.jobs)), int64(len(f.dead)), nil
+}
+
+// makeDue делает все задания доступными немедленно, минуя backoff
+func (f *fakeOutbox) makeDue() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id := range f.due {
+		f.due[id] = time.Now()
+	}
+}
+
+func statusServer(t *testing.T, status *int, hits *int) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*hits++
+		code := *status
+		mu.Unlock()
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOutbox_SendEnqueuesAndWorkerDelivers(t *testing.T) {
+	status, hits := http.StatusOK, 0
+	srv := statusServer(t, &status, &hits)
+	outbox := newFakeOutbox()
+	d := NewDispatcher([]config.WebhookEndpoint{
+		{Name: "a", URL: srv.URL},
+		{Name: "b", URL: srv.URL},
+	}, outbox, 3, testLogger())
+
+	d.Send(context.Background(), testPayload())
+	if hits != 0 {
+		t.Fatal("Send must only enqueue when outbox is available")
+	}
+	if len(outbox.jobs) != 2 {
+		t.Fatalf("expected one job per endpoint, got %d", len(outbox.jobs))
+	}
+
+	d.processOutbox(context.Background())
+	if hits != 2 || len(outbox.jobs) != 0 || len(outbox.dead) != 0 {
+		t.Errorf("hits=%d pending=%d dead=%d", hits, len(outbox.jobs), len(outbox.dead))
+	}
+}
+
+func TestOutbox_RetriesWithBackoffThenDeadLetters(t *testing.T) {
+	status, hits := http.StatusServiceUnavailable, 0
+	srv := statusServer(t, &status, &hits)
+	outbox := newFakeOutbox()
+	d := NewDispatcher([]config.WebhookEndpoint{{Name: "a", URL: srv.URL}}, outbox, 3, testLogger())
+
+	d.Send(context.Background(), testPayload())
+	d.processOutbox(context.Background())
+
+	job := outbox.jobs["1"]
+	if job == nil || job.Attempts != 1 || job.LastError == "" {
+		t.Fatalf("expected job rescheduled after first failure, got %+v", job)
+	}
+	if wait := time.Until(outbox.due["1"]); wait < retryBaseDelay-time.Second {
+		t.Errorf("retry scheduled too early: %v", wait)
+	}
+
+	// до истечения backoff задание не берётся
+	d.processOutbox(context.Background())
+	if hits != 1 {
+		t.Errorf("job delivered before backoff expired, hits=%d", hits)
+	}
+
+	for range 2 {
+		outbox.makeDue()
+		d.processOutbox(context.Background())
+	}
+	if hits != 3 || len(outbox.jobs) != 0 {
+		t.Fatalf("hits=%d pending=%d", hits, len(outbox.jobs))
+	}
+	if dead := outbox.dead["1"]; dead == nil || dead.Attempts != 3 {
+		t.Errorf("expected dead-letter after 3 attempts, got %+v", dead)
+	}
+}
+
+func TestOutbox_RejectedGoesStraightToDeadLetter(t *testing.T) {
+	status, hits := http.StatusBadRequest, 0
+	srv := statusServer(t, &status, &hits)
+	outbox := newFakeOutbox()
+	d := NewDispatcher([]config.WebhookEndpoint{{Name: "a", URL: srv.URL}}, outbox, 5, testLogger())
+
+	d.Send(context.Background(), testPayload())
+	d.processOutbox(context.Background())
+
+	if hits != 1 || len(outbox.dead) != 1 {
+		t.Errorf("4xx must not be retried: hits=%d dead=%d", hits, len(outbox.dead))
+	}
+}
+
+func TestOutbox_UnknownEndpointDeadLetters(t *testing.T) {
+	outbox := newFakeOutbox()
+	// задание осталось от endpoint'а, убранного из конфигурации до перезапуска
+	_ = outbox.EnqueueWebhook(context.Background(), &cache.WebhookJob{Endpoint: "removed", Event: config.EventViolationDetected})
+	d := NewDispatcher(nil, outbox, 5, testLogger())
+
+	d.processOutbox(context.Background())
+	if dead := outbox.dead["1"]; dead == nil || dead.LastError == "" {
+		t.Errorf("expected dead-letter with reason, got %+v", dead)
+	}
+}
+
+func TestOutbox_EnqueueFailureFallsBackToDirect(t *testing.T) {
+	status, hits := http.StatusOK, 0
+	srv := statusServer(t, &status, &hits)
+	outbox := newFakeOutbox()
+	outbox.enqueueErr = errors.New("redis down")
+	d := NewDispatcher([]config.WebhookEndpoint{{Name: "a", URL: srv.URL}}, outbox, 5, testLogger())
+
+	d.Send(context.Background(), testPayload())
+	if hits != 1 {
+		t.Errorf("expected direct delivery when outbox is down, hits=%d", hits)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestOutbox_JobRenderedPerEndpointFormat(t *testing.T) {
+	var gotType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+	}))
+	defer srv.Close()
+	outbox := newFakeOutbox()
+	d := NewDispatcher([]config.WebhookEndpoint{
+		{Name: "ce", URL: srv.URL, Format: config.WebhookFormatCloudEvents},
+	}, outbox, 3, testLogger())
+
+	d.Send(context.Background(), testPayload())
+	if job := outbox.jobs["1"]; job == nil || job.ContentType != contentTypeCloudEvents {
+		t.Fatalf("job = %+v", job)
+	}
+	d.processOutbox(context.Background())
+	if gotType != contentTypeCloudEvents {
+		t.Errorf("Content-Type = %q", gotType)
+	}
+}
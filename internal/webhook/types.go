@@ -1,22 +1,51 @@
 package webhook
 
-import "time"
+import (
+	"time"
 
+	"github.com/remnawave/limiter/internal/audit"
+)
+
+// Payload — тело webhook. Violation и Action есть только у событий
+// обнаружения, Actor, Reason и Details — у событий жизненного цикла, User —
+// у всех событий, кроме config_changed.
 type Payload struct {
-	Event      string           `json:"event"`
-	ActionMode string           `json:"action_mode"`
-	User       UserPayload      `json:"user"`
-	Violation  ViolationPayload `json:"violation"`
-	Action     ActionPayload    `json:"action"`
-	Timestamp  time.Time        `json:"timestamp"`
+	Event      string            `json:"event"`
+	ActionMode string            `json:"action_mode"`
+	User       *UserPayload      `json:"user,omitempty"`
+	Violation  *ViolationPayload `json:"violation,omitempty"`
+	Action     *ActionPayload    `json:"action,omitempty"`
+	Actor      *audit.Actor      `json:"actor,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Details    *LifecyclePayload `json:"details,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
 }
 
 type UserPayload struct {
-	UserID          int64  `json:"user_id"`
-	Username        string `json:"username"`
-	Email           string `json:"email,omitempty"`
-	TelegramID      int64  `json:"telegram_id,omitempty"`
-	SubscriptionURL string `json:"subscription_url,omitempty"`
+	UserID          int64          `json:"user_id"`
+	Username        string         `json:"username"`
+	Email           string         `json:"email,omitempty"`
+	TelegramID      int64          `json:"telegram_id,omitempty"`
+	SubscriptionURL string         `json:"subscription_url,omitempty"`
+	Tag             string         `json:"tag,omitempty"`
+	Squads          []SquadPayload `json:"squads,omitempty"`
+}
+
+type SquadPayload struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// LifecyclePayload — подробности события жизненного цикла; заполнены только
+// поля, относящиеся к событию.
+type LifecyclePayload struct {
+	DurationMin int    `json:"duration_min,omitempty"`
+	Permanent   bool   `json:"permanent,omitempty"`
+	Attempts    int    `json:"attempts,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Setting     string `json:"setting,omitempty"`
+	OldValue    string `json:"old_value,omitempty"`
+	NewValue    string `json:"new_value,omitempty"`
 }
 
 type IPPayload struct {
@@ -26,21 +55,86 @@ type IPPayload struct {
 	LastSeen time.Time `json:"last_seen"`
 	ASN      uint32    `json:"asn,omitempty"`
 	ASNOrg   string    `json:"asn_org,omitempty"`
+	// Country — страна клиента по GeoIP, NodeCountry — страна ноды.
+	Country     string `json:"country,omitempty"`
+	NodeCountry string `json:"node_country,omitempty"`
+	// ASNClass — класс ASN из ASN_MOBILE/ASN_RESIDENTIAL/ASN_HOSTING.
+	ASNClass string `json:"asn_class,omitempty"`
 }
 
 type ViolationPayload struct {
-	IPs               []IPPayload `json:"ips"`
-	IPCount           int         `json:"ip_count"`
-	DeviceLimit       int         `json:"device_limit"`
-	Tolerance         int         `json:"tolerance"`
-	EffectiveLimit    int         `json:"effective_limit"`
-	ViolationCount24h int64       `json:"violation_count_24h"`
-	SubnetCount       int         `json:"subnet_count,omitempty"`
-	ASNGroupCount     int         `json:"asn_group_count,omitempty"`
-	DeviceGroupCount  int         `json:"device_group_count"`
-	GroupingMode      string      `json:"grouping_mode"`
+	Reason            string         `json:"reason"`
+	IPs               []IPPayload    `json:"ips"`
+	IPCount           int            `json:"ip_count"`
+	DeviceLimit       int            `json:"device_limit"`
+	LimitSource       string         `json:"limit_source,omitempty"`
+	Tolerance         int            `json:"tolerance"`
+	EffectiveLimit    int            `json:"effective_limit"`
+	ViolationCount24h int64          `json:"violation_count_24h"`
+	SubnetCount       int            `json:"subnet_count,omitempty"`
+	SubnetPrefixV4    int            `json:"subnet_prefix_v4,omitempty"`
+	SubnetPrefixV6    int            `json:"subnet_prefix_v6,omitempty"`
+	ASNGroupCount     int            `json:"asn_group_count,omitempty"`
+	CountryGroupCount int            `json:"country_group_count,omitempty"`
+	DeviceGroupCount  int            `json:"device_group_count"`
+	GroupingMode      string         `json:"grouping_mode"`
+	Groups            []GroupPayload `json:"groups,omitempty"`
+	Travel            *TravelPayload `json:"travel,omitempty"`
+	HostingIPCount    int            `json:"hosting_ip_count,omitempty"`
+	HWIDDeviceCount   int            `json:"hwid_device_count,omitempty"`
+	HWIDDevices       []HWIDPayload  `json:"hwid_devices,omitempty"`
+	Score             *ScorePayload  `json:"score,omitempty"`
+}
+
+// ScorePayload — оценка SCORING (value, 0..100) и вклад каждого сигнала в баллах.
+type ScorePayload struct {
+	Value       int `json:"value"`
+	Groups      int `json:"groups"`
+	Persistence int `json:"persistence"`
+	Nodes       int `json:"nodes"`
+	Countries   int `json:"countries"`
+	ASN         int `json:"asn"`
+	History     int `json:"history"`
+}
+
+// HWIDPayload — устройство, зарегистрированное в панели по HWID. Сам HWID не
+// передаётся.
+type HWIDPayload struct {
+	Platform  string `json:"platform,omitempty"`
+	OSVersion string `json:"os_version,omitempty"`
+	Model     string `json:"model,omitempty"`
+}
+
+// GroupPayload — одно «устройство» и попавшие в него IP. Key: asn:<n>,
+// subnet:<cidr>, country:<cc>, mobile:<asn> или ip:<ip>.
+type GroupPayload struct {
+	Key string   `json:"key"`
+	IPs []string `json:"ips"`
+}
+
+// TravelPayload — два IP пользователя, одновременно активные в далёких друг
+// от друга странах.
+type TravelPayload struct {
+	FromIP      string `json:"from_ip"`
+	FromCountry string `json:"from_country"`
+	ToIP        string `json:"to_ip"`
+	ToCountry   string `json:"to_country"`
+	DistanceKm  int    `json:"distance_km"`
+	MinKm       int    `json:"min_km"`
 }
 
 type ActionPayload struct {
-	AutoDisableDurationMin int `json:"auto_disable_duration_min"`
+	AutoDisableDurationMin int                `json:"auto_disable_duration_min"`
+	Escalation             *EscalationPayload `json:"escalation,omitempty"`
+}
+
+// EscalationPayload — ступень ESCALATION_LADDER, выбранная для нарушения.
+// DurationMin == 0 у disable означает перманентное отключение.
+type EscalationPayload struct {
+	Step            int    `json:"step"`
+	Steps           int    `json:"steps"`
+	Action          string `json:"action"`
+	DurationMin     int    `json:"duration_min"`
+	PriorViolations int    `json:"prior_violations"`
+	WindowDays      int    `json:"window_days"`
 }
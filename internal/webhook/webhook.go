@@ -6,14 +6,17 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"io"
 	"net"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/config"
 )
 
 const (
@@ -21,9 +24,18 @@ const (
 	defaultRetryDelay = 2 * time.Second
 
 	maxDrainBytes = 4 << 10
+
+	defaultName = "default"
 )
 
+var deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "limiter_webhook_deliveries_total",
+	Help: "Исходы доставки webhook: delivered, rejected (4xx), failed.",
+}, []string{"endpoint", "event", "result"})
+
 type Client struct {
+	name       string
+	format     string
 	url        string
 	secret     string
 	httpClient *http.Client
@@ -32,9 +44,15 @@ type Client struct {
 }
 
 func NewClient(url, secret string, logger *logrus.Logger) *Client {
+	return newEndpointClient(config.WebhookEndpoint{Name: defaultName, URL: url, Secret: secret}, logger)
+}
+
+func newEndpointClient(e config.WebhookEndpoint, logger *logrus.Logger) *Client {
 	return &Client{
-		url:    url,
-		secret: secret,
+		name:   e.Name,
+		format: e.Format,
+		url:    e.URL,
+		secret: e.Secret,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 			Transport: &http.Transport{
@@ -55,20 +73,14 @@ func NewClient(url, secret string, logger *logrus.Logger) *Client {
 }
 
 func (c *Client) Send(ctx context.Context, payload *Payload) {
-	data, err := json.Marshal(payload)
+	data, contentType, err := render(c.format, payload)
 	if err != nil {
 		c.logger.WithError(err).Error("Ошибка сериализации webhook payload")
 		return
 	}
 
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-
-	var signature string
-	if c.secret != "" {
-		mac := hmac.New(sha256.New, []byte(c.secret))
-		mac.Write(data)
-		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
-	}
+	signature := c.sign(data)
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if attempt > 1 {
@@ -79,31 +91,59 @@ func (c *Client) Send(ctx context.Context, payload *Payload) {
 			}
 		}
 
-		retry, err := c.attempt(ctx, data, timestamp, signature)
+		retry, err := c.attempt(ctx, data, contentType, timestamp, signature)
 		if err == nil {
+			deliveries.WithLabelValues(c.name, payload.Event, "delivered").Inc()
 			return
 		}
 		if !retry || ctx.Err() != nil {
-			c.logger.WithError(err).WithField("event", payload.Event).Error("Webhook не доставлен")
+			if retry {
+				deliveries.WithLabelValues(c.name, payload.Event, "failed").Inc()
+			} else {
+				deliveries.WithLabelValues(c.name, payload.Event, "rejected").Inc()
+			}
+			c.logger.WithError(err).WithFields(logrus.Fields{
+				"endpoint": c.name,
+				"event":    payload.Event,
+			}).Error("Webhook не доставлен")
 			return
 		}
 		c.logger.WithError(err).WithFields(logrus.Fields{
-			"attempt": attempt,
-			"of":      maxAttempts,
-			"event":   payload.Event,
+			"attempt":  attempt,
+			"of":       maxAttempts,
+			"event":    payload.Event,
+			"endpoint": c.name,
 		}).Warn("Ошибка отправки webhook, повтор")
 	}
 
-	c.logger.WithField("event", payload.Event).Error("Webhook не доставлен: попытки исчерпаны")
+	deliveries.WithLabelValues(c.name, payload.Event, "failed").Inc()
+	c.logger.WithFields(logrus.Fields{
+		"endpoint": c.name,
+		"event":    payload.Event,
+	}).Error("Webhook не доставлен: попытки исчерпаны")
+}
+
+func (c *Client) sign(data []byte) string {
+	if c.secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(c.secret))
+	mac.Write(data)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver делает одну попытку доставки уже сериализованного тела.
+func (c *Client) deliver(ctx context.Context, data []byte, contentType string) (bool, error) {
+	return c.attempt(ctx, data, contentType, strconv.FormatInt(time.Now().Unix(), 10), c.sign(data))
 }
 
-func (c *Client) attempt(ctx context.Context, data []byte, timestamp, signature string) (bool, error) {
+func (c *Client) attempt(ctx context.Context, data []byte, contentType, timestamp, signature string) (bool, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
 	if err != nil {
 		return false, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", "remnawave-limiter")
 	req.Header.Set("X-Timestamp", timestamp)
 	if c.secret != "" {
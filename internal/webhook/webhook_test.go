@@ -10,10 +10,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/remnawave/limiter/internal/config"
 )
 
 func testLogger() *logrus.Logger {
@@ -26,12 +29,12 @@ func testPayload() *Payload {
 	return &Payload{
 		Event:      "violation_detected",
 		ActionMode: "auto",
-		User: UserPayload{
+		User: &UserPayload{
 			UserID:   42,
 			Username: "testuser",
 			Email:    "test@example.com",
 		},
-		Violation: ViolationPayload{
+		Violation: &ViolationPayload{
 			IPs: []IPPayload{
 				{IP: "1.1.1.1", NodeName: "DE-1", NodeUUID: "node-1", LastSeen: time.Now()},
 			},
@@ -41,7 +44,7 @@ func testPayload() *Payload {
 			EffectiveLimit:    4,
 			ViolationCount24h: 2,
 		},
-		Action: ActionPayload{
+		Action: &ActionPayload{
 			AutoDisableDurationMin: 10,
 		},
 		Timestamp: time.Now(),
@@ -244,3 +247,53 @@ func TestClient_Send_CancelledContext_StopsRetrying(t *testing.T) {
 		t.Errorf("expected no calls with cancelled context, got %d", calls)
 	}
 }
+
+func TestDispatcher_SendsOnlyToMatchingEndpoints(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	secrets := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		secrets[r.URL.Path] = r.Header.Get("X-Webhook-Secret")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]config.WebhookEndpoint{
+		{Name: "all", URL: srv.URL + "/all", Secret: "s-all"},
+		{Name: "soft", URL: srv.URL + "/soft", Events: []string{config.EventSoftViolationDetected}},
+		{Name: "vip", URL: srv.URL + "/vip", Secret: "s-vip", Squads: []string{"VIP"}},
+		{Name: "other", URL: srv.URL + "/other", UserIDs: []int64{1}},
+	}, nil, 1, testLogger())
+
+	payload := testPayload()
+	payload.User.Squads = []SquadPayload{{UUID: "sq-1", Name: "vip"}}
+	d.Send(context.Background(), payload)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hits["/all"] != 1 || hits["/vip"] != 1 {
+		t.Errorf("expected delivery to /all and /vip, got %v", hits)
+	}
+	if hits["/soft"] != 0 || hits["/other"] != 0 {
+		t.Errorf("filtered endpoints must not be called, got %v", hits)
+	}
+	// у каждого endpoint'а свой секрет
+	if secrets["/all"] != "s-all" || secrets["/vip"] != "s-vip" {
+		t.Errorf("unexpected secrets %v", secrets)
+	}
+}
+
+func TestDispatcher_EventWithoutUserBypassesUserFilter(t *testing.T) {
+	d := NewDispatcher([]config.WebhookEndpoint{
+		{Name: "vip", URL: "http://127.0.0.1", Squads: []string{"VIP"}},
+		{Name: "detections", URL: "http://127.0.0.1", Events: []string{config.EventViolationDetected}},
+	}, nil, 1, testLogger())
+
+	matched := d.match(&Payload{Event: config.EventConfigChanged})
+	if len(matched) != 1 || matched[0].Name != "vip" {
+		t.Errorf("matched = %+v, want only vip", matched)
+	}
+}